@@ -71,11 +71,11 @@ func (s *server) StartDeviceMonitoring() {
 	go s.processDeviceEvents()
 }
 
-// watchNetworkInterfaces monitors /sys/class/net for interface changes
+// watchNetworkInterfaces monitors <sysfs root>/class/net for interface changes
 func (s *server) watchNetworkInterfaces() {
-	if _, err := os.Stat("/sys/class/net"); err == nil {
+	if _, err := os.Stat(pkg.NetClassPath()); err == nil {
 		watcher := &DeviceWatcher{
-			path:     "/sys/class/net",
+			path:     pkg.NetClassPath(),
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -101,11 +101,11 @@ func (s *server) watchNetworkInterfaces() {
 	}
 }
 
-// watchPciDevices monitors /sys/bus/pci/devices for PCI changes
+// watchPciDevices monitors <sysfs root>/bus/pci/devices for PCI changes
 func (s *server) watchPciDevices() {
-	if _, err := os.Stat("/sys/bus/pci/devices"); err == nil {
+	if _, err := os.Stat(pkg.PciDevicesPath()); err == nil {
 		watcher := &DeviceWatcher{
-			path:     "/sys/bus/pci/devices",
+			path:     pkg.PciDevicesPath(),
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -133,9 +133,10 @@ func (s *server) watchPciDevices() {
 // watchDriverBindings monitors driver binding changes
 func (s *server) watchDriverBindings() {
 	// Monitor vfio driver specifically
-	if _, err := os.Stat("/sys/bus/pci/drivers/vfio-pci"); err == nil {
+	vfioPath := filepath.Join(pkg.PciDriversPath(), "vfio-pci")
+	if _, err := os.Stat(vfioPath); err == nil {
 		vfioWatcher := &DeviceWatcher{
-			path:     "/sys/bus/pci/drivers/vfio-pci",
+			path:     vfioPath,
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -160,9 +161,10 @@ func (s *server) watchDriverBindings() {
 	}
 
 	// Monitor Mellanox driver (mlx5_core is the actual driver being used)
-	if _, err := os.Stat("/sys/bus/pci/drivers/mlx5_core"); err == nil {
+	mlxPath := filepath.Join(pkg.PciDriversPath(), "mlx5_core")
+	if _, err := os.Stat(mlxPath); err == nil {
 		mlxWatcher := &DeviceWatcher{
-			path:     "/sys/bus/pci/drivers/mlx5_core",
+			path:     mlxPath,
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -415,7 +417,7 @@ func (s *server) refreshDeviceList() {
 	defer s.devicesLock.Unlock()
 
 	// Re-collect all device information
-	devices, err := pkg.ParseLshwDynamic()
+	devices, err := pkg.DiscoverNetworkDevices()
 	if err != nil {
 		pkg.WithError(err).Error("Failed to refresh devices")
 		return
@@ -666,9 +668,16 @@ func main() {
 		useFile  = flag.Bool("file", false, "Use static lshw file for testing (default: dynamic lshw)")
 		lshwFile = flag.String("lshw-file", "lshw-network.json", "Path to lshw JSON file (when using -file)")
 		debug    = flag.Bool("debug", false, "Enable debug logging")
+		sysfs    = flag.String("sysfs-root", pkg.DefaultSysfsRoot, "Root of the sysfs tree used for device discovery")
+		backend  = flag.String("discovery", pkg.DefaultDiscovery, "Device discovery backend: sysfs, lshw")
 	)
 	flag.Parse()
 
+	pkg.SetSysfsRoot(*sysfs)
+	if err := pkg.SetDiscoveryBackend(*backend); err != nil {
+		pkg.WithError(err).Fatal("Invalid discovery backend")
+	}
+
 	// Configure logging level based on debug flag
 	if *debug {
 		pkg.SetLogLevelFromString("debug")
@@ -699,16 +708,16 @@ func main() {
 			}
 		}
 	} else {
-		// Production mode: Run lshw dynamically
-		pkg.Info("Production mode: Running lshw -class network -json dynamically")
-		lshwStart := time.Now()
-		devices, err = pkg.ParseLshwDynamic()
+		// Production mode: Discover devices with the selected backend
+		pkg.WithField("backend", *backend).Info("Production mode: Discovering network devices")
+		discoveryStart := time.Now()
+		devices, err = pkg.DiscoverNetworkDevices()
 		if err != nil {
-			pkg.WithError(err).Error("Failed to run lshw")
+			pkg.WithError(err).Error("Failed to discover devices")
 			pkg.Info("Falling back to empty device list...")
 			devices = []pkg.Device{}
 		} else {
-			pkg.WithField("count", len(devices)).WithField("duration", time.Since(lshwStart)).Info("Successfully gathered devices from lshw")
+			pkg.WithField("count", len(devices)).WithField("duration", time.Since(discoveryStart)).Info("Successfully discovered devices")
 		}
 	}
 
@@ -1,33 +1,61 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"example.com/sriov-plugin/pkg"
 )
 
 func main() {
 	var (
-		configPath   = flag.String("config", "/etc/sriov-manager/config.json", "Path to configuration file")
+		configPath   = flag.String("config", "/etc/sriov-manager/config.json", "Path to configuration file (.json, .yaml, .yml or .toml)")
 		dryRun       = flag.Bool("dry-run", false, "Run in dry-run mode (don't make changes)")
 		validate     = flag.Bool("validate", false, "Validate configuration only")
 		discover     = flag.Bool("discover", false, "Discover devices only")
 		createConfig = flag.Bool("create-config", false, "Create default configuration file")
 		version      = flag.Bool("version", false, "Show version information")
+		printSchema  = flag.Bool("print-schema", false, "Print the JSON Schema of the configuration file")
+		migrate      = flag.Bool("migrate-config", false, "Rewrite the configuration file in the latest schema version, keeping a backup")
+		sysfsRoot    = flag.String("sysfs-root", pkg.DefaultSysfsRoot, "Root of the sysfs tree used for device discovery")
+		once         = flag.Bool("once", false, "Reconcile once and exit instead of running as a service")
+		interval     = flag.Duration("reconcile-interval", 5*time.Minute, "Interval between periodic reconciles")
+		statusFile   = flag.String("status-file", "", "Write per-device reconcile status as JSON to this file")
+		guardInUse   = flag.Bool("guard-in-use-vfs", false, "Refuse to change VF counts while VFs are bound to vfio-pci or up")
+		skipLAG      = flag.Bool("skip-lag-verification", false, "Accept VF-LAG bonds without checking that the hardware LAG engaged")
+		planFormat   = flag.String("plan-format", "text", "Dry-run plan output format: text, json")
+		discovery    = flag.String("discovery", pkg.DefaultDiscovery, "Device discovery backend: sysfs, lshw")
+		boot         = flag.Bool("boot", false, "Wait for configured PFs, apply the configuration and notify systemd once VFs exist")
+		bootState    = flag.String("boot-state", pkg.DefaultBootStatePath, "File recording the last applied configuration for --boot")
+		bootTimeout  = flag.Duration("boot-timeout", 60*time.Second, "How long --boot waits in total for PFs and VFs to appear")
 	)
 	flag.Parse()
 
+	// Point discovery and configuration at the requested sysfs tree
+	pkg.SetSysfsRoot(*sysfsRoot)
+	if err := pkg.SetDiscoveryBackend(*discovery); err != nil {
+		pkg.WithError(err).Fatal("Invalid discovery backend")
+	}
+
 	// Show version
 	if *version {
 		pkg.Info("SR-IOV Manager v1.0.0")
 		return
 	}
 
+	// Print the configuration schema for editors and CI
+	if *printSchema {
+		os.Stdout.Write(pkg.ConfigSchema())
+		return
+	}
+
 	// Create default config if requested
 	if *createConfig {
 		if err := createDefaultConfig(*configPath); err != nil {
@@ -37,15 +65,68 @@ func main() {
 		return
 	}
 
+	// Upgrade an old configuration file in place
+	if *migrate {
+		report, err := pkg.MigrateConfigFile(*configPath, *dryRun)
+		if report != nil {
+			for _, warning := range report.Warnings {
+				pkg.WithField("config", *configPath).Warn("Deprecated configuration: " + warning)
+			}
+		}
+		if err != nil {
+			pkg.WithError(err).Fatal("Failed to migrate configuration")
+		}
+		fields := map[string]interface{}{"config_path": *configPath, "version": report.ToVersion}
+		switch {
+		case !report.Migrated():
+			pkg.WithFields(fields).Info("Configuration is already at the latest version")
+		case *dryRun:
+			fields["from"] = report.FromVersion
+			pkg.WithFields(fields).Info("Configuration would be migrated (dry-run)")
+		default:
+			fields["from"] = report.FromVersion
+			fields["backup"] = report.BackupPath
+			pkg.WithFields(fields).Info("Configuration migrated")
+		}
+		return
+	}
+
+	// Load the state of the last boot, whose configuration stands in for an
+	// unreadable config file so VFs still come up
+	var state *pkg.BootState
+	if *boot {
+		var err error
+		if state, err = pkg.LoadBootState(*bootState); err != nil {
+			pkg.WithError(err).Warn("Ignoring boot state")
+			state = &pkg.BootState{}
+		}
+	}
+
 	// Load configuration
 	config, err := pkg.LoadConfig(*configPath)
+	if err != nil && state != nil && state.Config != nil {
+		pkg.WithError(err).Warn("Failed to load configuration, using the last applied configuration")
+		config, err = state.Config, nil
+	}
 	if err != nil {
 		pkg.WithError(err).Fatal("Failed to load configuration")
 	}
 
-	// Set dry-run mode if requested
-	if *dryRun {
-		config.DryRun = true
+	// Command line flags take precedence over the config file
+	applyOverrides := func(config *pkg.SRIOVConfig) {
+		if *dryRun {
+			config.DryRun = true
+		}
+		// Refuse VF resizes that would destroy in-use VFs if requested
+		if *guardInUse {
+			config.GuardInUseVFs = true
+		}
+		if *skipLAG {
+			config.SkipLAGVerification = true
+		}
+	}
+	applyOverrides(config)
+	if config.DryRun {
 		pkg.Info("Running in dry-run mode")
 	}
 
@@ -79,8 +160,68 @@ func main() {
 		return
 	}
 
+	// Boot must create the VFs before signalling readiness, which a dry run
+	// never does, so the notify unit would wait for its start timeout
+	if *boot && config.DryRun {
+		pkg.WithField("boot_state", *bootState).Fatal("--boot cannot be combined with dry-run; preview the boot plan with --dry-run alone")
+	}
+
+	// Plan a single reconcile without making changes
+	if config.DryRun {
+		if _, err := manager.Reconcile(); err != nil {
+			pkg.WithError(err).Fatal("Reconcile failed")
+		}
+		if err := printPlan(manager.Plan(), *planFormat); err != nil {
+			pkg.WithError(err).Fatal("Failed to print plan")
+		}
+		return
+	}
+
+	// Bring up VFs before network units start, then report readiness
+	if *boot {
+		statuses, err := manager.Boot(state, *bootTimeout)
+		if werr := writeStatusFile(*statusFile, statuses); werr != nil {
+			pkg.WithError(werr).Warn("Failed to write status file")
+		}
+		if err != nil {
+			sdNotify(fmt.Sprintf("STATUS=Boot failed: %v", err))
+			pkg.WithError(err).Fatal("Boot configuration failed")
+		}
+		if err := manager.SaveBootState(*bootState, statuses); err != nil {
+			pkg.WithError(err).Warn("Failed to save boot state")
+		}
+		sdNotify(fmt.Sprintf("READY=1\nSTATUS=%d devices configured", len(statuses)))
+		pkg.WithField("devices", len(statuses)).Info("Boot configuration applied")
+		if *once {
+			return
+		}
+	}
+
+	// Reconcile a single time if requested
+	if *once {
+		statuses, err := manager.Reconcile()
+		if err != nil {
+			pkg.WithError(err).Fatal("Reconcile failed")
+		}
+		if err := writeStatusFile(*statusFile, statuses); err != nil {
+			pkg.WithError(err).Fatal("Failed to write status file")
+		}
+		return
+	}
+
 	// Run as a service
-	if err := runAsService(manager); err != nil {
+	reload := func() error {
+		config, err := pkg.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+		applyOverrides(config)
+		return manager.UpdateConfig(config)
+	}
+	if !*boot {
+		*bootState = ""
+	}
+	if err := runAsService(manager, *interval, *statusFile, *bootState, reload); err != nil {
 		pkg.WithError(err).Fatal("Service failed")
 	}
 }
@@ -104,24 +245,93 @@ func createDefaultConfig(configPath string) error {
 	return nil
 }
 
-// runAsService runs the SR-IOV manager as a systemd service
-func runAsService(manager *pkg.SRIOVManager) error {
+// printPlan prints a dry-run plan in the requested format
+func printPlan(plan *pkg.Plan, format string) error {
+	switch format {
+	case "json":
+		out, err := plan.JSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+	case "text":
+		fmt.Println(plan.Text())
+	default:
+		return fmt.Errorf("invalid plan format: %s. Use: text or json", format)
+	}
+	return nil
+}
+
+// writeStatusFile writes reconcile status as JSON, doing nothing when path is empty
+func writeStatusFile(path string, statuses []pkg.DeviceReconcileStatus) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal status: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write status file: %v", err)
+	}
+
+	return nil
+}
+
+// sdNotify reports a state change to systemd, logging failures only
+func sdNotify(state string) {
+	if _, err := pkg.SdNotify(state); err != nil {
+		pkg.WithError(err).Warn("Failed to notify systemd")
+	}
+}
+
+// runAsService runs the SR-IOV manager as a systemd service, reconciling
+// devices on an interval and whenever the device tree changes. SIGHUP calls
+// reload to load and apply the configuration file again. Each clean pass is
+// recorded in bootState, unless it is empty.
+func runAsService(manager *pkg.SRIOVManager, interval time.Duration, statusFile, bootState string, reload func() error) error {
 	pkg.Info("Starting SR-IOV Manager service...")
 
-	// Set up signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	// Run the manager
+	// Set up signal handling for reload and graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	go func() {
-		if err := manager.Run(); err != nil {
-			pkg.WithError(err).Error("SR-IOV Manager failed")
+		for sig := range sigChan {
+			if sig == syscall.SIGHUP {
+				pkg.Info("Reloading configuration...")
+				sdNotify("RELOADING=1")
+				if err := reload(); err != nil {
+					pkg.WithError(err).Error("Configuration reload failed, keeping previous configuration")
+				}
+				sdNotify("READY=1")
+				continue
+			}
+			pkg.Info("Shutting down SR-IOV Manager service...")
+			sdNotify("STOPPING=1")
+			cancel()
+			return
 		}
 	}()
 
-	// Wait for shutdown signal
-	<-sigChan
-	pkg.Info("Shutting down SR-IOV Manager service...")
+	// Reconcile on device events as well as on the interval
+	events, err := pkg.WatchDeviceEvents(ctx)
+	if err != nil {
+		pkg.WithError(err).Warn("Device event watch unavailable, reconciling on interval only")
+	}
 
-	return nil
+	report := func(statuses []pkg.DeviceReconcileStatus) {
+		if err := writeStatusFile(statusFile, statuses); err != nil {
+			pkg.WithError(err).Warn("Failed to write status file")
+		}
+		if err := manager.SaveBootState(bootState, statuses); err != nil {
+			pkg.WithError(err).Debug("Boot state not updated")
+		}
+	}
+
+	return manager.RunReconcileLoop(ctx, interval, events, report)
 }
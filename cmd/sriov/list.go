@@ -144,6 +144,14 @@ func runList(cmd *cobra.Command, args []string) error {
 			deviceInfo.NUMADistance[int(node)] = int(distance)
 		}
 
+		// Add SR-IOV VF counts and inventory
+		deviceInfo.TotalVFs = int(d.TotalVfs)
+		deviceInfo.NumVFs = int(d.NumVfs)
+		deviceInfo.VFs = vfInfoFromProto(d.Vfs)
+		if e := d.Eswitch; e != nil {
+			deviceInfo.Eswitch = pkg.EswitchState{Mode: e.Mode, InlineMode: e.InlineMode, EncapMode: e.EncapMode}.String()
+		}
+
 		// Add detailed capabilities if available
 		if len(d.DetailedCapabilities) > 0 {
 			deviceInfo.DetailedCapabilities = make(map[string]DetailedCapabilityInfo)
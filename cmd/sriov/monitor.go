@@ -2,13 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"example.com/sriov-plugin/pkg"
 	"example.com/sriov-plugin/proto"
@@ -16,11 +18,13 @@ import (
 
 var (
 	// Monitor command flags
-	monitorServerAddr string
-	monitorTimeout    time.Duration
-	monitorInterval   time.Duration
-	monitorFormat     string
-	monitorLogLevel   string
+	monitorServerAddr     string
+	monitorTimeout        time.Duration
+	monitorInterval       time.Duration
+	monitorReconnectDelay time.Duration
+	monitorFormat         string
+	monitorLogLevel       string
+	monitorNoExisting     bool
 )
 
 var monitorCmd = &cobra.Command{
@@ -30,14 +34,15 @@ var monitorCmd = &cobra.Command{
 
 This command will:
   • Connect to the SR-IOV server
-  • Continuously monitor device changes
-  • Display updates in real-time
-  • Show device status changes
+  • Subscribe to the server's device event stream
+  • Print each added, removed or modified device as it happens
+  • Show which fields changed for modified devices
+  • Reconnect if the stream drops
 
 Examples:
   sriov monitor                    # Monitor with default settings
-  sriov monitor --interval 5s     # Update every 5 seconds
-  sriov monitor --format json     # Output in JSON format`,
+  sriov monitor --no-existing      # Only show changes, not the current devices
+  sriov monitor --format json     # One JSON event per line`,
 	RunE: runMonitor,
 }
 
@@ -48,8 +53,11 @@ func init() {
 	monitorCmd.Flags().StringVar(&monitorServerAddr, "server", "localhost:50051", "gRPC server address")
 	monitorCmd.Flags().DurationVar(&monitorTimeout, "timeout", 5*time.Second, "Connection timeout")
 	monitorCmd.Flags().DurationVar(&monitorInterval, "interval", 10*time.Second, "Monitoring interval")
+	monitorCmd.Flags().MarkDeprecated("interval", "device changes are now streamed as they happen")
+	monitorCmd.Flags().DurationVar(&monitorReconnectDelay, "reconnect-delay", 2*time.Second, "Delay before reconnecting when the event stream drops")
 	monitorCmd.Flags().StringVar(&monitorFormat, "format", "table", "Output format: table, json, simple")
 	monitorCmd.Flags().StringVar(&monitorLogLevel, "log-level", "warn", "Log level: debug, info, warn, error")
+	monitorCmd.Flags().BoolVar(&monitorNoExisting, "no-existing", false, "Do not print the devices present when monitoring starts")
 }
 
 func runMonitor(cmd *cobra.Command, args []string) error {
@@ -58,85 +66,136 @@ func runMonitor(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid log level: %v", err)
 	}
 
-	// Connect to server
-	pkg.Info("Connecting to SR-IOV server at %s...", monitorServerAddr)
-	conn, err := grpc.Dial(monitorServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
-	if err != nil {
-		return fmt.Errorf("failed to connect: %v", err)
+	format := strings.ToLower(monitorFormat)
+	if format == "table" {
+		fmt.Println(formatEventHeader())
 	}
-	defer conn.Close()
 
-	pkg.Info("Connected successfully")
-	pkg.Info("Starting real-time monitoring (interval: %v)", monitorInterval)
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	c := proto.NewSRIOVManagerClient(conn)
+	// Every connection replays the current devices unless --no-existing is
+	// set, so changes made while disconnected still show up
+	for {
+		err := watchDevices(ctx, format)
+		if ctx.Err() != nil {
+			return nil
+		}
+		pkg.Warn("Device event stream ended: %v, reconnecting in %v", err, monitorReconnectDelay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(monitorReconnectDelay):
+		}
+	}
+}
 
-	// Monitor loop
-	ticker := time.NewTicker(monitorInterval)
-	defer ticker.Stop()
+// watchDevices connects to the server and prints events until the stream ends
+func watchDevices(ctx context.Context, format string) error {
+	dialCtx, cancel := context.WithTimeout(ctx, monitorTimeout)
+	defer cancel()
+	conn, err := dialServerContext(dialCtx, monitorServerAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
 
-	lastDeviceCount := 0
-	lastDevices := ""
+	c := proto.NewSRIOVManagerClient(conn)
+	stream, err := c.WatchDevices(ctx, &proto.WatchDevicesRequest{IncludeExisting: !monitorNoExisting})
+	if err != nil {
+		return fmt.Errorf("failed to watch devices: %v", err)
+	}
+	pkg.Info("Watching device events from %s", monitorServerAddr)
 
 	for {
-		select {
-		case <-ticker.C:
-			ctx, cancel := context.WithTimeout(context.Background(), monitorTimeout)
-
-			// Get device list
-			r, err := c.ListDevices(ctx, &proto.ListDevicesRequest{})
-			cancel()
-
-			if err != nil {
-				pkg.Error("Error getting devices: %v", err)
-				continue
-			}
-
-			// Convert to DeviceInfo for consistent formatting
-			devices := make([]DeviceInfo, len(r.Devices))
-			for i, d := range r.Devices {
-				deviceInfo := DeviceInfo{
-					PCIAddress:   d.PciAddress,
-					Name:         d.Name,
-					Driver:       d.Driver,
-					Vendor:       d.Vendor,
-					Product:      d.Product,
-					SRIOVCapable: d.SriovCapable,
-					NUMANode:     int(d.NumaNode),
-					NUMADistance: make(map[int]int),
-				}
-
-				// Add NUMA distance information
-				for node, distance := range d.NumaDistance {
-					deviceInfo.NUMADistance[int(node)] = int(distance)
-				}
-
-				devices[i] = deviceInfo
-			}
-
-			// Check if there are changes
-			currentDeviceCount := len(devices)
-			currentDevices := ""
-
-			switch strings.ToLower(monitorFormat) {
-			case "json":
-				currentDevices = formatDeviceJSON(devices)
-			case "simple":
-				currentDevices = formatDeviceSimple(devices)
-			default:
-				currentDevices = formatDeviceTable(devices)
-			}
-
-			// Only print if there are changes
-			if currentDeviceCount != lastDeviceCount || currentDevices != lastDevices {
-				pkg.Info("=== Device Update (%s) ===", time.Now().Format("15:04:05"))
-				pkg.Info("Device count: %d", currentDeviceCount)
-				fmt.Println(currentDevices)
-				pkg.Info("=== End Update ===")
-
-				lastDeviceCount = currentDeviceCount
-				lastDevices = currentDevices
-			}
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		switch format {
+		case "json":
+			fmt.Println(formatEventJSON(event))
+		case "simple":
+			fmt.Println(formatEventSimple(event))
+		default:
+			fmt.Println(formatEventRow(event))
 		}
 	}
 }
+
+// eventTypeName returns the short name of an event type
+func eventTypeName(t proto.DeviceEventType) string {
+	switch t {
+	case proto.DeviceEventType_DEVICE_ADDED:
+		return "added"
+	case proto.DeviceEventType_DEVICE_REMOVED:
+		return "removed"
+	case proto.DeviceEventType_DEVICE_MODIFIED:
+		return "modified"
+	}
+	return "unknown"
+}
+
+// formatEventHeader returns the table header for streamed events
+func formatEventHeader() string {
+	return fmt.Sprintf("%-8s  %-8s  %-12s  %-16s  %-10s  %-4s  %s", "TIME", "EVENT", "PCI ADDRESS", "NAME", "DRIVER", "VFS", "CHANGED")
+}
+
+// formatEventRow formats an event as a fixed-width table row
+func formatEventRow(event *proto.DeviceEvent) string {
+	d := event.Device
+	return fmt.Sprintf("%-8s  %-8s  %-12s  %-16s  %-10s  %-4d  %s",
+		time.Unix(0, event.Timestamp).Format("15:04:05"),
+		eventTypeName(event.Type),
+		d.GetPciAddress(),
+		truncateString(d.GetName(), 16),
+		truncateString(d.GetDriver(), 10),
+		d.GetNumVfs(),
+		strings.Join(event.ChangedFields, ","))
+}
+
+// formatEventSimple formats an event as a single line
+func formatEventSimple(event *proto.DeviceEvent) string {
+	line := fmt.Sprintf("%s %s %s %s", eventTypeName(event.Type), event.Device.GetPciAddress(), event.Device.GetName(), event.Device.GetDriver())
+	if len(event.ChangedFields) > 0 {
+		line += " (" + strings.Join(event.ChangedFields, ", ") + ")"
+	}
+	return line
+}
+
+// formatEventJSON formats an event as a single line of JSON
+func formatEventJSON(event *proto.DeviceEvent) string {
+	d := event.Device
+	info := DeviceInfo{
+		PCIAddress:   d.GetPciAddress(),
+		Name:         d.GetName(),
+		Driver:       d.GetDriver(),
+		Vendor:       d.GetVendor(),
+		Product:      d.GetProduct(),
+		SRIOVCapable: d.GetSriovCapable(),
+		NUMANode:     int(d.GetNumaNode()),
+		NUMADistance: make(map[int]int),
+		TotalVFs:     int(d.GetTotalVfs()),
+		NumVFs:       int(d.GetNumVfs()),
+		VFs:          vfInfoFromProto(d.GetVfs()),
+	}
+	for node, distance := range d.GetNumaDistance() {
+		info.NUMADistance[int(node)] = int(distance)
+	}
+
+	data, err := json.Marshal(struct {
+		Time          string     `json:"time"`
+		Event         string     `json:"event"`
+		ChangedFields []string   `json:"changed_fields,omitempty"`
+		Device        DeviceInfo `json:"device"`
+	}{
+		Time:          time.Unix(0, event.Timestamp).Format(time.RFC3339Nano),
+		Event:         eventTypeName(event.Type),
+		ChangedFields: event.ChangedFields,
+		Device:        info,
+	})
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
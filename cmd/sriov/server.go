@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -14,16 +15,29 @@ import (
 
 	"example.com/sriov-plugin/pkg"
 	pb "example.com/sriov-plugin/proto"
+	pluginapi "example.com/sriov-plugin/proto/deviceplugin/v1beta1"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
 	// Server command flags
-	serverPort     int
-	serverConfig   string
-	serverLogLevel string
+	serverPort      int
+	serverConfig    string
+	serverLogLevel  string
+	serverSysfs     string
+	serverDiscovery string
+
+	// Device plugin flags
+	serverDevicePlugin    bool
+	serverDevicePluginDir string
+
+	// VF lease flags
+	serverLeaseState      string
+	serverLeaseGCInterval time.Duration
 )
 
 // server implements the SRIOVManager gRPC server
@@ -35,8 +49,20 @@ type server struct {
 	watchers     []*DeviceWatcher
 	ethtoolCache map[string]*pkg.EthtoolInfo // Cache for ethtool info
 	ethtoolLock  sync.RWMutex
+	// WatchDevices streams, fed with the changes found by each refresh
+	subscribers     map[chan *pb.DeviceEvent]struct{}
+	subscribersLock sync.Mutex
+	// Kubelet device plugins, one per configured resource pool
+	plugins []*pkg.DevicePlugin
+	// VF leases handed out by AllocateVF, nil without resource pools
+	leases *pkg.LeaseManager
+	// Configuration loaded with --config, empty without one
+	config *pkg.SRIOVConfig
 }
 
+// watchBufferSize is the number of events buffered per WatchDevices stream
+const watchBufferSize = 256
+
 // DeviceWatcher monitors for device changes
 type DeviceWatcher struct {
 	path     string
@@ -66,7 +92,10 @@ The server will:
 Examples:
   sriov server                    # Start server on default port 50051
   sriov server --port 8080       # Start server on custom port
-  sriov server --config config.yaml  # Use custom configuration`,
+  sriov server --config config.yaml  # Use custom configuration
+  sriov server --sysfs-root /host/sys  # Discover devices from a bind-mounted host /sys
+  sriov server --discovery lshw   # Discover devices with lshw instead of sysfs
+  sriov server --config config.json --device-plugin  # Serve resource_pools to the kubelet`,
 	RunE: runServer,
 }
 
@@ -75,8 +104,14 @@ func init() {
 
 	// Add flags
 	serverCmd.Flags().IntVar(&serverPort, "port", 50051, "gRPC server port")
-	serverCmd.Flags().StringVar(&serverConfig, "config", "", "Configuration file path")
+	serverCmd.Flags().StringVar(&serverConfig, "config", "", "Configuration file path (.json, .yaml, .yml or .toml)")
 	serverCmd.Flags().StringVar(&serverLogLevel, "log-level", "info", "Log level: debug, info, warn, error")
+	serverCmd.Flags().StringVar(&serverSysfs, "sysfs-root", pkg.DefaultSysfsRoot, "Root of the sysfs tree used for device discovery")
+	serverCmd.Flags().StringVar(&serverDiscovery, "discovery", pkg.DefaultDiscovery, "Device discovery backend: sysfs, lshw")
+	serverCmd.Flags().BoolVar(&serverDevicePlugin, "device-plugin", false, "Serve the config's resource_pools to the kubelet as device plugins")
+	serverCmd.Flags().StringVar(&serverDevicePluginDir, "device-plugin-dir", pluginapi.DevicePluginPath, "Kubelet device plugin directory")
+	serverCmd.Flags().StringVar(&serverLeaseState, "lease-state", pkg.DefaultLeaseStatePath, "File where VF leases are persisted across restarts")
+	serverCmd.Flags().DurationVar(&serverLeaseGCInterval, "lease-gc-interval", 30*time.Second, "How often expired VF leases are released")
 }
 
 func runServer(cmd *cobra.Command, args []string) error {
@@ -85,14 +120,71 @@ func runServer(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("invalid log level: %v", err)
 	}
 
+	// Point discovery at the requested sysfs tree
+	pkg.SetSysfsRoot(serverSysfs)
+	if err := pkg.SetDiscoveryBackend(serverDiscovery); err != nil {
+		return err
+	}
+
 	// Create server instance
 	s := &server{
 		ethtoolCache: make(map[string]*pkg.EthtoolInfo),
+		config:       &pkg.SRIOVConfig{},
+	}
+
+	var pools []pkg.ResourcePool
+	if serverConfig != "" {
+		config, err := loadServerConfig(serverConfig)
+		if err != nil {
+			return err
+		}
+		pools = config.ResourcePools
+		s.config = config
+	}
+	if serverDevicePlugin {
+		if len(pools) == 0 {
+			return fmt.Errorf("--device-plugin requires --config with resource_pools")
+		}
+		for _, pool := range pools {
+			s.plugins = append(s.plugins, pkg.NewDevicePlugin(pool, serverDevicePluginDir))
+		}
+	}
+	if len(pools) > 0 {
+		leases, err := pkg.NewLeaseManager(pools, serverLeaseState)
+		if err != nil {
+			return err
+		}
+		s.leases = leases
+	}
+	for _, plugin := range s.plugins {
+		plugin.SetLeaseManager(s.leases)
 	}
 
 	// Start device monitoring
 	s.StartDeviceMonitoring()
 
+	// Serve device plugins and leases once they have an inventory
+	pluginCtx, stopPlugins := context.WithCancel(context.Background())
+	defer stopPlugins()
+	var pluginsDone sync.WaitGroup
+	if len(pools) > 0 {
+		s.refreshDeviceList()
+	}
+	if s.leases != nil {
+		go s.expireLeases(pluginCtx, serverLeaseGCInterval)
+	}
+	if len(s.plugins) > 0 {
+		for _, plugin := range s.plugins {
+			pluginsDone.Add(1)
+			go func(plugin *pkg.DevicePlugin) {
+				defer pluginsDone.Done()
+				if err := plugin.Serve(pluginCtx); err != nil {
+					pkg.Error("Device plugin %s stopped: %v", plugin.ResourceName(), err)
+				}
+			}(plugin)
+		}
+	}
+
 	// Create gRPC server
 	grpcServer := grpc.NewServer()
 	pb.RegisterSRIOVManagerServer(grpcServer, s)
@@ -119,11 +211,45 @@ func runServer(cmd *cobra.Command, args []string) error {
 	<-quit
 
 	pkg.Info("Shutting down server...")
+	stopPlugins()
+	pluginsDone.Wait()
 	grpcServer.GracefulStop()
 
 	return nil
 }
 
+// loadServerConfig loads and validates the configuration holding resource pools
+func loadServerConfig(configPath string) (*pkg.SRIOVConfig, error) {
+	config, err := pkg.LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ValidateConfig(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+	return config, nil
+}
+
+// expireLeases releases expired VF leases every interval until ctx is done
+func (s *server) expireLeases(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.leases.ExpireLeases()
+			if err != nil {
+				pkg.Error("Failed to persist expired VF leases: %v", err)
+			}
+			if len(expired) > 0 && len(s.plugins) > 0 {
+				s.refreshDeviceList()
+			}
+		}
+	}
+}
+
 // StartDeviceMonitoring starts monitoring for device changes
 func (s *server) StartDeviceMonitoring() {
 	pkg.Info("Starting device change monitoring...")
@@ -150,11 +276,12 @@ func (s *server) StartDeviceMonitoring() {
 	go s.processDeviceEvents()
 }
 
-// watchNetworkInterfaces monitors /sys/class/net for interface changes
+// watchNetworkInterfaces monitors <sysfs root>/class/net for interface changes
 func (s *server) watchNetworkInterfaces() {
-	if _, err := os.Stat("/sys/class/net"); err == nil {
+	netPath := pkg.NetClassPath()
+	if _, err := os.Stat(netPath); err == nil {
 		watcher := &DeviceWatcher{
-			path:     "/sys/class/net",
+			path:     netPath,
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -176,15 +303,16 @@ func (s *server) watchNetworkInterfaces() {
 		}()
 		pkg.Info("Network interface monitoring enabled")
 	} else {
-		pkg.Warn("Warning: /sys/class/net not accessible, network interface monitoring disabled")
+		pkg.Warn("Warning: %s not accessible, network interface monitoring disabled", netPath)
 	}
 }
 
-// watchPciDevices monitors /sys/bus/pci/devices for PCI device changes
+// watchPciDevices monitors <sysfs root>/bus/pci/devices for PCI device changes
 func (s *server) watchPciDevices() {
-	if _, err := os.Stat("/sys/bus/pci/devices"); err == nil {
+	pciPath := pkg.PciDevicesPath()
+	if _, err := os.Stat(pciPath); err == nil {
 		watcher := &DeviceWatcher{
-			path:     "/sys/bus/pci/devices",
+			path:     pciPath,
 			events:   make(chan DeviceEvent, 100),
 			stopChan: make(chan struct{}),
 		}
@@ -205,15 +333,15 @@ func (s *server) watchPciDevices() {
 		}()
 		pkg.Info("PCI device monitoring enabled")
 	} else {
-		pkg.Warn("Warning: /sys/bus/pci/devices not accessible, PCI monitoring disabled")
+		pkg.Warn("Warning: %s not accessible, PCI monitoring disabled", pciPath)
 	}
 }
 
 // watchDriverBindings monitors driver binding changes
 func (s *server) watchDriverBindings() {
 	driverPaths := []string{
-		"/sys/bus/pci/drivers",
-		"/sys/bus/pci/devices",
+		pkg.PciDriversPath(),
+		pkg.PciDevicesPath(),
 	}
 
 	for _, path := range driverPaths {
@@ -386,11 +514,14 @@ func (s *server) handleDriverChange(event DeviceEvent) {
 	s.refreshDeviceList()
 }
 
-// checkSriovChanges checks for SR-IOV configuration changes
+// checkSriovChanges checks for SR-IOV configuration changes. sysfs does not
+// raise inotify events for attribute writes such as sriov_numvfs, so while
+// anyone is watching the device list is re-read and diffed periodically.
 func (s *server) checkSriovChanges() {
-	// This would check for SR-IOV specific changes
-	// For now, just log that we're checking
 	pkg.Debug("Checking SR-IOV configurations...")
+	if s.subscriberCount() > 0 || len(s.plugins) > 0 {
+		s.refreshDeviceList()
+	}
 }
 
 // watchEthtoolChanges monitors ethtool information changes
@@ -410,7 +541,7 @@ func (s *server) checkEthtoolChanges() {
 	defer s.ethtoolLock.Unlock()
 
 	// Get current devices
-	devices, err := pkg.ParseLshwDynamic()
+	devices, err := pkg.DiscoverNetworkDevices()
 	if err != nil {
 		pkg.Error("Error getting PCI devices for ethtool check: %v", err)
 		return
@@ -495,7 +626,7 @@ func (s *server) refreshDeviceList() {
 	pkg.Info("Refreshing device list...")
 
 	// Get current devices
-	devices, err := pkg.ParseLshwDynamic()
+	devices, err := pkg.DiscoverNetworkDevices()
 	if err != nil {
 		pkg.Error("Error refreshing devices: %v", err)
 		return
@@ -508,11 +639,35 @@ func (s *server) refreshDeviceList() {
 		return
 	}
 
-	// Update device list
+	// Attach VF administrative state
+	devices, err = pkg.AttachVFInfo(devices)
+	if err != nil {
+		pkg.Error("Error attaching VF info: %v", err)
+	}
+
+	devices, err = pkg.AttachEswitchInfo(devices)
+	if err != nil {
+		pkg.Error("Error attaching eswitch info: %v", err)
+	}
+
+	devices, err = pkg.AttachDevlinkPorts(devices)
+	if err != nil {
+		pkg.Error("Error attaching devlink ports: %v", err)
+	}
+
+	// Update device list and notify watchers of what changed
+	changes := pkg.DiffDevices(s.devices, devices)
 	s.devices = devices
 	s.lastUpdate = time.Now()
+	s.publishChanges(changes, s.lastUpdate)
+	for _, plugin := range s.plugins {
+		plugin.Update(devices)
+	}
+	if s.leases != nil {
+		s.leases.Update(devices)
+	}
 
-	pkg.Info("Device list refreshed: %d devices found", len(devices))
+	pkg.Info("Device list refreshed: %d devices found, %d changed", len(devices), len(changes))
 
 	// Debug print device information
 	debugPrintDeviceInfo(devices)
@@ -533,87 +688,545 @@ func (s *server) ListDevices(ctx context.Context, in *pb.ListDevicesRequest) (*p
 	// Convert devices to protobuf format
 	pbDevices := make([]*pb.Device, len(s.devices))
 	for i, device := range s.devices {
-		pbDevice := &pb.Device{
-			PciAddress:   device.PCIAddress,
-			Name:         device.Name,
-			Driver:       device.Driver,
-			Vendor:       device.Vendor,
-			Product:      device.Product,
-			SriovCapable: device.SRIOVCapable,
-			NumaNode:     int32(device.NUMANode),
-			NumaDistance: make(map[int32]int32),
+		pbDevices[i] = toProtoDevice(device)
+	}
+
+	return &pb.ListDevicesResponse{
+		Devices: pbDevices,
+	}, nil
+}
+
+// RefreshDevices implements the gRPC RefreshDevices method
+func (s *server) RefreshDevices(ctx context.Context, in *pb.RefreshDevicesRequest) (*pb.RefreshDevicesResponse, error) {
+	s.refreshDeviceList()
+
+	return &pb.RefreshDevicesResponse{
+		Success: true,
+		Message: fmt.Sprintf("Successfully refreshed %d devices", len(s.devices)),
+	}, nil
+}
+
+// ListVFs implements the gRPC ListVFs method
+func (s *server) ListVFs(ctx context.Context, in *pb.ListVFsRequest) (*pb.ListVFsResponse, error) {
+	if in.Pf == "" {
+		return nil, status.Error(codes.InvalidArgument, "pf is required")
+	}
+
+	s.devicesLock.RLock()
+	defer s.devicesLock.RUnlock()
+
+	// If no devices loaded or devices are stale, refresh
+	if len(s.devices) == 0 || time.Since(s.lastUpdate) > 30*time.Second {
+		s.devicesLock.RUnlock()
+		s.refreshDeviceList()
+		s.devicesLock.RLock()
+	}
+
+	pf, err := pkg.FindPF(s.devices, in.Pf)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	resp := &pb.ListVFsResponse{
+		PfAddress: pf.PCIAddress,
+		PfName:    pf.Name,
+	}
+	for _, vf := range pf.VFs {
+		resp.Vfs = append(resp.Vfs, toProtoVF(vf))
+	}
+
+	return resp, nil
+}
+
+// AllocateVF implements the gRPC AllocateVF method, leasing VFs from a
+// configured resource pool
+func (s *server) AllocateVF(ctx context.Context, in *pb.AllocateVFRequest) (*pb.AllocateVFResponse, error) {
+	if s.leases == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no resource pools configured, start the server with --config")
+	}
+	if in.Count <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "count must be positive")
+	}
+	if in.TtlSeconds < 0 {
+		return nil, status.Error(codes.InvalidArgument, "ttl_seconds must not be negative")
+	}
+	if in.StrictNuma && in.NumaNode == nil {
+		return nil, status.Error(codes.InvalidArgument, "strict_numa requires numa_node")
+	}
+
+	s.devicesLock.RLock()
+	stale := len(s.devices) == 0 || time.Since(s.lastUpdate) > 30*time.Second
+	s.devicesLock.RUnlock()
+	if stale {
+		s.refreshDeviceList()
+	}
+
+	numaNode := -1
+	if in.NumaNode != nil {
+		numaNode = int(*in.NumaNode)
+	}
+	lease, err := s.leases.Allocate(pkg.LeaseRequest{
+		Pool:       in.Pool,
+		Count:      int(in.Count),
+		NUMANode:   numaNode,
+		StrictNUMA: in.StrictNuma,
+		Owner:      in.Owner,
+		Metadata:   in.Metadata,
+		TTL:        time.Duration(in.TtlSeconds) * time.Second,
+	})
+	switch {
+	case errors.Is(err, pkg.ErrUnknownPool):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, pkg.ErrPoolServedToKubelet):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, pkg.ErrInsufficientVFs):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.AllocateVFResponse{Lease: toProtoLease(lease)}, nil
+}
+
+// ReleaseVF implements the gRPC ReleaseVF method
+func (s *server) ReleaseVF(ctx context.Context, in *pb.ReleaseVFRequest) (*pb.ReleaseVFResponse, error) {
+	if s.leases == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no resource pools configured, start the server with --config")
+	}
+	if in.LeaseId == "" {
+		return nil, status.Error(codes.InvalidArgument, "lease_id is required")
+	}
+
+	err := s.leases.Release(in.LeaseId)
+	switch {
+	case errors.Is(err, pkg.ErrLeaseNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Device plugins advertise the released VFs as healthy again
+	if len(s.plugins) > 0 {
+		go s.refreshDeviceList()
+	}
+	return &pb.ReleaseVFResponse{}, nil
+}
+
+// BindDriver implements the gRPC BindDriver method, moving a VF to another
+// kernel driver
+func (s *server) BindDriver(ctx context.Context, in *pb.BindDriverRequest) (*pb.BindDriverResponse, error) {
+	if in.PciAddress == "" || in.Driver == "" {
+		return nil, status.Error(codes.InvalidArgument, "pci_address and driver are required")
+	}
+	if pkg.PhysFnAddress(in.PciAddress) == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not an SR-IOV VF", in.PciAddress)
+	}
+
+	previous := pkg.BoundDriver(in.PciAddress)
+	manager := pkg.NewSRIOVManager(&pkg.SRIOVConfig{})
+	if err := manager.BindDriver(in.PciAddress, in.Driver); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	// Driver changes do not reliably raise inotify events, so publish now
+	go s.refreshDeviceList()
+
+	return &pb.BindDriverResponse{
+		PciAddress:     in.PciAddress,
+		PreviousDriver: previous,
+		Driver:         pkg.BoundDriver(in.PciAddress),
+	}, nil
+}
+
+// ListSFs implements the gRPC ListSFs method
+func (s *server) ListSFs(ctx context.Context, in *pb.ListSFsRequest) (*pb.ListSFsResponse, error) {
+	if in.Pf == "" {
+		return nil, status.Error(codes.InvalidArgument, "pf is required")
+	}
+
+	pf, err := s.findPF(in.Pf)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	resp := &pb.ListSFsResponse{
+		PfAddress: pf.PCIAddress,
+		PfName:    pf.Name,
+	}
+	for _, sf := range pf.SFs {
+		resp.Sfs = append(resp.Sfs, toProtoSF(sf))
+	}
+	return resp, nil
+}
+
+// CreateSF implements the gRPC CreateSF method, creating or reconfiguring a
+// scalable function
+func (s *server) CreateSF(ctx context.Context, in *pb.CreateSFRequest) (*pb.CreateSFResponse, error) {
+	if in.Pf == "" {
+		return nil, status.Error(codes.InvalidArgument, "pf is required")
+	}
+
+	pf, err := s.findPF(in.Pf)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	manager := s.sfManager()
+	sf, err := manager.CreateSF(pf, in.Sfnum, in.HwAddr, in.State)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	go s.refreshDeviceList()
+
+	return &pb.CreateSFResponse{Sf: toProtoSF(sf)}, nil
+}
+
+// DeleteSF implements the gRPC DeleteSF method
+func (s *server) DeleteSF(ctx context.Context, in *pb.DeleteSFRequest) (*pb.DeleteSFResponse, error) {
+	if in.Pf == "" {
+		return nil, status.Error(codes.InvalidArgument, "pf is required")
+	}
+
+	pf, err := s.findPF(in.Pf)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	manager := s.sfManager()
+	if err := manager.DeleteSF(pf, in.Sfnum); err != nil {
+		if errors.Is(err, pkg.ErrSFNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
 		}
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	go s.refreshDeviceList()
+
+	return &pb.DeleteSFResponse{}, nil
+}
+
+// sfManager returns a manager for the SF RPCs that knows the configured
+// policies, so SFs kept by a subfunctions policy are refused
+func (s *server) sfManager() *pkg.SRIOVManager {
+	return pkg.NewSRIOVManager(&pkg.SRIOVConfig{DevicePolicies: s.config.DevicePolicies})
+}
+
+// findPF returns a copy of the PF matching a PCI address or interface name,
+// refreshing a stale device list first
+func (s *server) findPF(pf string) (pkg.Device, error) {
+	s.devicesLock.RLock()
+	stale := len(s.devices) == 0 || time.Since(s.lastUpdate) > 30*time.Second
+	s.devicesLock.RUnlock()
+	if stale {
+		s.refreshDeviceList()
+	}
 
-		// Add NUMA distance information
-		for node, distance := range device.NUMADistance {
-			pbDevice.NumaDistance[int32(node)] = int32(distance)
+	s.devicesLock.RLock()
+	defer s.devicesLock.RUnlock()
+	device, err := pkg.FindPF(s.devices, pf)
+	if err != nil {
+		return pkg.Device{}, err
+	}
+	return *device, nil
+}
+
+func toProtoSF(sf pkg.SubFunction) *pb.SubFunction {
+	return &pb.SubFunction{
+		Sfnum:       sf.SFNum,
+		Pfnum:       sf.PFNum,
+		PortIndex:   sf.PortIndex,
+		Representor: sf.Representor,
+		HwAddr:      sf.HwAddr,
+		State:       sf.State,
+		Opstate:     sf.OpState,
+		AuxDevice:   sf.AuxDevice,
+		Netdev:      sf.NetDev,
+	}
+}
+
+// toProtoLease converts a VF lease to its protobuf form
+func toProtoLease(lease *pkg.VFLease) *pb.VFLease {
+	pbLease := &pb.VFLease{
+		Id:           lease.ID,
+		Pool:         lease.Pool,
+		Owner:        lease.Owner,
+		Metadata:     lease.Metadata,
+		PciAddresses: lease.VFs,
+		CreatedAt:    lease.CreatedAt.UnixNano(),
+	}
+	if !lease.ExpiresAt.IsZero() {
+		pbLease.ExpiresAt = lease.ExpiresAt.UnixNano()
+	}
+	return pbLease
+}
+
+// WatchDevices implements the gRPC WatchDevices method, streaming an event
+// for every device added, removed or modified until the client disconnects
+func (s *server) WatchDevices(in *pb.WatchDevicesRequest, stream pb.SRIOVManager_WatchDevicesServer) error {
+	s.devicesLock.RLock()
+	loaded := len(s.devices) > 0
+	s.devicesLock.RUnlock()
+	if !loaded {
+		s.refreshDeviceList()
+	}
+
+	// Subscribe before reading the snapshot so no change falls in between
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	if in.IncludeExisting {
+		s.devicesLock.RLock()
+		now := time.Now().UnixNano()
+		var existing []*pb.DeviceEvent
+		for _, device := range s.devices {
+			existing = append(existing, &pb.DeviceEvent{
+				Type:      pb.DeviceEventType_DEVICE_ADDED,
+				Device:    toProtoDevice(device),
+				Timestamp: now,
+			})
 		}
+		s.devicesLock.RUnlock()
 
-		// Add detailed capabilities if available
-		if len(device.DetailedCapabilities) > 0 {
-			pbDevice.DetailedCapabilities = make(map[string]*pb.DetailedCapability)
-			for name, cap := range device.DetailedCapabilities {
-				pbDevice.DetailedCapabilities[name] = &pb.DetailedCapability{
-					Id:          cap.ID,
-					Name:        cap.Name,
-					Status:      cap.Status,
-					Description: cap.Description,
-					Parameters:  cap.Parameters,
-				}
+		for _, event := range existing {
+			if err := stream.Send(event); err != nil {
+				return err
 			}
 		}
+	}
 
-		// Add ethtool information if available
-		if device.EthtoolInfo != nil {
-			pbDevice.EthtoolInfo = &pb.EthtoolInfo{
-				Features: make([]*pb.EthtoolFeature, len(device.EthtoolInfo.Features)),
-				Ring: &pb.EthtoolRingInfo{
-					RxMaxPending:      device.EthtoolInfo.Ring.RxMaxPending,
-					RxMiniMaxPending:  device.EthtoolInfo.Ring.RxMiniMaxPending,
-					RxJumboMaxPending: device.EthtoolInfo.Ring.RxJumboMaxPending,
-					TxMaxPending:      device.EthtoolInfo.Ring.TxMaxPending,
-					RxPending:         device.EthtoolInfo.Ring.RxPending,
-					RxMiniPending:     device.EthtoolInfo.Ring.RxMiniPending,
-					RxJumboPending:    device.EthtoolInfo.Ring.RxJumboPending,
-					TxPending:         device.EthtoolInfo.Ring.TxPending,
-				},
-				Channels: &pb.EthtoolChannelInfo{
-					MaxRx:         device.EthtoolInfo.Channels.MaxRx,
-					MaxTx:         device.EthtoolInfo.Channels.MaxTx,
-					MaxOther:      device.EthtoolInfo.Channels.MaxOther,
-					MaxCombined:   device.EthtoolInfo.Channels.MaxCombined,
-					RxCount:       device.EthtoolInfo.Channels.RxCount,
-					TxCount:       device.EthtoolInfo.Channels.TxCount,
-					OtherCount:    device.EthtoolInfo.Channels.OtherCount,
-					CombinedCount: device.EthtoolInfo.Channels.CombinedCount,
-				},
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "watcher fell behind and was disconnected")
+			}
+			if err := stream.Send(event); err != nil {
+				return err
 			}
+		}
+	}
+}
 
-			for j, feature := range device.EthtoolInfo.Features {
-				pbDevice.EthtoolInfo.Features[j] = &pb.EthtoolFeature{
-					Name:    feature.Name,
-					Enabled: feature.Enabled,
-					Fixed:   feature.Fixed,
-				}
+// subscribe registers a channel that receives device events
+func (s *server) subscribe() chan *pb.DeviceEvent {
+	events := make(chan *pb.DeviceEvent, watchBufferSize)
+	s.subscribersLock.Lock()
+	defer s.subscribersLock.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan *pb.DeviceEvent]struct{})
+	}
+	s.subscribers[events] = struct{}{}
+	return events
+}
+
+// unsubscribe removes a channel registered with subscribe
+func (s *server) unsubscribe(events chan *pb.DeviceEvent) {
+	s.subscribersLock.Lock()
+	defer s.subscribersLock.Unlock()
+	if _, ok := s.subscribers[events]; ok {
+		delete(s.subscribers, events)
+		close(events)
+	}
+}
+
+// subscriberCount returns the number of active WatchDevices streams
+func (s *server) subscriberCount() int {
+	s.subscribersLock.Lock()
+	defer s.subscribersLock.Unlock()
+	return len(s.subscribers)
+}
+
+// publishChanges sends device changes to every WatchDevices stream. A stream
+// whose buffer is full is closed rather than silently missing events.
+func (s *server) publishChanges(changes []pkg.DeviceChange, at time.Time) {
+	if len(changes) == 0 {
+		return
+	}
+
+	s.subscribersLock.Lock()
+	defer s.subscribersLock.Unlock()
+
+	for _, change := range changes {
+		event := &pb.DeviceEvent{
+			Type:          toProtoEventType(change.Type),
+			Device:        toProtoDevice(change.Device),
+			ChangedFields: change.ChangedFields,
+			Timestamp:     at.UnixNano(),
+		}
+		for events := range s.subscribers {
+			select {
+			case events <- event:
+			default:
+				pkg.Warn("Warning: WatchDevices stream fell behind, disconnecting it")
+				delete(s.subscribers, events)
+				close(events)
 			}
 		}
+	}
+}
 
-		pbDevices[i] = pbDevice
+// toProtoEventType converts a DeviceChangeType to its protobuf representation
+func toProtoEventType(t pkg.DeviceChangeType) pb.DeviceEventType {
+	switch t {
+	case pkg.DeviceAdded:
+		return pb.DeviceEventType_DEVICE_ADDED
+	case pkg.DeviceRemoved:
+		return pb.DeviceEventType_DEVICE_REMOVED
+	case pkg.DeviceModified:
+		return pb.DeviceEventType_DEVICE_MODIFIED
 	}
+	return pb.DeviceEventType_DEVICE_EVENT_UNSPECIFIED
+}
 
-	return &pb.ListDevicesResponse{
-		Devices: pbDevices,
-	}, nil
+// toProtoDevice converts a Device to its protobuf representation
+func toProtoDevice(device pkg.Device) *pb.Device {
+	pbDevice := &pb.Device{
+		PciAddress:   device.PCIAddress,
+		Name:         device.Name,
+		Driver:       device.Driver,
+		Vendor:       device.Vendor,
+		Product:      device.Product,
+		SriovCapable: device.SRIOVCapable,
+		NumaNode:     int32(device.NUMANode),
+		NumaDistance: make(map[int32]int32),
+	}
+
+	// Add NUMA distance information
+	for node, distance := range device.NUMADistance {
+		pbDevice.NumaDistance[int32(node)] = int32(distance)
+	}
+
+	// Add SR-IOV VF counts and inventory
+	if device.SRIOVInfo != nil {
+		pbDevice.TotalVfs = int32(device.SRIOVInfo.TotalVFs)
+		pbDevice.NumVfs = int32(device.SRIOVInfo.NumberOfVFs)
+	}
+	pbDevice.Physfn = device.PhysFn
+	for _, vf := range device.VFs {
+		pbDevice.Vfs = append(pbDevice.Vfs, toProtoVF(vf))
+	}
+	for _, sf := range device.SFs {
+		pbDevice.Sfs = append(pbDevice.Sfs, toProtoSF(sf))
+	}
+	for _, port := range device.DevlinkPorts {
+		pbDevice.DevlinkPorts = append(pbDevice.DevlinkPorts, toProtoDevlinkPort(port))
+	}
+	if device.Eswitch != nil {
+		pbDevice.Eswitch = &pb.Eswitch{
+			Mode:       device.Eswitch.Mode,
+			InlineMode: device.Eswitch.InlineMode,
+			EncapMode:  device.Eswitch.EncapMode,
+		}
+	}
+
+	// Add detailed capabilities if available
+	if len(device.DetailedCapabilities) > 0 {
+		pbDevice.DetailedCapabilities = make(map[string]*pb.DetailedCapability)
+		for name, cap := range device.DetailedCapabilities {
+			pbDevice.DetailedCapabilities[name] = &pb.DetailedCapability{
+				Id:          cap.ID,
+				Name:        cap.Name,
+				Status:      cap.Status,
+				Description: cap.Description,
+				Parameters:  cap.Parameters,
+			}
+		}
+	}
+
+	// Add ethtool information if available
+	if device.EthtoolInfo != nil {
+		pbDevice.EthtoolInfo = &pb.EthtoolInfo{
+			Features: make([]*pb.EthtoolFeature, len(device.EthtoolInfo.Features)),
+			Ring: &pb.EthtoolRingInfo{
+				RxMaxPending:      device.EthtoolInfo.Ring.RxMaxPending,
+				RxMiniMaxPending:  device.EthtoolInfo.Ring.RxMiniMaxPending,
+				RxJumboMaxPending: device.EthtoolInfo.Ring.RxJumboMaxPending,
+				TxMaxPending:      device.EthtoolInfo.Ring.TxMaxPending,
+				RxPending:         device.EthtoolInfo.Ring.RxPending,
+				RxMiniPending:     device.EthtoolInfo.Ring.RxMiniPending,
+				RxJumboPending:    device.EthtoolInfo.Ring.RxJumboPending,
+				TxPending:         device.EthtoolInfo.Ring.TxPending,
+			},
+			Channels: &pb.EthtoolChannelInfo{
+				MaxRx:         device.EthtoolInfo.Channels.MaxRx,
+				MaxTx:         device.EthtoolInfo.Channels.MaxTx,
+				MaxOther:      device.EthtoolInfo.Channels.MaxOther,
+				MaxCombined:   device.EthtoolInfo.Channels.MaxCombined,
+				RxCount:       device.EthtoolInfo.Channels.RxCount,
+				TxCount:       device.EthtoolInfo.Channels.TxCount,
+				OtherCount:    device.EthtoolInfo.Channels.OtherCount,
+				CombinedCount: device.EthtoolInfo.Channels.CombinedCount,
+			},
+		}
+
+		for j, feature := range device.EthtoolInfo.Features {
+			pbDevice.EthtoolInfo.Features[j] = &pb.EthtoolFeature{
+				Name:    feature.Name,
+				Enabled: feature.Enabled,
+				Fixed:   feature.Fixed,
+			}
+		}
+	}
+
+	return pbDevice
 }
 
-// RefreshDevices implements the gRPC RefreshDevices method
-func (s *server) RefreshDevices(ctx context.Context, in *pb.RefreshDevicesRequest) (*pb.RefreshDevicesResponse, error) {
-	s.refreshDeviceList()
+// toProtoVF converts a VirtualFunction to its protobuf representation
+func toProtoVF(vf pkg.VirtualFunction) *pb.VirtualFunction {
+	return &pb.VirtualFunction{
+		Index:        int32(vf.Index),
+		PciAddress:   vf.PCIAddress,
+		Driver:       vf.Driver,
+		Netdev:       vf.NetDev,
+		Mac:          vf.MAC,
+		Vlan:         int32(vf.Vlan),
+		Qos:          int32(vf.Qos),
+		Trust:        vf.Trust,
+		Spoofchk:     vf.SpoofChk,
+		LinkState:    vf.LinkState,
+		MinTxRate:    vf.MinTxRate,
+		MaxTxRate:    vf.MaxTxRate,
+		IommuGroup:   vf.IOMMUGroup,
+		PfAddress:    vf.PFAddress,
+		Representor:  vf.Representor,
+		PortFunction: toProtoPortFunction(vf.PortFunction),
+		QueryRss:     vf.QueryRSS,
+	}
+}
 
-	return &pb.RefreshDevicesResponse{
-		Success: true,
-		Message: fmt.Sprintf("Successfully refreshed %d devices", len(s.devices)),
-	}, nil
+func toProtoDevlinkPort(port pkg.DevlinkPort) *pb.DevlinkPort {
+	return &pb.DevlinkPort{
+		Handle:     port.Handle,
+		PciAddress: port.PCIAddress,
+		Index:      port.Index,
+		Type:       port.Type,
+		Netdev:     port.Netdev,
+		Flavour:    port.Flavour,
+		Number:     port.Number,
+		Controller: port.Controller,
+		Pfnum:      port.PFNum,
+		Vfnum:      port.VFNum,
+		Sfnum:      port.SFNum,
+		External:   port.External,
+		Splittable: port.Splittable,
+		Function:   toProtoPortFunction(port.Function),
+	}
+}
+
+func toProtoPortFunction(function *pkg.PortFunction) *pb.PortFunction {
+	if function == nil {
+		return nil
+	}
+	return &pb.PortFunction{
+		HwAddr:     function.HwAddr,
+		State:      function.State,
+		Opstate:    function.OpState,
+		Roce:       function.Roce,
+		Migratable: function.Migratable,
+	}
 }
 
 // debugPrintDeviceInfo prints detailed device information for debugging
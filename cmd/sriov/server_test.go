@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"example.com/sriov-plugin/pkg"
+	pb "example.com/sriov-plugin/proto"
+)
+
+// startBufconnServer serves s over an in-memory connection and returns a
+// client for it
+func startBufconnServer(t *testing.T, s *server) pb.SRIOVManagerClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterSRIOVManagerServer(grpcServer, s)
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial bufconn server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewSRIOVManagerClient(conn)
+}
+
+// TestWatchDevices tests that a stream gets the existing devices and the
+// changes found by a refresh, and is unsubscribed once cancelled
+func TestWatchDevices(t *testing.T) {
+	pf := pkg.Device{
+		Name: "ens60f0np0", PCIAddress: "0000:31:00.0", Driver: "mlx5_core",
+		Eswitch: &pkg.EswitchState{Mode: pkg.EswitchModeLegacy},
+	}
+	s := &server{
+		ethtoolCache: make(map[string]*pkg.EthtoolInfo),
+		devices:      []pkg.Device{pf},
+		lastUpdate:   time.Now(),
+	}
+	client := startBufconnServer(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchDevices(ctx, &pb.WatchDevicesRequest{IncludeExisting: true})
+	if err != nil {
+		t.Fatalf("WatchDevices failed: %v", err)
+	}
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive existing device: %v", err)
+	}
+	if event.Type != pb.DeviceEventType_DEVICE_ADDED || event.Device.Name != "ens60f0np0" {
+		t.Errorf("Expected ens60f0np0 to be added, got %v", event)
+	}
+	if n := s.subscriberCount(); n != 1 {
+		t.Fatalf("Expected 1 subscriber, got %d", n)
+	}
+
+	// A reconcile moves the PF to switchdev and the next refresh sees it
+	reconciled := pf
+	reconciled.Eswitch = &pkg.EswitchState{Mode: pkg.EswitchModeSwitchdev}
+	s.publishChanges(pkg.DiffDevices([]pkg.Device{pf}, []pkg.Device{reconciled}), time.Now())
+
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive change: %v", err)
+	}
+	if event.Type != pb.DeviceEventType_DEVICE_MODIFIED || !reflect.DeepEqual(event.ChangedFields, []string{"Eswitch"}) {
+		t.Errorf("Expected an Eswitch modification, got %v", event)
+	}
+	if mode := event.Device.GetEswitch().GetMode(); mode != string(pkg.EswitchModeSwitchdev) {
+		t.Errorf("Expected eswitch mode switchdev, got %q", mode)
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.subscriberCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the subscriber to be removed after cancel")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
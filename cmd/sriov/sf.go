@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"example.com/sriov-plugin/pkg"
+	"example.com/sriov-plugin/proto"
+)
+
+var (
+	// SF command flags
+	sfServerAddr string
+	sfTimeout    time.Duration
+	sfFormat     string
+	sfLogLevel   string
+
+	// SF create flags
+	sfCreateHwAddr string
+	sfCreateState  string
+)
+
+var sfCmd = &cobra.Command{
+	Use:   "sf",
+	Short: "Inspect, create and delete scalable functions",
+	Long: `Inspect, create and delete the scalable functions (SFs) of a physical
+function. SFs are devlink pcisf ports and need the PF's eswitch in switchdev
+mode.
+
+Examples:
+  sriov sf list ens60f0np0                                # List SFs of a PF
+  sriov sf create ens60f0np0 88 --hw-addr 02:00:00:00:88:00
+  sriov sf create 0000:31:00.0 89 --state inactive
+  sriov sf delete ens60f0np0 88`,
+}
+
+var sfListCmd = &cobra.Command{
+	Use:   "list <pf>",
+	Short: "List the SFs of a physical function",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSFList,
+}
+
+var sfCreateCmd = &cobra.Command{
+	Use:   "create <pf> <sfnum>",
+	Short: "Create or reconfigure an SF",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSFCreate,
+}
+
+var sfDeleteCmd = &cobra.Command{
+	Use:   "delete <pf> <sfnum>",
+	Short: "Deactivate and delete an SF",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runSFDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(sfCmd)
+	sfCmd.AddCommand(sfListCmd)
+	sfCmd.AddCommand(sfCreateCmd)
+	sfCmd.AddCommand(sfDeleteCmd)
+
+	// Add flags
+	sfCmd.PersistentFlags().StringVar(&sfServerAddr, "server", "localhost:50051", "gRPC server address")
+	sfCmd.PersistentFlags().DurationVar(&sfTimeout, "timeout", 5*time.Second, "Connection timeout")
+	sfCmd.PersistentFlags().StringVar(&sfLogLevel, "log-level", "warn", "Log level: debug, info, warn, error")
+	sfListCmd.Flags().StringVar(&sfFormat, "format", "table", "Output format: table, json")
+	sfCreateCmd.Flags().StringVar(&sfCreateHwAddr, "hw-addr", "", "MAC address of the SF")
+	sfCreateCmd.Flags().StringVar(&sfCreateState, "state", "", "active or inactive (default active)")
+}
+
+// sfClient sets the log level and connects to the server for an sf command
+func sfClient() (proto.SRIOVManagerClient, func(), error) {
+	if err := pkg.SetLogLevelFromString(sfLogLevel); err != nil {
+		return nil, nil, fmt.Errorf("invalid log level: %v", err)
+	}
+	conn, err := dialServer(sfServerAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proto.NewSRIOVManagerClient(conn), func() { conn.Close() }, nil
+}
+
+func parseSFNum(arg string) (uint32, error) {
+	sfnum, err := strconv.ParseUint(arg, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sfnum %q", arg)
+	}
+	return uint32(sfnum), nil
+}
+
+func runSFList(cmd *cobra.Command, args []string) error {
+	format := strings.ToLower(sfFormat)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format: %s. Use: table or json", sfFormat)
+	}
+
+	c, done, err := sfClient()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sfTimeout)
+	defer cancel()
+
+	resp, err := c.ListSFs(ctx, &proto.ListSFsRequest{Pf: args[0]})
+	if err != nil {
+		return fmt.Errorf("could not list SFs: %v", err)
+	}
+
+	sfs := sfInfoFromProto(resp.Sfs)
+	if format == "json" {
+		data, _ := json.MarshalIndent(sfs, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Printf("PF %s (%s): %d SFs\n", resp.PfName, resp.PfAddress, len(sfs))
+	fmt.Println(formatSFTable(sfs))
+	return nil
+}
+
+func runSFCreate(cmd *cobra.Command, args []string) error {
+	sfnum, err := parseSFNum(args[1])
+	if err != nil {
+		return err
+	}
+
+	c, done, err := sfClient()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sfTimeout)
+	defer cancel()
+
+	resp, err := c.CreateSF(ctx, &proto.CreateSFRequest{
+		Pf:     args[0],
+		Sfnum:  sfnum,
+		HwAddr: sfCreateHwAddr,
+		State:  sfCreateState,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create SF %d on %s: %v", sfnum, args[0], err)
+	}
+	fmt.Println(formatSFTable(sfInfoFromProto([]*proto.SubFunction{resp.Sf})))
+	return nil
+}
+
+func runSFDelete(cmd *cobra.Command, args []string) error {
+	sfnum, err := parseSFNum(args[1])
+	if err != nil {
+		return err
+	}
+
+	c, done, err := sfClient()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sfTimeout)
+	defer cancel()
+
+	if _, err := c.DeleteSF(ctx, &proto.DeleteSFRequest{Pf: args[0], Sfnum: sfnum}); err != nil {
+		return fmt.Errorf("could not delete SF %d on %s: %v", sfnum, args[0], err)
+	}
+	fmt.Printf("Deleted SF %d on %s\n", sfnum, args[0])
+	return nil
+}
+
+// sfInfoFromProto converts protobuf SFs to SFInfo for consistent formatting
+func sfInfoFromProto(pbSFs []*proto.SubFunction) []SFInfo {
+	var sfs []SFInfo
+	for _, sf := range pbSFs {
+		sfs = append(sfs, SFInfo{
+			SFNum:       sf.Sfnum,
+			PFNum:       sf.Pfnum,
+			PortIndex:   sf.PortIndex,
+			Representor: sf.Representor,
+			HwAddr:      sf.HwAddr,
+			State:       sf.State,
+			OpState:     sf.Opstate,
+			AuxDevice:   sf.AuxDevice,
+			NetDev:      sf.Netdev,
+		})
+	}
+	return sfs
+}
@@ -19,6 +19,47 @@ type DeviceInfo struct {
 	// NUMA topology information
 	NUMANode     int
 	NUMADistance map[int]int
+	// SR-IOV VF counts and inventory
+	TotalVFs int
+	NumVFs   int
+	VFs      []VFInfo
+	// Devlink eswitch state, e.g. "mode switchdev inline-mode none"
+	Eswitch string
+}
+
+// VFInfo represents virtual function information for CLI output
+type VFInfo struct {
+	Index      int    `json:"index"`
+	PCIAddress string `json:"pci_address"`
+	Driver     string `json:"driver"`
+	NetDev     string `json:"netdev,omitempty"`
+	MAC        string `json:"mac,omitempty"`
+	Vlan       int    `json:"vlan"`
+	Qos        int    `json:"qos"`
+	Trust      bool   `json:"trust"`
+	SpoofChk   bool   `json:"spoofchk"`
+	QueryRSS   bool   `json:"query_rss"`
+	LinkState  string `json:"link_state,omitempty"`
+	MinTxRate  uint32 `json:"min_tx_rate"`
+	MaxTxRate  uint32 `json:"max_tx_rate"`
+	IOMMUGroup string `json:"iommu_group,omitempty"`
+	// Switchdev representor netdev and its port function state
+	Representor  string `json:"representor,omitempty"`
+	FunctionAddr string `json:"function_hw_addr,omitempty"`
+	FunctionRoce string `json:"function_roce,omitempty"`
+}
+
+// SFInfo represents scalable function information for CLI output
+type SFInfo struct {
+	SFNum       uint32 `json:"sfnum"`
+	PFNum       uint32 `json:"pfnum"`
+	PortIndex   uint32 `json:"port_index"`
+	Representor string `json:"representor,omitempty"`
+	HwAddr      string `json:"hw_addr,omitempty"`
+	State       string `json:"state,omitempty"`
+	OpState     string `json:"opstate,omitempty"`
+	AuxDevice   string `json:"aux_device,omitempty"`
+	NetDev      string `json:"netdev,omitempty"`
 }
 
 // DetailedCapabilityInfo represents detailed capability information
@@ -81,6 +122,9 @@ func formatDeviceJSON(devices []DeviceInfo) string {
 		EthtoolInfo          *EthtoolInfo                      `json:"ethtool_info,omitempty"`
 		NUMANode             int                               `json:"numa_node"`
 		NUMADistance         map[int]int                       `json:"numa_distance,omitempty"`
+		TotalVFs             int                               `json:"total_vfs,omitempty"`
+		NumVFs               int                               `json:"num_vfs,omitempty"`
+		VFs                  []VFInfo                          `json:"vfs,omitempty"`
 	}
 
 	var output []DeviceOutput
@@ -96,6 +140,9 @@ func formatDeviceJSON(devices []DeviceInfo) string {
 			EthtoolInfo:          device.EthtoolInfo,
 			NUMANode:             device.NUMANode,
 			NUMADistance:         device.NUMADistance,
+			TotalVFs:             device.TotalVFs,
+			NumVFs:               device.NumVFs,
+			VFs:                  device.VFs,
 		})
 	}
 
@@ -136,6 +183,9 @@ func formatDeviceDetailed(devices []DeviceInfo) string {
 		builder.WriteString(fmt.Sprintf("  Product: %s\n", device.Product))
 		builder.WriteString(fmt.Sprintf("  SR-IOV Capable: %t\n", device.SRIOVCapable))
 		builder.WriteString(fmt.Sprintf("  NUMA Node: %d\n", device.NUMANode))
+		if device.Eswitch != "" {
+			builder.WriteString(fmt.Sprintf("  Eswitch: %s\n", device.Eswitch))
+		}
 		if len(device.NUMADistance) > 0 {
 			var distances []string
 			for node, distance := range device.NUMADistance {
@@ -271,8 +321,8 @@ func formatDeviceTableSRIOV(devices []DeviceInfo) string {
 		totalVFs := "N/A"
 		currentVFs := "N/A"
 		if device.SRIOVCapable {
-			totalVFs = "16"  // Default value, could be enhanced to get actual VF count
-			currentVFs = "4" // Default value, could be enhanced to get actual VF count
+			totalVFs = fmt.Sprintf("%d", device.TotalVFs)
+			currentVFs = fmt.Sprintf("%d", device.NumVFs)
 		}
 
 		numaInfo := "No affinity"
@@ -284,12 +334,65 @@ func formatDeviceTableSRIOV(devices []DeviceInfo) string {
 
 		builder.WriteString(fmt.Sprintf("│ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │\n",
 			interfaceName, description, product, vendor, pciAddr, totalVFs, currentVFs, numaInfo, driver))
+
+		// One row per VF beneath its PF
+		for _, vf := range device.VFs {
+			vfName := fmt.Sprintf("└ vf%d %s", vf.Index, vf.NetDev)
+			builder.WriteString(fmt.Sprintf("│ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │ %-19s │\n",
+				truncateString(vfName, 19), "", "", "", vf.PCIAddress, "", "", "", truncateString(vf.Driver, 19)))
+		}
 	}
 
 	builder.WriteString("└─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┴─────────────────────┘\n")
 	return builder.String()
 }
 
+func formatVFTable(vfs []VFInfo) string {
+	var builder strings.Builder
+	builder.WriteString("┌───────┬──────────────┬─────────────────────┬─────────────────────┬─────────────────────┬───────────────────┬───────┬───────┬────────┬──────────┬───────────┬─────────┬─────────────┬───────┐\n")
+	builder.WriteString("│ INDEX │ PCI ADDRESS  │ DRIVER              │ NETDEV              │ REPRESENTOR         │ MAC               │ VLAN  │ QOS   │ TRUST  │ SPOOFCHK │ QUERY RSS │ LINK    │ TX RATE     │ IOMMU │\n")
+	builder.WriteString("├───────┼──────────────┼─────────────────────┼─────────────────────┼─────────────────────┼───────────────────┼───────┼───────┼────────┼──────────┼───────────┼─────────┼─────────────┼───────┤\n")
+
+	for _, vf := range vfs {
+		trust := "off"
+		if vf.Trust {
+			trust = "on"
+		}
+		spoofchk := "off"
+		if vf.SpoofChk {
+			spoofchk = "on"
+		}
+		queryRSS := "off"
+		if vf.QueryRSS {
+			queryRSS = "on"
+		}
+		txRate := fmt.Sprintf("%d-%d", vf.MinTxRate, vf.MaxTxRate)
+
+		builder.WriteString(fmt.Sprintf("│ %-5d │ %-12s │ %-19s │ %-19s │ %-19s │ %-17s │ %-5d │ %-5d │ %-6s │ %-8s │ %-9s │ %-7s │ %-11s │ %-5s │\n",
+			vf.Index, vf.PCIAddress, truncateString(vf.Driver, 19), truncateString(vf.NetDev, 19), truncateString(vf.Representor, 19), vf.MAC,
+			vf.Vlan, vf.Qos, trust, spoofchk, queryRSS, truncateString(vf.LinkState, 7), txRate, vf.IOMMUGroup))
+	}
+
+	builder.WriteString("└───────┴──────────────┴─────────────────────┴─────────────────────┴─────────────────────┴───────────────────┴───────┴───────┴────────┴──────────┴───────────┴─────────┴─────────────┴───────┘\n")
+	return builder.String()
+}
+
+func formatSFTable(sfs []SFInfo) string {
+	var builder strings.Builder
+	builder.WriteString("┌────────────┬───────┬─────────────────────┬───────────────────┬──────────┬──────────┬─────────────────────┬─────────────────────┐\n")
+	builder.WriteString("│ SFNUM      │ PFNUM │ REPRESENTOR         │ HW ADDR           │ STATE    │ OPSTATE  │ AUX DEVICE          │ NETDEV              │\n")
+	builder.WriteString("├────────────┼───────┼─────────────────────┼───────────────────┼──────────┼──────────┼─────────────────────┼─────────────────────┤\n")
+
+	for _, sf := range sfs {
+		builder.WriteString(fmt.Sprintf("│ %-10d │ %-5d │ %-19s │ %-17s │ %-8s │ %-8s │ %-19s │ %-19s │\n",
+			sf.SFNum, sf.PFNum, truncateString(sf.Representor, 19), sf.HwAddr, sf.State, sf.OpState,
+			truncateString(sf.AuxDevice, 19), truncateString(sf.NetDev, 19)))
+	}
+
+	builder.WriteString("└────────────┴───────┴─────────────────────┴───────────────────┴──────────┴──────────┴─────────────────────┴─────────────────────┘\n")
+	return builder.String()
+}
+
 // truncateString truncates a string to the specified length, adding "..." if needed
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"example.com/sriov-plugin/pkg"
+	"example.com/sriov-plugin/proto"
+)
+
+var (
+	// VF command flags
+	vfServerAddr string
+	vfTimeout    time.Duration
+	vfFormat     string
+	vfLogLevel   string
+
+	// VF allocate flags
+	vfAllocCount      int
+	vfAllocNUMANode   int
+	vfAllocStrictNUMA bool
+	vfAllocOwner      string
+	vfAllocMetadata   map[string]string
+	vfAllocTTL        time.Duration
+
+	// VF bind flags
+	vfBindDriver string
+)
+
+var vfCmd = &cobra.Command{
+	Use:   "vf",
+	Short: "Inspect, lease and bind SR-IOV virtual functions",
+	Long: `Inspect the virtual functions of an SR-IOV physical function, lease VFs
+from the resource pools configured on the server, and move VFs between kernel
+drivers.
+
+Examples:
+  sriov vf list ens60f0np0              # List VFs of a PF by interface name
+  sriov vf list 0000:31:00.0            # List VFs of a PF by PCI address
+  sriov vf list ens60f0np0 --format json
+  sriov vf allocate example.com/cx7_vf --count 2 --numa-node 0 --owner job-42 --ttl 1h
+  sriov vf release 3f9c0d2e8a1b4c6d9e0f1a2b3c4d5e6f
+  sriov vf bind 0000:31:00.2 0000:31:00.3 --driver vfio-pci
+  sriov vf bind 0000:31:00.2 --driver mlx5_core`,
+}
+
+var vfAllocateCmd = &cobra.Command{
+	Use:   "allocate <pool>",
+	Short: "Lease VFs from a resource pool",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runVFAllocate,
+}
+
+var vfBindCmd = &cobra.Command{
+	Use:   "bind <vf-pci-address>...",
+	Short: "Bind VFs to a kernel driver",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runVFBind,
+}
+
+var vfReleaseCmd = &cobra.Command{
+	Use:   "release <lease-id>",
+	Short: "Release a VF lease",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runVFRelease,
+}
+
+var vfListCmd = &cobra.Command{
+	Use:   "list <pf>",
+	Short: "List the VFs of a physical function",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runVFList,
+}
+
+func init() {
+	rootCmd.AddCommand(vfCmd)
+	vfCmd.AddCommand(vfListCmd)
+	vfCmd.AddCommand(vfAllocateCmd)
+	vfCmd.AddCommand(vfReleaseCmd)
+	vfCmd.AddCommand(vfBindCmd)
+
+	// Add flags
+	vfCmd.PersistentFlags().StringVar(&vfServerAddr, "server", "localhost:50051", "gRPC server address")
+	vfCmd.PersistentFlags().DurationVar(&vfTimeout, "timeout", 5*time.Second, "Connection timeout")
+	vfCmd.PersistentFlags().StringVar(&vfLogLevel, "log-level", "warn", "Log level: debug, info, warn, error")
+	vfListCmd.Flags().StringVar(&vfFormat, "format", "table", "Output format: table, json")
+	vfAllocateCmd.Flags().StringVar(&vfFormat, "format", "table", "Output format: table, json")
+	vfAllocateCmd.Flags().IntVar(&vfAllocCount, "count", 1, "Number of VFs to lease")
+	vfAllocateCmd.Flags().IntVar(&vfAllocNUMANode, "numa-node", -1, "Preferred NUMA node, -1 for no preference")
+	vfAllocateCmd.Flags().BoolVar(&vfAllocStrictNUMA, "strict-numa", false, "Fail rather than lease VFs outside --numa-node")
+	vfAllocateCmd.Flags().StringVar(&vfAllocOwner, "owner", "", "Owner recorded on the lease")
+	vfAllocateCmd.Flags().StringToStringVar(&vfAllocMetadata, "meta", nil, "Lease metadata as key=value pairs")
+	vfAllocateCmd.Flags().DurationVar(&vfAllocTTL, "ttl", 0, "Lease lifetime, 0 to keep it until released")
+	vfBindCmd.Flags().StringVar(&vfBindDriver, "driver", "", "Kernel driver to bind to, e.g. vfio-pci")
+	vfBindCmd.MarkFlagRequired("driver")
+}
+
+// dialServer connects to the SR-IOV gRPC server
+func dialServer(addr string) (*grpc.ClientConn, error) {
+	return dialServerContext(context.Background(), addr)
+}
+
+// dialServerContext connects to the SR-IOV server, giving up when ctx is done
+func dialServerContext(ctx context.Context, addr string) (*grpc.ClientConn, error) {
+	pkg.Info("Connecting to SR-IOV server at %s...", addr)
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %v", err)
+	}
+	return conn, nil
+}
+
+func runVFList(cmd *cobra.Command, args []string) error {
+	// Set log level from flag
+	if err := pkg.SetLogLevelFromString(vfLogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+
+	conn, err := dialServer(vfServerAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := proto.NewSRIOVManagerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), vfTimeout)
+	defer cancel()
+
+	resp, err := c.ListVFs(ctx, &proto.ListVFsRequest{Pf: args[0]})
+	if err != nil {
+		return fmt.Errorf("could not list VFs: %v", err)
+	}
+
+	vfs := vfInfoFromProto(resp.Vfs)
+
+	switch strings.ToLower(vfFormat) {
+	case "json":
+		data, _ := json.MarshalIndent(vfs, "", "  ")
+		fmt.Println(string(data))
+	case "table":
+		fmt.Printf("PF %s (%s): %d VFs\n", resp.PfName, resp.PfAddress, len(vfs))
+		fmt.Println(formatVFTable(vfs))
+	default:
+		return fmt.Errorf("invalid format: %s. Use: table or json", vfFormat)
+	}
+
+	return nil
+}
+
+// vfInfoFromProto converts protobuf VFs to VFInfo for consistent formatting
+func vfInfoFromProto(pbVFs []*proto.VirtualFunction) []VFInfo {
+	var vfs []VFInfo
+	for _, vf := range pbVFs {
+		vfs = append(vfs, VFInfo{
+			Index:        int(vf.Index),
+			PCIAddress:   vf.PciAddress,
+			Driver:       vf.Driver,
+			NetDev:       vf.Netdev,
+			MAC:          vf.Mac,
+			Vlan:         int(vf.Vlan),
+			Qos:          int(vf.Qos),
+			Trust:        vf.Trust,
+			SpoofChk:     vf.Spoofchk,
+			QueryRSS:     vf.QueryRss,
+			LinkState:    vf.LinkState,
+			MinTxRate:    vf.MinTxRate,
+			MaxTxRate:    vf.MaxTxRate,
+			IOMMUGroup:   vf.IommuGroup,
+			Representor:  vf.Representor,
+			FunctionAddr: vf.GetPortFunction().GetHwAddr(),
+			FunctionRoce: vf.GetPortFunction().GetRoce(),
+		})
+	}
+	return vfs
+}
+
+func runVFAllocate(cmd *cobra.Command, args []string) error {
+	// Set log level from flag
+	if err := pkg.SetLogLevelFromString(vfLogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+
+	format := strings.ToLower(vfFormat)
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format: %s. Use: table or json", vfFormat)
+	}
+
+	conn, err := dialServer(vfServerAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := proto.NewSRIOVManagerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), vfTimeout)
+	defer cancel()
+
+	req := &proto.AllocateVFRequest{
+		Pool:       args[0],
+		Count:      int32(vfAllocCount),
+		StrictNuma: vfAllocStrictNUMA,
+		Owner:      vfAllocOwner,
+		Metadata:   vfAllocMetadata,
+		TtlSeconds: int64(vfAllocTTL / time.Second),
+	}
+	if vfAllocNUMANode >= 0 {
+		node := int32(vfAllocNUMANode)
+		req.NumaNode = &node
+	}
+	resp, err := c.AllocateVF(ctx, req)
+	if err != nil {
+		return fmt.Errorf("could not allocate VFs: %v", err)
+	}
+
+	lease := resp.Lease
+	if format == "json" {
+		data, _ := json.MarshalIndent(lease, "", "  ")
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Lease %s from %s: %s\n", lease.Id, lease.Pool, strings.Join(lease.PciAddresses, ", "))
+	if lease.ExpiresAt != 0 {
+		fmt.Printf("Expires at %s\n", time.Unix(0, lease.ExpiresAt).Format(time.RFC3339))
+	}
+	return nil
+}
+
+func runVFRelease(cmd *cobra.Command, args []string) error {
+	// Set log level from flag
+	if err := pkg.SetLogLevelFromString(vfLogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+
+	conn, err := dialServer(vfServerAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := proto.NewSRIOVManagerClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), vfTimeout)
+	defer cancel()
+
+	if _, err := c.ReleaseVF(ctx, &proto.ReleaseVFRequest{LeaseId: args[0]}); err != nil {
+		return fmt.Errorf("could not release lease: %v", err)
+	}
+	fmt.Printf("Released lease %s\n", args[0])
+	return nil
+}
+
+func runVFBind(cmd *cobra.Command, args []string) error {
+	// Set log level from flag
+	if err := pkg.SetLogLevelFromString(vfLogLevel); err != nil {
+		return fmt.Errorf("invalid log level: %v", err)
+	}
+
+	conn, err := dialServer(vfServerAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c := proto.NewSRIOVManagerClient(conn)
+
+	for _, address := range args {
+		ctx, cancel := context.WithTimeout(context.Background(), vfTimeout)
+		resp, err := c.BindDriver(ctx, &proto.BindDriverRequest{PciAddress: address, Driver: vfBindDriver})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("could not bind %s to %s: %v", address, vfBindDriver, err)
+		}
+		previous := resp.PreviousDriver
+		if previous == "" {
+			previous = "no driver"
+		}
+		fmt.Printf("%s: %s -> %s\n", resp.PciAddress, previous, resp.Driver)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ActionType identifies the kind of change an action makes
+type ActionType string
+
+const (
+	ActionWriteSysfs ActionType = "write-sysfs"
+	ActionRunCommand ActionType = "run-command"
+	ActionNetlink    ActionType = "netlink"
+)
+
+// Action is a single mutating step taken by the manager
+type Action struct {
+	Type        ActionType `json:"type"`
+	Target      string     `json:"target"`
+	Current     string     `json:"current"`
+	Desired     string     `json:"desired"`
+	Description string     `json:"description"`
+}
+
+// Plan is the ordered list of actions a dry run would have taken
+type Plan struct {
+	Actions []Action `json:"actions"`
+}
+
+// JSON renders the plan as indented JSON
+func (p *Plan) JSON() (string, error) {
+	actions := p.Actions
+	if actions == nil {
+		actions = []Action{}
+	}
+	data, err := json.MarshalIndent(Plan{Actions: actions}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal plan: %v", err)
+	}
+	return string(data), nil
+}
+
+// Text renders the plan as a human readable table
+func (p *Plan) Text() string {
+	if len(p.Actions) == 0 {
+		return "No changes planned"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Planned %d action(s):\n", len(p.Actions))
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tACTION\tTARGET\tCURRENT\tDESIRED\tDESCRIPTION")
+	for i, action := range p.Actions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, action.Type, action.Target,
+			planValue(action.Current), planValue(action.Desired), action.Description)
+	}
+	w.Flush()
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+// planValue shows empty values as a dash so table columns stay aligned
+func planValue(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// writeSysfsFile writes a sysfs attribute, overridden in tests to emulate the kernel
+var writeSysfsFile = os.WriteFile
+
+// Plan returns the actions recorded in dry-run mode, in the order they were planned
+func (m *SRIOVManager) Plan() *Plan {
+	m.actionMu.Lock()
+	defer m.actionMu.Unlock()
+	return &Plan{Actions: append([]Action(nil), m.planned...)}
+}
+
+// recordAction appends an action to the dry-run plan
+func (m *SRIOVManager) recordAction(action Action) {
+	m.actionMu.Lock()
+	m.planned = append(m.planned, action)
+	m.actionMu.Unlock()
+
+	WithFields(logrus.Fields{
+		"action":  string(action.Type),
+		"target":  action.Target,
+		"current": action.Current,
+		"desired": action.Desired,
+	}).Info("Dry run: " + action.Description)
+}
+
+// readSysfsValue returns the value of a sysfs attribute, taking writes
+// planned earlier in the same dry run into account
+func (m *SRIOVManager) readSysfsValue(path string) (string, error) {
+	m.actionMu.Lock()
+	value, ok := m.dryRunValues[path]
+	m.actionMu.Unlock()
+	if ok {
+		return value, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeSysfs writes a sysfs attribute, or records the write in dry-run mode
+func (m *SRIOVManager) writeSysfs(description, path, value string) error {
+	if !m.Config().DryRun {
+		return writeSysfsFile(path, []byte(value), 0644)
+	}
+
+	current, _ := m.readSysfsValue(path)
+	m.recordAction(Action{
+		Type:        ActionWriteSysfs,
+		Target:      path,
+		Current:     current,
+		Desired:     value,
+		Description: description,
+	})
+
+	m.actionMu.Lock()
+	if m.dryRunValues == nil {
+		m.dryRunValues = make(map[string]string)
+	}
+	m.dryRunValues[path] = value
+	m.actionMu.Unlock()
+	return nil
+}
+
+// runCommand runs an external command and returns its combined output, or
+// records the command in dry-run mode. current and desired describe the
+// state the command changes.
+func (m *SRIOVManager) runCommand(description, current, desired, name string, args ...string) ([]byte, error) {
+	if !m.Config().DryRun {
+		return exec.Command(name, args...).CombinedOutput()
+	}
+
+	m.recordAction(Action{
+		Type:        ActionRunCommand,
+		Target:      strings.Join(append([]string{name}, args...), " "),
+		Current:     current,
+		Desired:     desired,
+		Description: description,
+	})
+	return nil, nil
+}
+
+// applyNetlink runs a netlink change, or records it in dry-run mode. target
+// names the attribute in ip(8) terms, e.g. "ens60f0np0 vf 0 trust".
+func (m *SRIOVManager) applyNetlink(description, target, current, desired string, apply func() error) error {
+	if !m.Config().DryRun {
+		return apply()
+	}
+
+	m.recordAction(Action{
+		Type:        ActionNetlink,
+		Target:      target,
+		Current:     current,
+		Desired:     desired,
+		Description: description,
+	})
+	return nil
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDryRunPlan tests that dry-run mode records an ordered plan without making changes
+func TestDryRunPlan(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	pfDir := filepath.Join(root, "bus", "pci", "devices", "0000:31:00.0")
+	writeSysfsFiles(t, pfDir, map[string]string{"sriov_numvfs": "2\n"})
+	symlink(t, "../../../bus/pci/devices/0000:31:00.0", filepath.Join(root, "class", "net", "ens60f0np0", "device"))
+	useFakeBondLinks(t)
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		DryRun: true,
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 8, Mode: ModeVFLag},
+		},
+		BondConfigs: []BondConfig{
+			{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0"}, Mode: "active-backup"},
+		},
+	})
+
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcilePlanned {
+		t.Errorf("expected planned, got %s (%s)", status.State, status.Message)
+	}
+
+	sriovPath := filepath.Join(pfDir, "sriov_numvfs")
+	expected := []Action{
+		{Type: ActionWriteSysfs, Target: sriovPath, Current: "2", Desired: "0"},
+		{Type: ActionWriteSysfs, Target: sriovPath, Current: "0", Desired: "8"},
+		{Type: ActionNetlink, Target: "bond0", Current: "absent", Desired: "bond mode active-backup"},
+		{Type: ActionNetlink, Target: "ens60f0np0 master", Current: "", Desired: "bond0"},
+		{Type: ActionNetlink, Target: "bond0 state", Current: "down", Desired: "up"},
+	}
+
+	plan := manager.Plan()
+	if len(plan.Actions) != len(expected) {
+		t.Fatalf("expected %d actions, got %d: %+v", len(expected), len(plan.Actions), plan.Actions)
+	}
+	for i, want := range expected {
+		got := plan.Actions[i]
+		if got.Type != want.Type || got.Target != want.Target || got.Current != want.Current || got.Desired != want.Desired {
+			t.Errorf("action %d: expected %+v, got %+v", i, want, got)
+		}
+		if got.Description == "" {
+			t.Errorf("action %d: missing description", i)
+		}
+	}
+
+	data, err := os.ReadFile(sriovPath)
+	if err != nil {
+		t.Fatalf("failed to read sriov_numvfs: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "2" {
+		t.Errorf("dry run modified sriov_numvfs: %q", string(data))
+	}
+}
+
+// TestPlanOutput tests text and JSON rendering of a plan
+func TestPlanOutput(t *testing.T) {
+	empty := &Plan{}
+	if empty.Text() != "No changes planned" {
+		t.Errorf("unexpected empty plan text: %q", empty.Text())
+	}
+	out, err := empty.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	if !strings.Contains(out, `"actions": []`) {
+		t.Errorf("expected empty actions array, got %s", out)
+	}
+
+	plan := &Plan{Actions: []Action{
+		{Type: ActionWriteSysfs, Target: "/sys/bus/pci/devices/0000:31:00.0/sriov_numvfs", Current: "0", Desired: "4", Description: "set VF count on ens60f0np0"},
+		{Type: ActionRunCommand, Target: "ip link set bond0 up", Desired: "up", Description: "bring up bond0"},
+	}}
+
+	text := plan.Text()
+	lines := strings.Split(text, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, column and 2 action lines, got %d:\n%s", len(lines), text)
+	}
+	if !strings.HasPrefix(lines[2], "1") || !strings.Contains(lines[2], "write-sysfs") || !strings.Contains(lines[2], "set VF count on ens60f0np0") {
+		t.Errorf("unexpected first action line: %q", lines[2])
+	}
+	if !strings.Contains(lines[3], " - ") {
+		t.Errorf("expected empty current value shown as dash: %q", lines[3])
+	}
+
+	out, err = plan.JSON()
+	if err != nil {
+		t.Fatalf("JSON failed: %v", err)
+	}
+	var decoded Plan
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("plan JSON does not round-trip: %v", err)
+	}
+	if len(decoded.Actions) != 2 || decoded.Actions[0].Desired != "4" {
+		t.Errorf("unexpected decoded plan: %+v", decoded)
+	}
+}
@@ -0,0 +1,426 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+// BondState is the current configuration of a bond interface
+type BondState struct {
+	Mode           string
+	MIIMonitor     int
+	LACPRate       string
+	XmitHashPolicy string
+	Up             bool
+}
+
+// bondLinkManager creates and configures bonds over rtnetlink
+type bondLinkManager interface {
+	// Bond returns the state of a bond, nil when it does not exist
+	Bond(name string) (*BondState, error)
+	Add(bond BondConfig) error
+	Delete(name string) error
+	// Modify applies the set fields of bond to an existing bond
+	Modify(bond BondConfig) error
+	// Master returns the master of an interface, empty when it has none
+	Master(name string) (string, error)
+	Enslave(slave, bond string) error
+	// Release removes an interface from its master
+	Release(slave string) error
+	SetUp(name string) error
+}
+
+// bondLinks is defined as a variable so it can be overridden in tests
+var bondLinks bondLinkManager = netlinkBondLinks{}
+
+// netlinkBondLinks manages bonds through the rtnetlink API
+type netlinkBondLinks struct{}
+
+func (netlinkBondLinks) Bond(name string) (*BondState, error) {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	bond, ok := link.(*netlink.Bond)
+	if !ok {
+		return nil, fmt.Errorf("%s is a %s interface, not a bond", name, link.Type())
+	}
+	return &BondState{
+		Mode:           bond.Mode.String(),
+		MIIMonitor:     bond.Miimon,
+		LACPRate:       bond.LacpRate.String(),
+		XmitHashPolicy: bond.XmitHashPolicy.String(),
+		Up:             bond.Attrs().Flags&net.FlagUp != 0,
+	}, nil
+}
+
+func (netlinkBondLinks) Add(bond BondConfig) error {
+	return netlink.LinkAdd(newBondLink(bond, netlink.LinkAttrs{Name: bond.BondName}))
+}
+
+func (netlinkBondLinks) Delete(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkDel(link)
+}
+
+func (netlinkBondLinks) Modify(bond BondConfig) error {
+	link, err := netlink.LinkByName(bond.BondName)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkModify(newBondLink(bond, netlink.LinkAttrs{Name: bond.BondName, Index: link.Attrs().Index}))
+}
+
+func (netlinkBondLinks) Master(name string) (string, error) {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return "", err
+	}
+	if link.Attrs().MasterIndex == 0 {
+		return "", nil
+	}
+	master, err := netlink.LinkByIndex(link.Attrs().MasterIndex)
+	if err != nil {
+		return "", err
+	}
+	return master.Attrs().Name, nil
+}
+
+// Enslave takes the slave down first, which the bonding driver requires
+func (netlinkBondLinks) Enslave(slave, bond string) error {
+	slaveLink, err := netlink.LinkByName(slave)
+	if err != nil {
+		return err
+	}
+	bondLink, err := netlink.LinkByName(bond)
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkSetDown(slaveLink); err != nil {
+		return fmt.Errorf("failed to take %s down: %v", slave, err)
+	}
+	return netlink.LinkSetMaster(slaveLink, bondLink)
+}
+
+func (netlinkBondLinks) Release(slave string) error {
+	link, err := netlink.LinkByName(slave)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetNoMaster(link)
+}
+
+func (netlinkBondLinks) SetUp(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetUp(link)
+}
+
+// newBondLink converts the set fields of a bond configuration
+func newBondLink(config BondConfig, attrs netlink.LinkAttrs) *netlink.Bond {
+	bond := netlink.NewLinkBond(attrs)
+	if config.Mode != "" {
+		bond.Mode = netlink.StringToBondMode(config.Mode)
+	}
+	if config.MIIMonitor > 0 {
+		bond.Miimon = config.MIIMonitor
+	}
+	if config.LACPRate != "" {
+		bond.LacpRate = netlink.StringToBondLacpRate(config.LACPRate)
+	}
+	if config.XmitHashPolicy != "" {
+		bond.XmitHashPolicy = netlink.StringToBondXmitHashPolicy(config.XmitHashPolicy)
+	}
+	return bond
+}
+
+// Validate checks the bond name, mode and LACP parameters
+func (b *BondConfig) Validate() error {
+	if b.BondName == "" {
+		return fmt.Errorf("bond_name is required")
+	}
+	if len(b.BondName) > 15 || strings.ContainsAny(b.BondName, "/ ") {
+		return fmt.Errorf("invalid bond_name %q", b.BondName)
+	}
+	if len(b.SlaveInterfaces) == 0 {
+		return fmt.Errorf("slave_interfaces is required")
+	}
+	if _, ok := netlink.StringToBondModeMap[b.Mode]; b.Mode != "" && !ok {
+		return fmt.Errorf("invalid mode %q", b.Mode)
+	}
+	if b.MIIMonitor < 0 {
+		return fmt.Errorf("mii_monitor must be >= 0")
+	}
+	if b.LACPRate != "" {
+		if _, ok := netlink.StringToBondLacpRateMap[b.LACPRate]; !ok {
+			return fmt.Errorf("invalid lacp_rate %q, expected slow or fast", b.LACPRate)
+		}
+		if b.Mode != "802.3ad" {
+			return fmt.Errorf("lacp_rate requires mode 802.3ad")
+		}
+	}
+	if b.XmitHashPolicy != "" {
+		if _, ok := netlink.StringToBondXmitHashPolicyMap[b.XmitHashPolicy]; !ok {
+			return fmt.Errorf("invalid xmit_hash_policy %q", b.XmitHashPolicy)
+		}
+		if b.Mode != "802.3ad" && b.Mode != "balance-xor" && b.Mode != "balance-tlb" {
+			return fmt.Errorf("xmit_hash_policy requires mode 802.3ad, balance-xor or balance-tlb")
+		}
+	}
+	return nil
+}
+
+// String formats the set bonding options the way ip(8) takes them
+func (b BondConfig) String() string {
+	parts := []string{"bond"}
+	if b.Mode != "" {
+		parts = append(parts, "mode "+b.Mode)
+	}
+	if b.MIIMonitor > 0 {
+		parts = append(parts, fmt.Sprintf("miimon %d", b.MIIMonitor))
+	}
+	if b.LACPRate != "" {
+		parts = append(parts, "lacp_rate "+b.LACPRate)
+	}
+	if b.XmitHashPolicy != "" {
+		parts = append(parts, "xmit_hash_policy "+b.XmitHashPolicy)
+	}
+	return strings.Join(parts, " ")
+}
+
+// hasOptions reports whether any bonding option is set
+func (b BondConfig) hasOptions() bool {
+	return b.Mode != "" || b.MIIMonitor > 0 || b.LACPRate != "" || b.XmitHashPolicy != ""
+}
+
+// changes returns the set options of b that differ from the bond's state,
+// in BondConfig form
+func (b BondConfig) changes(current BondState) BondConfig {
+	diff := BondConfig{BondName: b.BondName}
+	if b.Mode != "" && b.Mode != current.Mode {
+		diff.Mode = b.Mode
+	}
+	if b.MIIMonitor > 0 && b.MIIMonitor != current.MIIMonitor {
+		diff.MIIMonitor = b.MIIMonitor
+	}
+	if b.LACPRate != "" && b.LACPRate != current.LACPRate {
+		diff.LACPRate = b.LACPRate
+	}
+	if b.XmitHashPolicy != "" && b.XmitHashPolicy != current.XmitHashPolicy {
+		diff.XmitHashPolicy = b.XmitHashPolicy
+	}
+	return diff
+}
+
+// configureVFLagMode builds the bond of a VF-LAG device and checks that the
+// hardware LAG engaged, returning a description of each change
+func (m *SRIOVManager) configureVFLagMode(device Device, bond *BondConfig) ([]string, error) {
+	if bond == nil {
+		return nil, fmt.Errorf("no bond configuration found for device %s", device.Name)
+	}
+
+	actions, err := m.ensureBond(bond)
+	if err != nil || m.Config().DryRun {
+		return actions, err
+	}
+	if m.Config().SkipLAGVerification {
+		WithFields(logrus.Fields{"device": device.Name, "bond": bond.BondName}).Debug("Hardware LAG not verified")
+		return actions, nil
+	}
+	// Hardware LAG engages asynchronously once every slave is enslaved
+	return actions, VendorPluginFor(device).VerifyLAG(device, bond)
+}
+
+// pendingVFLagSlaves returns the slaves of a bond, other than the device
+// being configured, that are not in switchdev mode yet but whose own policy
+// puts them there. Their PFs are configured later in the same pass, and the
+// bond can only be built once every slave is in switchdev mode.
+func (m *SRIOVManager) pendingVFLagSlaves(device Device, bond *BondConfig) []string {
+	if m.Config().DryRun {
+		return nil
+	}
+	var pending []string
+	for _, slave := range bond.SlaveInterfaces {
+		if slave == device.Name {
+			continue
+		}
+		pf, ok := netdevPciFunction(slave)
+		if !ok {
+			continue
+		}
+		state, err := getEswitchState(pf.PCIAddress)
+		if err != nil || state.Mode == EswitchModeSwitchdev {
+			continue
+		}
+		if policy := m.Config().GetDevicePolicyForDevice(pf); policy != nil && policy.DesiredEswitch().Mode == EswitchModeSwitchdev {
+			pending = append(pending, slave)
+		}
+	}
+	return pending
+}
+
+// netdevPciFunction reads the PCI function behind an interface, with the
+// IDs, driver and NUMA node a policy selects on
+func netdevPciFunction(name string) (Device, bool) {
+	devicePath := filepath.Join(NetClassPath(), name, "device")
+	target, err := os.Readlink(devicePath)
+	if err != nil {
+		return Device{}, false
+	}
+	device := readPciFunction(filepath.Base(target), devicePath)
+	device.Name = name
+	return device, true
+}
+
+// readPciFunction reads the IDs, driver and NUMA node of the PCI function
+// in devicePath
+func readPciFunction(pciAddress, devicePath string) Device {
+	device := Device{
+		PCIAddress:  pciAddress,
+		VendorID:    normalizePciID(readSysfsAttr(devicePath, "vendor")),
+		DeviceID:    normalizePciID(readSysfsAttr(devicePath, "device")),
+		SubVendorID: normalizePciID(readSysfsAttr(devicePath, "subsystem_vendor")),
+		SubDeviceID: normalizePciID(readSysfsAttr(devicePath, "subsystem_device")),
+		Driver:      readSysfsLink(devicePath, "driver"),
+		NUMANode:    -1,
+	}
+	if node, err := strconv.Atoi(readSysfsAttr(devicePath, "numa_node")); err == nil {
+		device.NUMANode = node
+	}
+	return device
+}
+
+// findBondConfig returns the bond configuration that lists an interface as a slave
+func (m *SRIOVManager) findBondConfig(name string) *BondConfig {
+	for i := range m.Config().BondConfigs {
+		for _, slave := range m.Config().BondConfigs[i].SlaveInterfaces {
+			if slave == name {
+				return &m.Config().BondConfigs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ensureBond creates and configures a bond, enslaves its interfaces and
+// brings it up, returning a description of each change. The slaves must
+// be ports of the same NIC in switchdev mode for the hardware to offload
+// the bond as a VF-LAG.
+func (m *SRIOVManager) ensureBond(bond *BondConfig) ([]string, error) {
+	if err := m.checkVFLagSlaves(bond); err != nil {
+		return nil, err
+	}
+
+	current, err := bondLinks.Bond(bond.BondName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bond %s: %v", bond.BondName, err)
+	}
+
+	var actions []string
+	if current == nil {
+		description := fmt.Sprintf("create bond %s", bond.BondName)
+		if err := m.applyNetlink(description, bond.BondName, "absent", bond.String(), func() error {
+			return bondLinks.Add(*bond)
+		}); err != nil {
+			return actions, fmt.Errorf("failed to create bond %s: %v", bond.BondName, err)
+		}
+		actions = append(actions, description)
+		current = &BondState{}
+	} else if diff := bond.changes(*current); diff.Mode != "" {
+		// The kernel only changes the mode of a bond without slaves
+		return actions, fmt.Errorf("bond %s is in mode %s, not %s; delete it to change the mode",
+			bond.BondName, current.Mode, bond.Mode)
+	} else if diff.hasOptions() {
+		description := fmt.Sprintf("update bond %s", bond.BondName)
+		currentConfig := BondConfig{MIIMonitor: current.MIIMonitor, LACPRate: current.LACPRate, XmitHashPolicy: current.XmitHashPolicy}
+		if err := m.applyNetlink(description, bond.BondName, currentConfig.String(), diff.String(), func() error {
+			return bondLinks.Modify(diff)
+		}); err != nil {
+			return actions, fmt.Errorf("failed to update bond %s: %v", bond.BondName, err)
+		}
+		actions = append(actions, description)
+	}
+
+	for _, slave := range bond.SlaveInterfaces {
+		master, err := bondLinks.Master(slave)
+		if err != nil {
+			return actions, fmt.Errorf("failed to read master of %s: %v", slave, err)
+		}
+		if master == bond.BondName {
+			continue
+		}
+		description := fmt.Sprintf("enslave %s to %s", slave, bond.BondName)
+		if err := m.applyNetlink(description, slave+" master", master, bond.BondName, func() error {
+			return bondLinks.Enslave(slave, bond.BondName)
+		}); err != nil {
+			return actions, fmt.Errorf("failed to enslave %s to %s: %v", slave, bond.BondName, err)
+		}
+		actions = append(actions, description)
+	}
+
+	if !current.Up {
+		description := fmt.Sprintf("bring up %s", bond.BondName)
+		if err := m.applyNetlink(description, bond.BondName+" state", "down", "up", func() error {
+			return bondLinks.SetUp(bond.BondName)
+		}); err != nil {
+			return actions, fmt.Errorf("failed to bring up %s: %v", bond.BondName, err)
+		}
+		actions = append(actions, description)
+	}
+	return actions, nil
+}
+
+// checkVFLagSlaves checks that the slaves of a bond are PCI functions of the
+// same NIC with their eswitch in switchdev mode. The eswitch is not checked
+// in dry-run mode, where a planned switchdev change has not happened yet.
+func (m *SRIOVManager) checkVFLagSlaves(bond *BondConfig) error {
+	var first, firstSlave string
+	for _, slave := range bond.SlaveInterfaces {
+		target, err := os.Readlink(filepath.Join(NetClassPath(), slave, "device"))
+		if err != nil {
+			return fmt.Errorf("VF-LAG slave %s of %s is not a PCI network device", slave, bond.BondName)
+		}
+		address := filepath.Base(target)
+
+		// Ports of one NIC differ only in the PCI function number
+		slot := address
+		if dot := strings.LastIndex(address, "."); dot >= 0 {
+			slot = address[:dot]
+		}
+		if first == "" {
+			first, firstSlave = slot, slave
+		} else if slot != first {
+			return fmt.Errorf("VF-LAG slaves of %s must be ports of the same NIC, but %s and %s are not",
+				bond.BondName, firstSlave, slave)
+		}
+
+		if m.Config().DryRun {
+			continue
+		}
+		state, err := getEswitchState(address)
+		if err != nil {
+			return fmt.Errorf("failed to read eswitch state of %s: %v", slave, err)
+		}
+		if state.Mode != EswitchModeSwitchdev {
+			return fmt.Errorf("VF-LAG slave %s of %s is in eswitch mode %q, not switchdev", slave, bond.BondName, state.Mode)
+		}
+	}
+	return nil
+}
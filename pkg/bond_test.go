@@ -0,0 +1,252 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeBondLinks keeps bonds and masters in memory and records every change
+type fakeBondLinks struct {
+	bonds   map[string]*BondState
+	masters map[string]string
+	calls   []string
+	// failEnslave is a slave that cannot be enslaved
+	failEnslave string
+}
+
+// useFakeBondLinks routes bond changes to a fake for the duration of a test
+func useFakeBondLinks(t *testing.T) *fakeBondLinks {
+	fake := &fakeBondLinks{bonds: make(map[string]*BondState), masters: make(map[string]string)}
+	original := bondLinks
+	bondLinks = fake
+	t.Cleanup(func() { bondLinks = original })
+	return fake
+}
+
+func (f *fakeBondLinks) Bond(name string) (*BondState, error) {
+	if state, ok := f.bonds[name]; ok {
+		copied := *state
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeBondLinks) Add(bond BondConfig) error {
+	f.bonds[bond.BondName] = &BondState{Mode: bond.Mode, MIIMonitor: bond.MIIMonitor, LACPRate: bond.LACPRate, XmitHashPolicy: bond.XmitHashPolicy}
+	f.calls = append(f.calls, "add "+bond.String())
+	return nil
+}
+
+func (f *fakeBondLinks) Delete(name string) error {
+	delete(f.bonds, name)
+	f.calls = append(f.calls, "delete "+name)
+	return nil
+}
+
+func (f *fakeBondLinks) Modify(bond BondConfig) error {
+	state := f.bonds[bond.BondName]
+	if bond.LACPRate != "" {
+		state.LACPRate = bond.LACPRate
+	}
+	if bond.XmitHashPolicy != "" {
+		state.XmitHashPolicy = bond.XmitHashPolicy
+	}
+	if bond.MIIMonitor > 0 {
+		state.MIIMonitor = bond.MIIMonitor
+	}
+	f.calls = append(f.calls, "modify "+bond.String())
+	return nil
+}
+
+func (f *fakeBondLinks) Master(name string) (string, error) {
+	return f.masters[name], nil
+}
+
+func (f *fakeBondLinks) Enslave(slave, bond string) error {
+	if slave == f.failEnslave {
+		return fmt.Errorf("operation not supported")
+	}
+	f.masters[slave] = bond
+	f.calls = append(f.calls, fmt.Sprintf("enslave %s to %s", slave, bond))
+	return nil
+}
+
+func (f *fakeBondLinks) Release(slave string) error {
+	delete(f.masters, slave)
+	f.calls = append(f.calls, "release "+slave)
+	return nil
+}
+
+func (f *fakeBondLinks) SetUp(name string) error {
+	f.bonds[name].Up = true
+	f.calls = append(f.calls, "up "+name)
+	return nil
+}
+
+// useVFLagSysfs creates a sysfs tree with the two ports of one ConnectX,
+// ens60f0np0 and ens60f1np1, and a port of another NIC, ens61f0np0. mlx5
+// debugfs reports an active hardware LAG on every port.
+func useVFLagSysfs(t *testing.T) string {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	t.Cleanup(func() { SetSysfsRoot("") })
+	for name, address := range map[string]string{
+		"ens60f0np0": "0000:31:00.0",
+		"ens60f1np1": "0000:31:00.1",
+		"ens61f0np0": "0000:4b:00.0",
+	} {
+		writeSysfsFiles(t, PciDevicePath(address), map[string]string{
+			"sriov_numvfs": "4\n", "vendor": "0x15b3\n", "device": "0x101e\n",
+		})
+		writeSysfsFiles(t, filepath.Dir(mlx5LagPath(address, "state")), map[string]string{"state": "active\n"})
+		symlink(t, filepath.Join("..", "..", "..", "bus", "pci", "devices", address), filepath.Join(NetClassPath(), name, "device"))
+	}
+	return root
+}
+
+// TestEnsureBond tests creating an LACP bond and converging an existing one
+func TestEnsureBond(t *testing.T) {
+	useVFLagSysfs(t)
+	useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	fake := useFakeBondLinks(t)
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	bond := &BondConfig{
+		BondName:        "bond0",
+		SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"},
+		Mode:            "802.3ad",
+		MIIMonitor:      100,
+		LACPRate:        "fast",
+		XmitHashPolicy:  "layer3+4",
+	}
+
+	actions, err := manager.ensureBond(bond)
+	if err != nil {
+		t.Fatalf("ensureBond failed: %v", err)
+	}
+	expectedCalls := []string{
+		"add bond mode 802.3ad miimon 100 lacp_rate fast xmit_hash_policy layer3+4",
+		"enslave ens60f0np0 to bond0", "enslave ens60f1np1 to bond0", "up bond0",
+	}
+	if !reflect.DeepEqual(fake.calls, expectedCalls) || len(actions) != 4 {
+		t.Errorf("expected calls %v, got %v (actions %v)", expectedCalls, fake.calls, actions)
+	}
+
+	fake.calls = nil
+	if actions, err := manager.ensureBond(bond); err != nil || len(actions) != 0 || len(fake.calls) != 0 {
+		t.Errorf("expected no changes, got %v %v %v", actions, fake.calls, err)
+	}
+
+	// LACP options change in place, the mode does not
+	fake.bonds["bond0"].LACPRate = "slow"
+	if _, err := manager.ensureBond(bond); err != nil || !reflect.DeepEqual(fake.calls, []string{"modify bond lacp_rate fast"}) {
+		t.Errorf("expected lacp_rate to be updated, got %v %v", fake.calls, err)
+	}
+	fake.bonds["bond0"].Mode = "active-backup"
+	if _, err := manager.ensureBond(bond); err == nil || !strings.Contains(err.Error(), "delete it to change the mode") {
+		t.Errorf("expected a mode change to be refused, got %v", err)
+	}
+}
+
+// TestCheckVFLagSlaves tests that VF-LAG slaves must be switchdev ports of
+// one NIC
+func TestCheckVFLagSlaves(t *testing.T) {
+	useVFLagSysfs(t)
+	eswitch := useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	useFakeBondLinks(t)
+	manager := NewSRIOVManager(&SRIOVConfig{})
+
+	bond := &BondConfig{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"}}
+	if err := manager.checkVFLagSlaves(bond); err != nil {
+		t.Errorf("expected ports of one NIC to be accepted, got %v", err)
+	}
+
+	mixed := &BondConfig{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens61f0np0"}}
+	if err := manager.checkVFLagSlaves(mixed); err == nil || !strings.Contains(err.Error(), "same NIC") {
+		t.Errorf("expected ports of different NICs to be refused, got %v", err)
+	}
+
+	missing := &BondConfig{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "eth9"}}
+	if err := manager.checkVFLagSlaves(missing); err == nil {
+		t.Error("expected a missing slave to be refused")
+	}
+
+	eswitch.states["0000:31:00.1"] = EswitchState{Mode: EswitchModeLegacy}
+	if _, err := manager.ensureBond(bond); err == nil || !strings.Contains(err.Error(), "not switchdev") {
+		t.Errorf("expected legacy ports to be refused, got %v", err)
+	}
+	manager.Config().DryRun = true
+	if err := manager.checkVFLagSlaves(bond); err != nil {
+		t.Errorf("expected the eswitch check to be skipped in dry-run mode, got %v", err)
+	}
+}
+
+// TestMlx5VerifyLAG tests reading the hardware LAG state from mlx5 debugfs
+func TestMlx5VerifyLAG(t *testing.T) {
+	original := lagSettleTimeout
+	lagSettleTimeout = 0
+	defer func() { lagSettleTimeout = original }()
+
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	plugin := &mlx5Plugin{}
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0"}
+	bond := &BondConfig{BondName: "bond0"}
+
+	// Without debugfs the LAG cannot be verified
+	if err := plugin.VerifyLAG(device, bond); err == nil || !strings.Contains(err.Error(), "skip_lag_verification") {
+		t.Errorf("expected an error without debugfs, got %v", err)
+	}
+
+	lagDir := filepath.Dir(mlx5LagPath(device.PCIAddress, "state"))
+	writeSysfsFiles(t, lagDir, map[string]string{"state": "disabled\n"})
+	if err := plugin.VerifyLAG(device, bond); err == nil || !strings.Contains(err.Error(), "hardware LAG did not engage") {
+		t.Errorf("expected an inactive LAG to fail, got %v", err)
+	}
+
+	writeSysfsFiles(t, lagDir, map[string]string{"state": "active\n", "type": "switchdev\n"})
+	if err := plugin.VerifyLAG(device, bond); err != nil {
+		t.Errorf("expected an active LAG to pass, got %v", err)
+	}
+}
+
+// TestValidateBondConfig tests validation of bond names, modes and LACP
+// parameters
+func TestValidateBondConfig(t *testing.T) {
+	slaves := []string{"ens60f0np0", "ens60f1np1"}
+	testCases := []struct {
+		name  string
+		bonds []BondConfig
+		valid bool
+	}{
+		{"lacp", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "802.3ad", LACPRate: "fast", XmitHashPolicy: "layer3+4"}}, true},
+		{"active-backup", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "active-backup", MIIMonitor: 100}}, true},
+		{"missing name", []BondConfig{{SlaveInterfaces: slaves}}, false},
+		{"long name", []BondConfig{{BondName: "bond-with-a-long-name", SlaveInterfaces: slaves}}, false},
+		{"no slaves", []BondConfig{{BondName: "bond0"}}, false},
+		{"invalid mode", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "lacp"}}, false},
+		{"lacp_rate without 802.3ad", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "active-backup", LACPRate: "fast"}}, false},
+		{"invalid lacp_rate", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "802.3ad", LACPRate: "medium"}}, false},
+		{"invalid xmit_hash_policy", []BondConfig{{BondName: "bond0", SlaveInterfaces: slaves, Mode: "802.3ad", XmitHashPolicy: "layer4"}}, false},
+		{"shared slave", []BondConfig{
+			{BondName: "bond0", SlaveInterfaces: slaves},
+			{BondName: "bond1", SlaveInterfaces: []string{"ens60f1np1"}},
+		}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policies := []DevicePolicy{{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeVFLag}}
+			err := (&SRIOVConfig{DevicePolicies: policies, BondConfigs: tc.bonds}).ValidateConfig()
+			if tc.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
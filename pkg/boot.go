@@ -0,0 +1,252 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultBootStatePath is where the last applied configuration is kept for
+// the next boot
+const DefaultBootStatePath = "/var/lib/sriov-manager/boot-state.json"
+
+// bootPollInterval is how often boot checks for PFs that have not appeared yet
+var bootPollInterval = 500 * time.Millisecond
+
+// BootState records the last configuration that was applied without errors
+// and the PFs it configured, so the next boot knows which PFs to wait for
+type BootState struct {
+	AppliedAt time.Time    `json:"applied_at"`
+	Config    *SRIOVConfig `json:"config"`
+	PFs       []BootPF     `json:"pfs"`
+}
+
+// BootPF is a PF a policy was applied to
+type BootPF struct {
+	Name       string `json:"name"`
+	PCIAddress string `json:"pci_address"`
+	NumVFs     int    `json:"num_vfs"`
+}
+
+// LoadBootState reads the boot state file. A missing file, e.g. on the
+// first boot, yields an empty state.
+func LoadBootState(path string) (*BootState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &BootState{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boot state: %v", err)
+	}
+
+	// The configuration may have been saved by an older version, so it is
+	// migrated like a config file
+	var raw struct {
+		BootState
+		Config json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse boot state: %v", err)
+	}
+	state := raw.BootState
+	if len(raw.Config) > 0 && string(raw.Config) != "null" {
+		config, report, err := decodeConfig(raw.Config, ConfigFormatJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse boot state configuration: %v", err)
+		}
+		logConfigMigration(path, report)
+		state.Config = config
+	}
+	return &state, nil
+}
+
+// SaveBootState records the current configuration and the PFs it was
+// applied to. Nothing is saved while any device failed to reconcile, so the
+// file always describes a configuration that applied cleanly.
+func (m *SRIOVManager) SaveBootState(path string, statuses []DeviceReconcileStatus) error {
+	if path == "" || m.Config().DryRun {
+		return nil
+	}
+
+	state := BootState{AppliedAt: time.Now(), Config: m.Config()}
+	for _, status := range statuses {
+		switch status.State {
+		case ReconcileFailed, ReconcileDrifted:
+			return fmt.Errorf("not saving boot state, %s is %s", status.Device, status.State)
+		case ReconcileSkipped:
+			continue
+		}
+		state.PFs = append(state.PFs, BootPF{Name: status.Device, PCIAddress: status.PCIAddress, NumVFs: status.DesiredVFs})
+	}
+	sort.Slice(state.PFs, func(i, j int) bool {
+		return state.PFs[i].PCIAddress < state.PFs[j].PCIAddress
+	})
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal boot state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create boot state directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write boot state: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace boot state: %v", err)
+	}
+	return nil
+}
+
+// Boot applies the configuration once during system boot. It waits for the
+// PFs recorded in the boot state or matched by a policy and for the
+// configured bond slaves to appear, reconciles, and returns once the VFs of
+// every configured PF exist. All waits share timeout, so boot never takes
+// much longer than it. PFs that do not appear in time are logged and left
+// out, so a removed NIC does not hold up the boot.
+func (m *SRIOVManager) Boot(state *BootState, timeout time.Duration) ([]DeviceReconcileStatus, error) {
+	deadline := time.Now().Add(timeout)
+	pfs := append([]BootPF(nil), state.PFs...)
+	known := make(map[string]bool)
+	for _, pf := range pfs {
+		known[pf.PCIAddress] = true
+	}
+	for _, pf := range m.Config().policyPFs() {
+		if !known[pf.PCIAddress] {
+			pfs = append(pfs, pf)
+		}
+	}
+	var netdevs []string
+	for _, bond := range m.Config().BondConfigs {
+		netdevs = append(netdevs, bond.SlaveInterfaces...)
+	}
+	if missing := waitForPFs(pfs, netdevs, deadline); len(missing) > 0 {
+		WithFields(map[string]interface{}{
+			"missing": strings.Join(missing, ", "),
+			"timeout": timeout.String(),
+		}).Warn("PFs did not appear, configuring the PFs that are present")
+	}
+
+	statuses, err := m.Reconcile()
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []string
+	for _, status := range statuses {
+		if status.State == ReconcileFailed || status.State == ReconcileDrifted {
+			failed = append(failed, fmt.Sprintf("%s: %s", status.Device, status.Message))
+		}
+	}
+	if len(failed) > 0 {
+		return statuses, fmt.Errorf("failed to configure %s", strings.Join(failed, "; "))
+	}
+
+	if !m.Config().DryRun {
+		if err := waitForBootVFs(statuses, deadline); err != nil {
+			return statuses, err
+		}
+	}
+	return statuses, nil
+}
+
+// policyPFs returns the network PCI functions in sysfs that a device policy
+// matches, so boot also waits for PFs that were never configured before.
+// Interface names and drivers are only known once the PF's driver probes,
+// so selectors on them are not applied.
+func (c *SRIOVConfig) policyPFs() []BootPF {
+	entries, err := os.ReadDir(PciDevicesPath())
+	if err != nil {
+		return nil
+	}
+	var pfs []BootPF
+	for _, entry := range entries {
+		if !isPciAddress(entry.Name()) {
+			continue
+		}
+		devicePath := PciDevicePath(entry.Name())
+		class := strings.TrimPrefix(readSysfsAttr(devicePath, "class"), "0x")
+		if !strings.HasPrefix(class, "02") {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(devicePath, "physfn")); err == nil {
+			continue
+		}
+		device := readPciFunction(entry.Name(), devicePath)
+		for i := range c.DevicePolicies {
+			if c.DevicePolicies[i].matchesBeforeProbe(device) {
+				pfs = append(pfs, BootPF{PCIAddress: device.PCIAddress})
+				break
+			}
+		}
+	}
+	return pfs
+}
+
+// matchesBeforeProbe reports whether the policy can match a PCI function
+// whose driver has not probed yet
+func (p *DevicePolicy) matchesBeforeProbe(device Device) bool {
+	if !p.MatchesIDs(device.VendorID, device.DeviceID, device.SubVendorID, device.SubDeviceID) {
+		return false
+	}
+	if p.Selector == nil {
+		return true
+	}
+	selector := *p.Selector
+	selector.InterfaceNames, selector.Drivers = nil, nil
+	return selector.Matches(device)
+}
+
+// waitForPFs waits until every PF has a network interface and every named
+// interface exists, and returns the ones still missing at the deadline
+func waitForPFs(pfs []BootPF, netdevs []string, deadline time.Time) []string {
+	for {
+		var missing []string
+		for _, pf := range pfs {
+			// The PF's netdev is registered once its driver has probed
+			entries, err := os.ReadDir(filepath.Join(PciDevicePath(pf.PCIAddress), "net"))
+			if err == nil && len(entries) > 0 {
+				continue
+			}
+			if pf.Name == "" {
+				missing = append(missing, pf.PCIAddress)
+			} else {
+				missing = append(missing, fmt.Sprintf("%s (%s)", pf.Name, pf.PCIAddress))
+			}
+		}
+		for _, name := range netdevs {
+			if _, err := os.Stat(filepath.Join(NetClassPath(), name)); err != nil {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) == 0 || !time.Now().Before(deadline) {
+			return missing
+		}
+		time.Sleep(bootPollInterval)
+	}
+}
+
+// waitForBootVFs waits for the VFs of every configured PF to exist and, with
+// driver autoprobe, to have a driver, so network units ordered after boot
+// find their interfaces. Every PF shares the same deadline.
+func waitForBootVFs(statuses []DeviceReconcileStatus, deadline time.Time) error {
+	for _, status := range statuses {
+		if status.State == ReconcileSkipped || status.DesiredVFs == 0 {
+			continue
+		}
+		device := Device{Name: status.Device, PCIAddress: status.PCIAddress}
+		timeout := time.Until(deadline)
+		if timeout < 0 {
+			timeout = 0
+		}
+		if err := waitForVFDrivers(device, status.DesiredVFs, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package pkg
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBootState tests that only cleanly applied configurations are saved
+func TestBootState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "boot-state.json")
+
+	state, err := LoadBootState(path)
+	if err != nil || state.Config != nil || len(state.PFs) != 0 {
+		t.Fatalf("expected an empty state without a file, got %+v %v", state, err)
+	}
+
+	config := &SRIOVConfig{Version: "1.0", DevicePolicies: []DevicePolicy{{VendorID: "15b3", DeviceID: "101e", NumVFs: 4}}}
+	manager := NewSRIOVManager(config)
+	statuses := []DeviceReconcileStatus{
+		{Device: "ens60f1np1", PCIAddress: "0000:31:00.1", State: ReconcileInSync, DesiredVFs: 4},
+		{Device: "ens60f0np0", PCIAddress: "0000:31:00.0", State: ReconcileApplied, DesiredVFs: 4},
+		{Device: "eno1", PCIAddress: "0000:02:00.0", State: ReconcileSkipped},
+	}
+	if err := manager.SaveBootState(path, statuses); err != nil {
+		t.Fatalf("SaveBootState failed: %v", err)
+	}
+
+	state, err = LoadBootState(path)
+	if err != nil {
+		t.Fatalf("LoadBootState failed: %v", err)
+	}
+	expected := []BootPF{
+		{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", NumVFs: 4},
+		{Name: "ens60f1np1", PCIAddress: "0000:31:00.1", NumVFs: 4},
+	}
+	if !reflect.DeepEqual(state.PFs, expected) || state.Config == nil || state.Config.DevicePolicies[0].NumVFs != 4 {
+		t.Errorf("unexpected boot state %+v", state)
+	}
+
+	// A failed pass leaves the last good state in place
+	statuses[0].State = ReconcileFailed
+	if err := manager.SaveBootState(path, statuses); err == nil {
+		t.Error("expected a failed device to prevent saving")
+	}
+	if state, _ := LoadBootState(path); len(state.PFs) != 2 {
+		t.Errorf("expected the previous state to be kept, got %+v", state)
+	}
+}
+
+// TestWaitForPFs tests waiting for PF netdevs and bond slaves to appear
+func TestWaitForPFs(t *testing.T) {
+	original := bootPollInterval
+	bootPollInterval = 10 * time.Millisecond
+	defer func() { bootPollInterval = original }()
+
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	pfs := []BootPF{{Name: "ens60f0np0", PCIAddress: "0000:31:00.0"}}
+	if err := os.MkdirAll(filepath.Join(PciDevicePath("0000:31:00.0"), "net"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The driver probes after the wait has started
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.MkdirAll(filepath.Join(PciDevicePath("0000:31:00.0"), "net", "ens60f0np0"), 0755)
+		os.MkdirAll(filepath.Join(NetClassPath(), "ens60f1np1"), 0755)
+	}()
+	if missing := waitForPFs(pfs, []string{"ens60f1np1"}, time.Now().Add(5*time.Second)); len(missing) != 0 {
+		t.Errorf("expected all PFs to appear, missing %v", missing)
+	}
+
+	missing := waitForPFs(append(pfs, BootPF{Name: "ens61f0np0", PCIAddress: "0000:4b:00.0"}), []string{"eth9"}, time.Now().Add(20*time.Millisecond))
+	if expected := []string{"ens61f0np0 (0000:4b:00.0)", "eth9"}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expected missing %v, got %v", expected, missing)
+	}
+}
+
+// TestPolicyPFs tests finding the PFs to wait for from the policies before
+// their drivers have probed
+func TestPolicyPFs(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	functions := map[string][2]string{
+		"0000:31:00.0": {"0x15b3", "0x020000"},
+		"0000:31:00.1": {"0x15b3", "0x020000"},
+		"0000:31:00.2": {"0x15b3", "0x020000"},
+		"0000:4b:00.0": {"0x8086", "0x020000"},
+		"0000:00:01.0": {"0x15b3", "0x060400"},
+	}
+	for address, ids := range functions {
+		writeSysfsFiles(t, PciDevicePath(address), map[string]string{
+			"vendor": ids[0] + "\n", "device": "0x101e\n", "class": ids[1] + "\n", "numa_node": "0\n",
+		})
+	}
+	symlink(t, filepath.Join("..", "0000:31:00.0"), filepath.Join(PciDevicePath("0000:31:00.2"), "physfn"))
+
+	config := &SRIOVConfig{DevicePolicies: []DevicePolicy{
+		{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Selector: &DeviceSelector{
+			InterfaceNames: []string{"ens60f*"}, Drivers: []string{"mlx5_core"}, NUMANodes: []int{0},
+		}},
+		{VendorID: "8086", DeviceID: "101e", NumVFs: 4, Selector: &DeviceSelector{NUMANodes: []int{1}}},
+	}}
+	expected := []BootPF{{PCIAddress: "0000:31:00.0"}, {PCIAddress: "0000:31:00.1"}}
+	if pfs := config.policyPFs(); !reflect.DeepEqual(pfs, expected) {
+		t.Errorf("expected %v, got %v", expected, pfs)
+	}
+}
+
+// TestWaitForBootVFs tests that boot waits for the VFs of configured PFs only
+func TestWaitForBootVFs(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	pfPath := PciDevicePath("0000:31:00.0")
+	writeSysfsFiles(t, pfPath, map[string]string{"sriov_numvfs": "2\n", "sriov_drivers_autoprobe": "0\n"})
+	symlink(t, filepath.Join("..", "0000:31:00.2"), filepath.Join(pfPath, "virtfn0"))
+	if err := os.MkdirAll(PciDevicePath("0000:31:00.2"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := []DeviceReconcileStatus{
+		{Device: "ens60f0np0", PCIAddress: "0000:31:00.0", State: ReconcileApplied, DesiredVFs: 2},
+		{Device: "eno1", PCIAddress: "0000:02:00.0", State: ReconcileSkipped, DesiredVFs: 8},
+	}
+	if err := waitForBootVFs(statuses, time.Now()); err == nil || !strings.Contains(err.Error(), "1 of 2 VFs") {
+		t.Errorf("expected a missing VF to fail, got %v", err)
+	}
+
+	symlink(t, filepath.Join("..", "0000:31:00.3"), filepath.Join(pfPath, "virtfn1"))
+	if err := os.MkdirAll(PciDevicePath("0000:31:00.3"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForBootVFs(statuses, time.Now()); err != nil {
+		t.Errorf("expected VFs to be ready, got %v", err)
+	}
+}
+
+// TestSdNotify tests sending readiness to a notify socket
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := SdNotify("READY=1"); sent || err != nil {
+		t.Errorf("expected no notification without a socket, got %v %v", sent, err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	if sent, err := SdNotify("READY=1\nSTATUS=2 devices configured"); !sent || err != nil {
+		t.Fatalf("expected the notification to be sent, got %v %v", sent, err)
+	}
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("failed to read notification: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1\nSTATUS=2 devices configured" {
+		t.Errorf("unexpected notification %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the file format of a configuration file
+type ConfigFormat string
+
+const (
+	ConfigFormatJSON ConfigFormat = "json"
+	ConfigFormatYAML ConfigFormat = "yaml"
+	ConfigFormatTOML ConfigFormat = "toml"
+)
+
+// ConfigFormatFor picks the format of a configuration file from its
+// extension: .yaml or .yml for YAML, .toml for TOML and JSON otherwise
+func ConfigFormatFor(configPath string) ConfigFormat {
+	switch strings.ToLower(filepath.Ext(configPath)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML
+	case ".toml":
+		return ConfigFormatTOML
+	default:
+		return ConfigFormatJSON
+	}
+}
+
+// decodeConfig parses a configuration in the given format, upgrading it to
+// CurrentConfigVersion and rejecting unknown fields. Every format is read
+// into a generic document for the migrations and then converted to JSON,
+// so all formats use the json field names and the same strict decoder.
+func decodeConfig(data []byte, format ConfigFormat) (*SRIOVConfig, *ConfigMigrationReport, error) {
+	var doc map[string]interface{}
+	var err error
+	switch format {
+	case ConfigFormatYAML:
+		err = yaml.Unmarshal(data, &doc)
+	case ConfigFormatTOML:
+		err = toml.Unmarshal(data, &doc)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(&doc); err == nil {
+			if _, err := decoder.Token(); err != io.EOF {
+				return nil, nil, fmt.Errorf("unexpected data after the configuration")
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("the configuration is empty")
+	}
+
+	report, err := migrateConfigDocument(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, nil, err
+	}
+
+	var config SRIOVConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, nil, fmt.Errorf("%s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	for i, policy := range config.DevicePolicies {
+		if policy.EnableSwitch {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("device policy %d: enable_switch is deprecated, use eswitch mode switchdev", i))
+		}
+	}
+	return &config, report, nil
+}
+
+// encodeConfig renders a configuration in the given format
+func encodeConfig(config *SRIOVConfig, format ConfigFormat) ([]byte, error) {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil || format == ConfigFormatJSON {
+		return data, err
+	}
+
+	// Round-trip through a generic document so YAML and TOML use the json
+	// field names and omit the same empty fields
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	normalizeNumbers(doc)
+
+	if format == ConfigFormatYAML {
+		return yaml.Marshal(doc)
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeNumbers replaces json.Number values with integers where possible,
+// so TOML does not write num_vfs = 4.0
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// configSchema is the JSON Schema of SRIOVConfig
+//
+//go:embed sriov_config.schema.json
+var configSchema []byte
+
+// ConfigSchema returns the JSON Schema of the configuration file, for
+// editors and CI checks of YAML, TOML and JSON configurations
+func ConfigSchema() []byte {
+	return append([]byte(nil), configSchema...)
+}
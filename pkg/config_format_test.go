@@ -0,0 +1,238 @@
+package pkg
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sampleConfigs is one configuration in every supported format
+var sampleConfigs = map[string]string{
+	"config.json": `{
+  "version": "1.0",
+  "device_policies": [
+    {"vendor_id": "15b3", "device_id": "101e", "num_vfs": 4, "mode": "vf-lag",
+     "selector": {"interface_names": ["ens60f*"], "numa_nodes": [1]},
+     "vf_template": {"vlan": 100, "trust": true},
+     "vf_overrides": [{"vf": 0, "mac": "02:00:00:00:00:10"}]}
+  ],
+  "bond_configs": [
+    {"bond_name": "bond0", "slave_interfaces": ["ens60f0np0", "ens60f1np1"], "mode": "802.3ad", "lacp_rate": "fast"}
+  ]
+}`,
+	"config.yaml": `
+version: "1.0"
+device_policies:
+  - vendor_id: "15b3"
+    device_id: "101e"
+    num_vfs: 4
+    mode: vf-lag
+    selector:
+      interface_names: ["ens60f*"]
+      numa_nodes: [1]
+    vf_template:
+      vlan: 100
+      trust: true
+    vf_overrides:
+      - vf: 0
+        mac: "02:00:00:00:00:10"
+bond_configs:
+  - bond_name: bond0
+    slave_interfaces: [ens60f0np0, ens60f1np1]
+    mode: 802.3ad
+    lacp_rate: fast
+`,
+	"config.toml": `
+version = "1.0"
+
+[[device_policies]]
+vendor_id = "15b3"
+device_id = "101e"
+num_vfs = 4
+mode = "vf-lag"
+selector = { interface_names = ["ens60f*"], numa_nodes = [1] }
+vf_template = { vlan = 100, trust = true }
+vf_overrides = [{ vf = 0, mac = "02:00:00:00:00:10" }]
+
+[[bond_configs]]
+bond_name = "bond0"
+slave_interfaces = ["ens60f0np0", "ens60f1np1"]
+mode = "802.3ad"
+lacp_rate = "fast"
+`,
+}
+
+// TestLoadConfigFormats tests that YAML and TOML load the same as JSON
+func TestLoadConfigFormats(t *testing.T) {
+	dir := t.TempDir()
+	loaded := make(map[string]*SRIOVConfig)
+	for name, content := range sampleConfigs {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: LoadConfig failed: %v", name, err)
+		}
+		if err := config.ValidateConfig(); err != nil {
+			t.Errorf("%s: expected a valid config, got %v", name, err)
+		}
+		loaded[name] = config
+	}
+
+	for _, name := range []string{"config.yaml", "config.toml"} {
+		if !reflect.DeepEqual(loaded[name], loaded["config.json"]) {
+			t.Errorf("%s loaded differently from JSON:\n%+v\n%+v", name, loaded[name], loaded["config.json"])
+		}
+	}
+	if template := loaded["config.toml"].DevicePolicies[0].VFTemplate; template == nil || *template.Vlan != 100 {
+		t.Errorf("expected vf_template vlan 100, got %+v", template)
+	}
+}
+
+// TestLoadConfigUnknownFields tests that misspelt fields are rejected in
+// every format
+func TestLoadConfigUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range sampleConfigs {
+		path := filepath.Join(dir, name)
+		content = strings.Replace(content, "lacp_rate", "lacp_rat", 1)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), `unknown field "lacp_rat"`) {
+			t.Errorf("%s: expected the unknown field to be rejected, got %v", name, err)
+		}
+	}
+
+	path := filepath.Join(dir, "trailing.json")
+	if err := os.WriteFile(path, []byte(`{"device_policies": []} {}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected trailing data to be rejected")
+	}
+}
+
+// TestSaveConfigFormats tests saving and reloading in every format
+func TestSaveConfigFormats(t *testing.T) {
+	dir := t.TempDir()
+	config := CreateDefaultConfig()
+	for _, name := range []string{"config.json", "config.yml", "config.toml"} {
+		path := filepath.Join(dir, name)
+		if err := SaveConfig(config, path); err != nil {
+			t.Fatalf("%s: SaveConfig failed: %v", name, err)
+		}
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("%s: LoadConfig failed: %v", name, err)
+		}
+		if !reflect.DeepEqual(loaded, config) {
+			t.Errorf("%s did not round-trip:\n%+v\n%+v", name, loaded, config)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.toml"))
+	if err != nil || !strings.Contains(string(data), "num_vfs = 4\n") {
+		t.Errorf("expected integer VF counts in TOML, got %s %v", data, err)
+	}
+}
+
+// TestConfigSchema tests that the JSON Schema describes exactly the fields
+// of the configuration types
+func TestConfigSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(ConfigSchema(), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	types := map[string]interface{}{
+		"DevicePolicy":   DevicePolicy{},
+		"DeviceSelector": DeviceSelector{},
+		"VFSettings":     VFSettings{},
+		"VFOverride":     VFOverride{},
+		"EswitchState":   EswitchState{},
+		"SFPolicy":       SFPolicy{},
+		"BondConfig":     BondConfig{},
+		"ResourcePool":   ResourcePool{},
+	}
+	check := func(name string, value interface{}, properties map[string]json.RawMessage) {
+		expected := jsonFieldNames(reflect.TypeOf(value))
+		var actual []string
+		for property := range properties {
+			actual = append(actual, property)
+		}
+		sort.Strings(actual)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("%s: schema has properties %v, type has fields %v", name, actual, expected)
+		}
+	}
+	check("SRIOVConfig", SRIOVConfig{}, schema.Properties)
+	for name, value := range types {
+		def, ok := schema.Defs[name]
+		if !ok {
+			t.Errorf("schema has no definition of %s", name)
+			continue
+		}
+		check(name, value, def.Properties)
+	}
+}
+
+// jsonFieldNames returns the sorted json names of a struct's fields,
+// including those of embedded structs
+func jsonFieldNames(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TestPatternsOverlap tests detecting glob patterns that match a common value
+func TestPatternsOverlap(t *testing.T) {
+	testCases := []struct {
+		a, b    string
+		overlap bool
+	}{
+		{"101e", "101E", true},
+		{"101e", "101f", false},
+		{"101*", "10?e", true},
+		{"*e", "101*", true},
+		{"*e", "*f", false},
+		{"ens60f*", "ens61f*", false},
+		{"ens6[01]f*", "ens61f0np0", true},
+		{"0000:31:00.[01]", "0000:31:00.[2-7]", false},
+		{"0000:31:00.[^0]", "0000:31:00.0", false},
+		{"a\\*", "a*", true},
+		{"a\\*", "ab", false},
+		{"*", "", true},
+		{"?", "", false},
+	}
+	for _, tc := range testCases {
+		if overlap := patternsOverlap(tc.a, tc.b); overlap != tc.overlap {
+			t.Errorf("patternsOverlap(%q, %q) = %v, expected %v", tc.a, tc.b, overlap, tc.overlap)
+		}
+		if overlap := patternsOverlap(tc.b, tc.a); overlap != tc.overlap {
+			t.Errorf("patternsOverlap(%q, %q) = %v, expected %v", tc.b, tc.a, overlap, tc.overlap)
+		}
+	}
+}
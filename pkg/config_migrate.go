@@ -0,0 +1,245 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CurrentConfigVersion is the configuration schema version written by
+// SaveConfig. Files with an older version are migrated when loaded.
+const CurrentConfigVersion = "2.0"
+
+// legacyConfigVersion is assumed for files without a version, which predate
+// versioned schemas
+const legacyConfigVersion = "1.0"
+
+// configMigrationFunc upgrades a generic configuration document by one
+// schema version in place and returns a deprecation warning for each
+// setting it rewrote
+type configMigrationFunc func(doc map[string]interface{}) ([]string, error)
+
+type configMigration struct {
+	to      string
+	migrate configMigrationFunc
+}
+
+// configMigrations maps each old schema version to the migration that
+// upgrades it to the next one
+var configMigrations = make(map[string]configMigration)
+
+func init() {
+	registerConfigMigration("1.0", "2.0", migrateConfigV1)
+}
+
+// registerConfigMigration adds the migration from one schema version to the
+// next. Migrations are chained until CurrentConfigVersion is reached.
+func registerConfigMigration(from, to string, migrate configMigrationFunc) {
+	if _, ok := configMigrations[from]; ok {
+		panic(fmt.Sprintf("config migration from version %s registered twice", from))
+	}
+	configMigrations[from] = configMigration{to: to, migrate: migrate}
+}
+
+// ConfigMigrationReport describes how a loaded configuration was upgraded
+type ConfigMigrationReport struct {
+	FromVersion string
+	ToVersion   string
+	// Warnings name each deprecated setting and what replaced it
+	Warnings []string
+	// BackupPath is where MigrateConfigFile kept the original file
+	BackupPath string
+}
+
+// Migrated reports whether the configuration was written for an older
+// schema version
+func (r *ConfigMigrationReport) Migrated() bool {
+	return r.FromVersion != r.ToVersion
+}
+
+// migrateConfigDocument upgrades a generic configuration document to
+// CurrentConfigVersion. Migrations work on the document rather than on
+// SRIOVConfig so they can handle fields the current types no longer have.
+func migrateConfigDocument(doc map[string]interface{}) (*ConfigMigrationReport, error) {
+	version := legacyConfigVersion
+	if value, ok := doc["version"]; ok {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("version must be a string such as %q, got %v", CurrentConfigVersion, value)
+		}
+		if s != "" {
+			version = s
+		}
+	}
+
+	report := &ConfigMigrationReport{FromVersion: version, ToVersion: version}
+	for version != CurrentConfigVersion {
+		migration, ok := configMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("unsupported config version %q, this build reads versions up to %s", version, CurrentConfigVersion)
+		}
+		warnings, err := migration.migrate(doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate config from version %s to %s: %v", version, migration.to, err)
+		}
+		for _, warning := range warnings {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("version %s: %s", version, warning))
+		}
+		version = migration.to
+		doc["version"] = version
+	}
+	report.ToVersion = version
+	return report, nil
+}
+
+// migrateConfigV1 upgrades a version 1.0 configuration:
+//   - enable_switch is replaced by eswitch.mode switchdev
+//   - policies that can match the same device at the same priority were
+//     chosen by file order; they are given distinct priorities that keep
+//     that order, since 2.0 rejects such ties
+func migrateConfigV1(doc map[string]interface{}) ([]string, error) {
+	items, _ := doc["device_policies"].([]interface{})
+	var warnings []string
+	for i, item := range items {
+		policy, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		warning, err := migrateEnableSwitch(policy)
+		if err != nil {
+			return nil, fmt.Errorf("device policy %d: %v", i, err)
+		}
+		if warning != "" {
+			warnings = append(warnings, fmt.Sprintf("device policy %d: %s", i, warning))
+		}
+	}
+
+	priorityWarnings, err := migratePolicyOrder(items)
+	if err != nil {
+		return nil, err
+	}
+	return append(warnings, priorityWarnings...), nil
+}
+
+// migrateEnableSwitch moves a policy's enable_switch into its eswitch mode
+func migrateEnableSwitch(policy map[string]interface{}) (string, error) {
+	value, ok := policy["enable_switch"]
+	if !ok {
+		return "", nil
+	}
+	enabled, ok := value.(bool)
+	if !ok {
+		return "", fmt.Errorf("enable_switch must be a boolean, got %v", value)
+	}
+	delete(policy, "enable_switch")
+	if !enabled {
+		return "enable_switch is deprecated and was removed", nil
+	}
+
+	eswitch, ok := policy["eswitch"].(map[string]interface{})
+	if !ok {
+		eswitch = make(map[string]interface{})
+		policy["eswitch"] = eswitch
+	}
+	switch mode, _ := eswitch["mode"].(string); mode {
+	case "":
+		eswitch["mode"] = string(EswitchModeSwitchdev)
+	case string(EswitchModeLegacy):
+		return "", fmt.Errorf("enable_switch conflicts with eswitch mode legacy")
+	}
+	return "enable_switch is deprecated, replaced by eswitch mode switchdev", nil
+}
+
+// migratePolicyOrder gives every policy a distinct priority when any two
+// policies could match the same device at the same priority. Scaling by the
+// number of policies keeps both the priority order and, within a priority,
+// the file order that used to break ties.
+func migratePolicyOrder(items []interface{}) ([]string, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var policies []DevicePolicy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("device_policies: %v", strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	tied := false
+	for i := range policies {
+		for j := i + 1; j < len(policies) && !tied; j++ {
+			tied = policies[i].Priority == policies[j].Priority && policies[i].overlaps(&policies[j])
+		}
+	}
+	if !tied {
+		return nil, nil
+	}
+
+	var warnings []string
+	n := len(policies)
+	for i, item := range items {
+		policy, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		priority := policies[i].Priority*n + n - 1 - i
+		if priority != policies[i].Priority {
+			warnings = append(warnings, fmt.Sprintf("device policy %d: priority %d is now %d so overlapping policies keep their file order", i, policies[i].Priority, priority))
+		}
+		policy["priority"] = priority
+	}
+	return warnings, nil
+}
+
+// logConfigMigration warns about each deprecated setting of a configuration
+// and whether it was upgraded from an older version
+func logConfigMigration(configPath string, report *ConfigMigrationReport) {
+	for _, warning := range report.Warnings {
+		WithField("config", configPath).Warn("Deprecated configuration: " + warning)
+	}
+	if !report.Migrated() {
+		return
+	}
+	WithFields(map[string]interface{}{
+		"config": configPath,
+		"from":   report.FromVersion,
+		"to":     report.ToVersion,
+	}).Warn("Configuration uses an old schema version and was upgraded in memory; run sriov-manager --migrate-config to rewrite it")
+}
+
+// MigrateConfigFile upgrades a configuration file to CurrentConfigVersion,
+// keeping the original next to it as <file>.<old version>.bak. The file is
+// left alone when it is already current or when the upgraded configuration
+// does not validate. With dryRun set nothing is written.
+func MigrateConfigFile(configPath string, dryRun bool) (*ConfigMigrationReport, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	config, report, err := decodeConfig(data, ConfigFormatFor(configPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if !report.Migrated() {
+		return report, nil
+	}
+	if err := config.ValidateConfig(); err != nil {
+		return report, fmt.Errorf("migrated configuration is invalid: %v", err)
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	backupPath := fmt.Sprintf("%s.%s.bak", configPath, report.FromVersion)
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return report, fmt.Errorf("failed to back up config file: %v", err)
+	}
+	report.BackupPath = backupPath
+	if err := SaveConfig(config, configPath); err != nil {
+		return report, err
+	}
+	return report, nil
+}
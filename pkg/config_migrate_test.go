@@ -0,0 +1,184 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// legacyConfig is a version 1.0 configuration with a deprecated
+// enable_switch and two overlapping policies that relied on file order
+const legacyConfig = `
+version: "1.0"
+device_policies:
+  - vendor_id: "15b3"
+    device_id: "101e"
+    num_vfs: 8
+    enable_switch: true
+    selector:
+      interface_names: ["ens60f0np0"]
+  - vendor_id: "15b3"
+    device_id: "*"
+    num_vfs: 2
+  - vendor_id: "8086"
+    device_id: "1592"
+    num_vfs: 4
+    priority: 5
+    enable_switch: false
+`
+
+// TestMigrateConfigV1 tests upgrading a version 1.0 configuration
+func TestMigrateConfigV1(t *testing.T) {
+	config, report, err := decodeConfig([]byte(legacyConfig), ConfigFormatYAML)
+	if err != nil {
+		t.Fatalf("decodeConfig failed: %v", err)
+	}
+	if !report.Migrated() || report.FromVersion != "1.0" || config.Version != CurrentConfigVersion {
+		t.Errorf("expected a migration from 1.0 to %s, got %+v (version %s)", CurrentConfigVersion, report, config.Version)
+	}
+	if err := config.ValidateConfig(); err != nil {
+		t.Errorf("expected the migrated config to be valid, got %v", err)
+	}
+
+	policy := config.DevicePolicies[0]
+	if policy.EnableSwitch || policy.Eswitch == nil || policy.Eswitch.Mode != EswitchModeSwitchdev {
+		t.Errorf("expected enable_switch to become eswitch mode switchdev, got %v %+v", policy.EnableSwitch, policy.Eswitch)
+	}
+	if config.DevicePolicies[2].EnableSwitch || config.DevicePolicies[2].Eswitch != nil {
+		t.Errorf("expected enable_switch false to be dropped, got %+v", config.DevicePolicies[2])
+	}
+
+	var priorities []int
+	for _, policy := range config.DevicePolicies {
+		priorities = append(priorities, policy.Priority)
+	}
+	if !reflect.DeepEqual(priorities, []int{2, 1, 15}) {
+		t.Errorf("expected priorities [2 1 15], got %v", priorities)
+	}
+	device := Device{Name: "ens60f0np0", VendorID: "15b3", DeviceID: "101e"}
+	if selected := config.GetDevicePolicyForDevice(device); selected == nil || selected.NumVFs != 8 {
+		t.Errorf("expected the first policy to still win, got %+v", selected)
+	}
+
+	if len(report.Warnings) != 5 {
+		t.Errorf("expected 5 warnings, got %q", report.Warnings)
+	}
+	for _, warning := range report.Warnings {
+		if !strings.HasPrefix(warning, "version 1.0: device policy ") {
+			t.Errorf("unexpected warning %q", warning)
+		}
+	}
+}
+
+// TestMigrateConfigErrors tests versions and settings that cannot be migrated
+func TestMigrateConfigErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"newer version", `{"version": "9.0", "device_policies": []}`, `unsupported config version "9.0"`},
+		{"numeric version", `{"version": 2, "device_policies": []}`, "version must be a string"},
+		{"conflicting eswitch", `{"version": "1.0", "device_policies": [
+			{"vendor_id": "15b3", "device_id": "101e", "num_vfs": 4, "enable_switch": true, "eswitch": {"mode": "legacy"}}]}`,
+			"device policy 0: enable_switch conflicts with eswitch mode legacy"},
+		{"empty", `null`, "the configuration is empty"},
+	}
+	for _, tc := range testCases {
+		if _, _, err := decodeConfig([]byte(tc.config), ConfigFormatJSON); err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", tc.name, tc.expected, err)
+		}
+	}
+
+	// enable_switch is still read from current files, with a warning
+	config, report, err := decodeConfig([]byte(`{"version": "2.0", "device_policies": [
+		{"vendor_id": "15b3", "device_id": "101e", "num_vfs": 4, "enable_switch": true}]}`), ConfigFormatJSON)
+	if err != nil || !config.DevicePolicies[0].EnableSwitch || report.Migrated() || len(report.Warnings) != 1 {
+		t.Errorf("expected a deprecation warning only, got %+v %v", report, err)
+	}
+}
+
+// TestMigrateConfigFile tests rewriting a configuration file in the latest
+// version
+func TestMigrateConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(legacyConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := MigrateConfigFile(path, true)
+	if err != nil || !report.Migrated() || report.BackupPath != "" {
+		t.Fatalf("expected a dry-run migration, got %+v %v", report, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != legacyConfig {
+		t.Error("expected a dry run to leave the file alone")
+	}
+
+	if report, err = MigrateConfigFile(path, false); err != nil || report.BackupPath != path+".1.0.bak" {
+		t.Fatalf("expected a migration with a backup, got %+v %v", report, err)
+	}
+	if data, _ := os.ReadFile(report.BackupPath); string(data) != legacyConfig {
+		t.Errorf("expected the backup to hold the original file, got %s", data)
+	}
+	config, report, err := decodeConfig(mustReadFile(t, path), ConfigFormatYAML)
+	if err != nil || report.Migrated() || len(report.Warnings) != 0 || config.Version != CurrentConfigVersion {
+		t.Errorf("expected a current config without warnings, got %+v %v", report, err)
+	}
+
+	if report, err = MigrateConfigFile(path, false); err != nil || report.Migrated() || report.BackupPath != "" {
+		t.Errorf("expected a current file to be left alone, got %+v %v", report, err)
+	}
+}
+
+// TestMigrateConfigFileInvalid tests that a migrated configuration that
+// fails validation is not written
+func TestMigrateConfigFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{"version": "1.0", "device_policies": [{"vendor_id": "15b3", "device_id": "101e", "num_vfs": 4}],
+		"bond_configs": [{"bond_name": "bond0", "slave_interfaces": ["ens60f0np0"]}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MigrateConfigFile(path, false); err == nil || !strings.Contains(err.Error(), "migrated configuration is invalid") {
+		t.Errorf("expected the invalid config to be refused, got %v", err)
+	}
+	if string(mustReadFile(t, path)) != content {
+		t.Error("expected the file to be left alone")
+	}
+	if _, err := os.Stat(path + ".1.0.bak"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup, got %v", err)
+	}
+}
+
+// TestLoadBootStateMigrates tests that a boot state saved with an older
+// configuration version is upgraded
+func TestLoadBootStateMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "boot-state.json")
+	content := `{"config": {"version": "1.0", "device_policies": [
+		{"vendor_id": "15b3", "device_id": "101e", "num_vfs": 4, "enable_switch": true}]},
+		"pfs": [{"name": "ens60f0np0", "pci_address": "0000:31:00.0", "num_vfs": 4}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state, err := LoadBootState(path)
+	if err != nil {
+		t.Fatalf("LoadBootState failed: %v", err)
+	}
+	if state.Config.Version != CurrentConfigVersion || state.Config.DevicePolicies[0].DesiredEswitch().Mode != EswitchModeSwitchdev {
+		t.Errorf("expected a migrated config, got %+v", state.Config)
+	}
+	if len(state.PFs) != 1 || state.PFs[0].NumVFs != 4 {
+		t.Errorf("expected the PFs to be kept, got %+v", state.PFs)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
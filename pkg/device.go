@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 )
 
@@ -26,6 +27,11 @@ type Device struct {
 	Driver     string
 	Vendor     string
 	Product    string
+	// PCI IDs from sysfs, lowercase hex without 0x prefix
+	VendorID    string
+	DeviceID    string
+	SubVendorID string
+	SubDeviceID string
 	// Enhanced fields for SR-IOV information
 	SRIOVCapable bool
 	SRIOVInfo    *SRIOVInfo
@@ -52,6 +58,16 @@ type Device struct {
 	// NUMA topology information
 	NUMANode     int
 	NUMADistance map[int]int // Distance to other NUMA nodes
+	// Virtual functions of a PF, ordered by VF index
+	VFs []VirtualFunction
+	// Parent PF address when this device is itself a VF
+	PhysFn string
+	// Devlink eswitch state of a PF, nil when the driver has no eswitch
+	Eswitch *EswitchState
+	// Ports of the PF's devlink instance, ordered by port index
+	DevlinkPorts []DevlinkPort
+	// Scalable functions of a PF, ordered by sfnum
+	SFs []SubFunction
 }
 
 // GetDetailedCapabilities returns formatted detailed capability information
@@ -118,6 +134,21 @@ func (d *Device) GetNUMATopologyInfo() string {
 	return strings.Join(info, " | ")
 }
 
+// GetVF returns the VF with the given index, or nil if it does not exist
+func (d *Device) GetVF(index int) *VirtualFunction {
+	for i := range d.VFs {
+		if d.VFs[i].Index == index {
+			return &d.VFs[i]
+		}
+	}
+	return nil
+}
+
+// IsVF returns true if the device is a virtual function
+func (d *Device) IsVF() bool {
+	return d.PhysFn != ""
+}
+
 // ParseLshwFromFile parses a lshw -class network -json output file
 func ParseLshwFromFile(path string) ([]Device, error) {
 	f, err := os.Open(path)
@@ -177,6 +208,7 @@ func parseLshwData(raw []map[string]any) ([]Device, error) {
 			for capName := range caps {
 				capabilities = append(capabilities, capName)
 			}
+			sort.Strings(capabilities)
 		}
 
 		devices = append(devices, Device{
@@ -224,6 +256,11 @@ func AttachPciInfo(devices []Device) ([]Device, error) {
 			if dev.Product == "" {
 				dev.Product = p.DeviceName
 			}
+			// Add PCI IDs for policy matching
+			dev.VendorID = p.VendorID
+			dev.DeviceID = p.DeviceID
+			dev.SubVendorID = p.SubVendorID
+			dev.SubDeviceID = p.SubDeviceID
 			// Add enhanced SR-IOV information
 			dev.SRIOVCapable = p.SRIOVCapable
 			dev.SRIOVInfo = p.SRIOVInfo
@@ -232,6 +269,9 @@ func AttachPciInfo(devices []Device) ([]Device, error) {
 			// Add NUMA topology information
 			dev.NUMANode = p.NUMANode
 			dev.NUMADistance = p.NUMADistance
+			// Add VF inventory and PF linkage
+			dev.VFs = p.VFs
+			dev.PhysFn = p.PhysFn
 			devices[i] = dev
 		}
 	}
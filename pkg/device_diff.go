@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"reflect"
+	"sort"
+)
+
+// DeviceChangeType is the kind of change between two device snapshots
+type DeviceChangeType string
+
+const (
+	DeviceAdded    DeviceChangeType = "added"
+	DeviceRemoved  DeviceChangeType = "removed"
+	DeviceModified DeviceChangeType = "modified"
+)
+
+// DeviceChange describes one device that differs between two snapshots.
+// Device is the new state, or the last known state for removals.
+type DeviceChange struct {
+	Type          DeviceChangeType
+	Device        Device
+	ChangedFields []string
+}
+
+// DeviceKey identifies a device across snapshots. Representors share their
+// PF's PCI address, so the interface name is part of the key.
+func DeviceKey(device Device) string {
+	return device.PCIAddress + "/" + device.Name
+}
+
+// DiffDevices returns the devices added, removed or modified between two
+// snapshots, ordered by device key
+func DiffDevices(previous, current []Device) []DeviceChange {
+	before := make(map[string]Device, len(previous))
+	for _, device := range previous {
+		before[DeviceKey(device)] = device
+	}
+	after := make(map[string]Device, len(current))
+	for _, device := range current {
+		after[DeviceKey(device)] = device
+	}
+
+	var changes []DeviceChange
+	for key, device := range after {
+		old, ok := before[key]
+		if !ok {
+			changes = append(changes, DeviceChange{Type: DeviceAdded, Device: device})
+			continue
+		}
+		if fields := changedDeviceFields(old, device); len(fields) > 0 {
+			changes = append(changes, DeviceChange{Type: DeviceModified, Device: device, ChangedFields: fields})
+		}
+	}
+	for key, device := range before {
+		if _, ok := after[key]; !ok {
+			changes = append(changes, DeviceChange{Type: DeviceRemoved, Device: device})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return DeviceKey(changes[i].Device) < DeviceKey(changes[j].Device)
+	})
+	return changes
+}
+
+// changedDeviceFields returns the names of the Device fields that differ
+func changedDeviceFields(a, b Device) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, va.Type().Field(i).Name)
+		}
+	}
+	return fields
+}
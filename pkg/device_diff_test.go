@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiffDevices tests detection of added, removed and modified devices
+func TestDiffDevices(t *testing.T) {
+	pf := Device{PCIAddress: "0000:31:00.0", Name: "ens60f0np0", Driver: "mlx5_core", SRIOVInfo: &SRIOVInfo{NumberOfVFs: 0}}
+	rep := Device{PCIAddress: "0000:31:00.0", Name: "ens60f0npf0vf0", Driver: "mlx5e_rep"}
+	vf := Device{PCIAddress: "0000:31:00.2", Name: "ens60f0v0", Driver: "mlx5_core", PhysFn: "0000:31:00.0"}
+
+	previous := []Device{pf, rep, vf}
+
+	resized := pf
+	resized.SRIOVInfo = &SRIOVInfo{NumberOfVFs: 4}
+	rebound := vf
+	rebound.Name = ""
+	rebound.Driver = "vfio-pci"
+	current := []Device{resized, rep, rebound}
+
+	changes := DiffDevices(previous, current)
+	expected := []DeviceChange{
+		{Type: DeviceRemoved, Device: vf},
+		{Type: DeviceAdded, Device: rebound},
+		{Type: DeviceModified, Device: resized, ChangedFields: []string{"SRIOVInfo"}},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+
+	byKey := make(map[string]DeviceChange)
+	for _, c := range changes {
+		byKey[string(c.Type)+" "+DeviceKey(c.Device)] = c
+	}
+	for _, want := range expected {
+		got, ok := byKey[string(want.Type)+" "+DeviceKey(want.Device)]
+		if !ok {
+			t.Errorf("missing %s change for %s", want.Type, DeviceKey(want.Device))
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %+v, got %+v", want, got)
+		}
+	}
+
+	if changes := DiffDevices(current, current); len(changes) != 0 {
+		t.Errorf("expected no changes between identical snapshots, got %+v", changes)
+	}
+}
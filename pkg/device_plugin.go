@@ -0,0 +1,408 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pluginapi "example.com/sriov-plugin/proto/deviceplugin/v1beta1"
+)
+
+// kubeletRegisterTimeout bounds the Register call to the kubelet
+var kubeletRegisterTimeout = 10 * time.Second
+
+// kubeletRegisterRetry is the first delay before retrying a failed
+// registration; it doubles up to kubeletRegisterMaxRetry
+var (
+	kubeletRegisterRetry    = time.Second
+	kubeletRegisterMaxRetry = 30 * time.Second
+)
+
+// nonAlphanumeric matches runs of characters not allowed in socket and env names
+var nonAlphanumeric = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// DevicePlugin serves the VFs of one ResourcePool to the kubelet over the
+// v1beta1 device plugin API
+type DevicePlugin struct {
+	pluginapi.UnimplementedDevicePluginServer
+
+	pool      ResourcePool
+	pluginDir string
+	server    *grpc.Server
+	// leases holds VFs leased before the pool was served, nil without one
+	leases *LeaseManager
+
+	mu       sync.Mutex
+	vfs      map[string]poolVF
+	devices  []*pluginapi.Device
+	watchers map[chan []*pluginapi.Device]struct{}
+}
+
+// NewDevicePlugin creates a device plugin for a pool. pluginDir is the kubelet
+// device plugin directory holding kubelet.sock.
+func NewDevicePlugin(pool ResourcePool, pluginDir string) *DevicePlugin {
+	if pool.DeviceType == "" {
+		pool.DeviceType = PoolDeviceNetdevice
+	}
+	return &DevicePlugin{
+		pool:      pool,
+		pluginDir: pluginDir,
+		vfs:       make(map[string]poolVF),
+		watchers:  make(map[chan []*pluginapi.Device]struct{}),
+	}
+}
+
+// SetLeaseManager makes the plugin the only allocator of its pool: leases
+// are refused on the pool, and VFs still held by leases taken before it was
+// served are advertised as unhealthy until they are released
+func (p *DevicePlugin) SetLeaseManager(leases *LeaseManager) {
+	leases.ServeToKubelet(p.pool.ResourceName)
+	p.mu.Lock()
+	p.leases = leases
+	p.mu.Unlock()
+}
+
+// leased reports whether a VF is held by a lease
+func (p *DevicePlugin) leased(address string) bool {
+	return p.leases != nil && p.leases.Leased(address)
+}
+
+// ResourceName returns the extended resource name served by the plugin
+func (p *DevicePlugin) ResourceName() string {
+	return p.pool.ResourceName
+}
+
+// SocketName returns the plugin socket name, relative to the plugin directory
+func (p *DevicePlugin) SocketName() string {
+	return "sriov-" + nonAlphanumeric.ReplaceAllString(p.pool.ResourceName, "_") + ".sock"
+}
+
+// EnvName returns the variable that carries allocated VF addresses into containers
+func (p *DevicePlugin) EnvName() string {
+	return "PCIDEVICE_" + strings.ToUpper(nonAlphanumeric.ReplaceAllString(p.pool.ResourceName, "_"))
+}
+
+// Start listens on the plugin socket and registers the pool with the kubelet
+func (p *DevicePlugin) Start() error {
+	socket := filepath.Join(p.pluginDir, p.SocketName())
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket %s: %v", socket, err)
+	}
+
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", socket, err)
+	}
+	server := grpc.NewServer()
+	pluginapi.RegisterDevicePluginServer(server, p)
+	p.server = server
+	go func() {
+		// Serve returns nil once the server is stopped
+		if err := server.Serve(lis); err != nil {
+			WithField("resource", p.pool.ResourceName).WithError(err).Error("Device plugin gRPC server failed")
+		}
+	}()
+
+	if err := p.register(); err != nil {
+		p.Stop()
+		return err
+	}
+
+	WithFields(map[string]interface{}{
+		"resource": p.pool.ResourceName,
+		"socket":   socket,
+	}).Info("Registered device plugin with kubelet")
+	return nil
+}
+
+// Stop stops serving and removes the plugin socket
+func (p *DevicePlugin) Stop() {
+	if p.server == nil {
+		return
+	}
+	p.server.Stop()
+	p.server = nil
+	os.Remove(filepath.Join(p.pluginDir, p.SocketName()))
+}
+
+// Serve starts the plugin and re-registers whenever the kubelet restarts,
+// which it signals by recreating kubelet.sock. Failed registrations are
+// retried with backoff. It returns when ctx is done.
+func (p *DevicePlugin) Serve(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %v", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(p.pluginDir); err != nil {
+		return fmt.Errorf("failed to watch %s: %v", p.pluginDir, err)
+	}
+
+	defer p.Stop()
+	p.startWithRetry(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("watcher events channel closed")
+			}
+			if filepath.Base(event.Name) != pluginapi.KubeletSocket || !event.Has(fsnotify.Create) {
+				continue
+			}
+			WithField("resource", p.pool.ResourceName).Info("Kubelet restarted, re-registering device plugin")
+			p.Stop()
+			p.startWithRetry(ctx)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("watcher errors channel closed")
+			}
+			WithError(err).Warn("Device plugin directory watcher error")
+		}
+	}
+}
+
+// startWithRetry starts the plugin, retrying with exponential backoff until
+// it is registered or ctx is done
+func (p *DevicePlugin) startWithRetry(ctx context.Context) {
+	delay := kubeletRegisterRetry
+	for {
+		err := p.Start()
+		if err == nil {
+			return
+		}
+		WithFields(map[string]interface{}{
+			"resource": p.pool.ResourceName,
+			"retry":    delay.String(),
+		}).WithError(err).Warn("Failed to start device plugin")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if delay > kubeletRegisterMaxRetry {
+			delay = kubeletRegisterMaxRetry
+		}
+	}
+}
+
+// register announces the plugin socket and resource name to the kubelet
+func (p *DevicePlugin) register() error {
+	kubeletSocket := filepath.Join(p.pluginDir, pluginapi.KubeletSocket)
+	conn, err := grpc.NewClient("unix://"+kubeletSocket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to connect to kubelet at %s: %v", kubeletSocket, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubeletRegisterTimeout)
+	defer cancel()
+
+	_, err = pluginapi.NewRegistrationClient(conn).Register(ctx, &pluginapi.RegisterRequest{
+		Version:      pluginapi.Version,
+		Endpoint:     p.SocketName(),
+		ResourceName: p.pool.ResourceName,
+		Options:      &pluginapi.DevicePluginOptions{GetPreferredAllocationAvailable: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register %s with kubelet: %v", p.pool.ResourceName, err)
+	}
+	return nil
+}
+
+// Update recomputes the pool from a discovered device inventory and notifies
+// ListAndWatch streams if the advertised devices changed. Leased VFs are
+// advertised as unhealthy so the kubelet does not hand them out.
+func (p *DevicePlugin) Update(devices []Device) {
+	vfs := selectPoolVFs(p.pool, devices)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	advertised := make([]*pluginapi.Device, 0, len(vfs))
+	for _, vf := range vfs {
+		device := &pluginapi.Device{ID: vf.PCIAddress, Health: pluginapi.Unhealthy}
+		if poolVFHealthy(p.pool, vf) && !p.leased(vf.PCIAddress) {
+			device.Health = pluginapi.Healthy
+		}
+		if vf.NUMANode >= 0 {
+			device.Topology = &pluginapi.TopologyInfo{
+				Nodes: []*pluginapi.NUMANode{{ID: int64(vf.NUMANode)}},
+			}
+		}
+		advertised = append(advertised, device)
+	}
+	sort.Slice(advertised, func(i, j int) bool {
+		return advertised[i].ID < advertised[j].ID
+	})
+
+	p.vfs = vfs
+	if advertisedEqual(p.devices, advertised) {
+		return
+	}
+	p.devices = advertised
+	for watcher := range p.watchers {
+		// Only the latest list matters, so replace any unread one
+		select {
+		case <-watcher:
+		default:
+		}
+		watcher <- advertised
+	}
+}
+
+// advertisedEqual compares two advertised device lists
+func advertisedEqual(a, b []*pluginapi.Device) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Health != b[i].Health || a[i].GetTopology().String() != b[i].GetTopology().String() {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDevicePluginOptions implements the v1beta1 DevicePlugin service
+func (p *DevicePlugin) GetDevicePluginOptions(ctx context.Context, in *pluginapi.Empty) (*pluginapi.DevicePluginOptions, error) {
+	return &pluginapi.DevicePluginOptions{GetPreferredAllocationAvailable: true}, nil
+}
+
+// ListAndWatch implements the v1beta1 DevicePlugin service, sending the full
+// device list on connect and again whenever it changes
+func (p *DevicePlugin) ListAndWatch(in *pluginapi.Empty, stream pluginapi.DevicePlugin_ListAndWatchServer) error {
+	updates := make(chan []*pluginapi.Device, 1)
+	p.mu.Lock()
+	updates <- p.devices
+	p.watchers[updates] = struct{}{}
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.watchers, updates)
+		p.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case devices := <-updates:
+			if err := stream.Send(&pluginapi.ListAndWatchResponse{Devices: devices}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetPreferredAllocation implements the v1beta1 DevicePlugin service. It keeps
+// required devices and fills up with VFs on the NUMA node holding the most
+// available VFs, so allocations stay NUMA local where possible.
+func (p *DevicePlugin) GetPreferredAllocation(ctx context.Context, in *pluginapi.PreferredAllocationRequest) (*pluginapi.PreferredAllocationResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := &pluginapi.PreferredAllocationResponse{}
+	for _, req := range in.ContainerRequests {
+		chosen := append([]string(nil), req.MustIncludeDeviceIDs...)
+		taken := make(map[string]bool)
+		for _, id := range chosen {
+			taken[id] = true
+		}
+
+		perNode := make(map[int]int)
+		for _, id := range req.AvailableDeviceIDs {
+			perNode[p.vfs[id].NUMANode]++
+		}
+		available := append([]string(nil), req.AvailableDeviceIDs...)
+		sort.SliceStable(available, func(i, j int) bool {
+			ni, nj := p.vfs[available[i]].NUMANode, p.vfs[available[j]].NUMANode
+			if perNode[ni] != perNode[nj] {
+				return perNode[ni] > perNode[nj]
+			}
+			if ni != nj {
+				return ni < nj
+			}
+			return available[i] < available[j]
+		})
+
+		for _, id := range available {
+			if len(chosen) >= int(req.AllocationSize) {
+				break
+			}
+			if !taken[id] {
+				chosen = append(chosen, id)
+				taken[id] = true
+			}
+		}
+		resp.ContainerResponses = append(resp.ContainerResponses, &pluginapi.ContainerPreferredAllocationResponse{DeviceIDs: chosen})
+	}
+	return resp, nil
+}
+
+// Allocate implements the v1beta1 DevicePlugin service. Containers get the
+// allocated VF addresses in PCIDEVICE_<RESOURCE>, and vfio pools also get
+// /dev/vfio/vfio and the VFs' IOMMU group devices.
+func (p *DevicePlugin) Allocate(ctx context.Context, in *pluginapi.AllocateRequest) (*pluginapi.AllocateResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp := &pluginapi.AllocateResponse{}
+	for _, req := range in.ContainerRequests {
+		container := &pluginapi.ContainerAllocateResponse{Envs: make(map[string]string)}
+		var addresses []string
+		for _, id := range req.DevicesIds {
+			vf, ok := p.vfs[id]
+			if !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "device %s is not in resource pool %s", id, p.pool.ResourceName)
+			}
+			if p.leased(id) {
+				return nil, status.Errorf(codes.FailedPrecondition, "device %s is leased", id)
+			}
+			addresses = append(addresses, id)
+
+			if p.pool.DeviceType == PoolDeviceVFIO {
+				if vf.IOMMUGroup == "" {
+					return nil, status.Errorf(codes.FailedPrecondition, "device %s has no IOMMU group", id)
+				}
+				if len(container.Devices) == 0 {
+					container.Devices = append(container.Devices, vfioDeviceSpec("vfio"))
+				}
+				container.Devices = append(container.Devices, vfioDeviceSpec(vf.IOMMUGroup))
+			}
+		}
+		container.Envs[p.EnvName()] = strings.Join(addresses, ",")
+		resp.ContainerResponses = append(resp.ContainerResponses, container)
+	}
+	return resp, nil
+}
+
+// vfioDeviceSpec returns the device spec for an entry under /dev/vfio
+func vfioDeviceSpec(name string) *pluginapi.DeviceSpec {
+	path := filepath.Join("/dev/vfio", name)
+	return &pluginapi.DeviceSpec{ContainerPath: path, HostPath: path, Permissions: "rw"}
+}
+
+// PreStartContainer implements the v1beta1 DevicePlugin service; nothing is
+// needed before containers start
+func (p *DevicePlugin) PreStartContainer(ctx context.Context, in *pluginapi.PreStartContainerRequest) (*pluginapi.PreStartContainerResponse, error) {
+	return &pluginapi.PreStartContainerResponse{}, nil
+}
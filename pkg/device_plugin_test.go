@@ -0,0 +1,339 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pluginapi "example.com/sriov-plugin/proto/deviceplugin/v1beta1"
+)
+
+// fakeKubelet records device plugin registrations like the kubelet does
+type fakeKubelet struct {
+	pluginapi.UnimplementedRegistrationServer
+
+	mu       sync.Mutex
+	requests []*pluginapi.RegisterRequest
+}
+
+func (k *fakeKubelet) Register(ctx context.Context, req *pluginapi.RegisterRequest) (*pluginapi.Empty, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.requests = append(k.requests, req)
+	return &pluginapi.Empty{}, nil
+}
+
+// startFakeKubelet serves a fake registration service on dir/kubelet.sock
+func startFakeKubelet(t *testing.T, dir string) *fakeKubelet {
+	lis, err := net.Listen("unix", filepath.Join(dir, pluginapi.KubeletSocket))
+	if err != nil {
+		t.Fatalf("Failed to listen for fake kubelet: %v", err)
+	}
+	kubelet := &fakeKubelet{}
+	server := grpc.NewServer()
+	pluginapi.RegisterRegistrationServer(server, kubelet)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return kubelet
+}
+
+// dialPlugin connects to a plugin socket the way the kubelet does
+func dialPlugin(t *testing.T, socket string) pluginapi.DevicePluginClient {
+	conn, err := grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to dial plugin: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pluginapi.NewDevicePluginClient(conn)
+}
+
+// devicePluginInventory returns a PF with two netdevice VFs and one vfio VF on
+// NUMA node 1, its representor, and a PF with one VF and no NUMA affinity
+func devicePluginInventory() []Device {
+	pf0 := Device{
+		PCIAddress: "0000:31:00.0", Name: "ens60f0np0", Driver: "mlx5_core", NUMANode: 1,
+		VFs: []VirtualFunction{
+			{Index: 0, PCIAddress: "0000:31:00.2", Driver: "mlx5_core", NetDev: "ens60f0v0", IOMMUGroup: "30"},
+			{Index: 1, PCIAddress: "0000:31:00.3", Driver: "mlx5_core", NetDev: "ens60f0v1", IOMMUGroup: "31"},
+			{Index: 2, PCIAddress: "0000:31:00.4", Driver: "vfio-pci", IOMMUGroup: "32"},
+		},
+	}
+	rep := pf0
+	rep.Name = "ens60f0npf0vf0"
+	rep.Driver = "mlx5e_rep"
+	pf1 := Device{
+		PCIAddress: "0000:32:00.0", Name: "ens61f0np0", Driver: "mlx5_core", NUMANode: -1,
+		VFs: []VirtualFunction{
+			{Index: 0, PCIAddress: "0000:32:00.2", Driver: "mlx5_core", NetDev: "ens61f0v0", IOMMUGroup: "40"},
+		},
+	}
+	vf := Device{PCIAddress: "0000:31:00.2", Name: "ens60f0v0", Driver: "mlx5_core", NUMANode: 1, PhysFn: "0000:31:00.0"}
+	return []Device{pf0, rep, vf, pf1}
+}
+
+// TestDevicePluginRegisterAndServe tests registration, ListAndWatch and Allocate
+// against a fake kubelet
+func TestDevicePluginRegisterAndServe(t *testing.T) {
+	dir := t.TempDir()
+	kubelet := startFakeKubelet(t, dir)
+
+	plugin := NewDevicePlugin(ResourcePool{ResourceName: "example.com/cx7_vf"}, dir)
+	plugin.Update(devicePluginInventory())
+	if err := plugin.Start(); err != nil {
+		t.Fatalf("Failed to start plugin: %v", err)
+	}
+	defer plugin.Stop()
+
+	kubelet.mu.Lock()
+	requests := kubelet.requests
+	kubelet.mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 registration, got %d", len(requests))
+	}
+	req := requests[0]
+	if req.Version != pluginapi.Version || req.ResourceName != "example.com/cx7_vf" || req.Endpoint != "sriov-example_com_cx7_vf.sock" {
+		t.Errorf("Unexpected registration: %+v", req)
+	}
+
+	client := dialPlugin(t, filepath.Join(dir, req.Endpoint))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.ListAndWatch(ctx, &pluginapi.Empty{})
+	if err != nil {
+		t.Fatalf("ListAndWatch failed: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive device list: %v", err)
+	}
+
+	node1 := &pluginapi.TopologyInfo{Nodes: []*pluginapi.NUMANode{{ID: 1}}}
+	expected := []struct {
+		id       string
+		health   string
+		topology *pluginapi.TopologyInfo
+	}{
+		{"0000:31:00.2", pluginapi.Healthy, node1},
+		{"0000:31:00.3", pluginapi.Healthy, node1},
+		{"0000:31:00.4", pluginapi.Unhealthy, node1},
+		{"0000:32:00.2", pluginapi.Healthy, nil},
+	}
+	if len(resp.Devices) != len(expected) {
+		t.Fatalf("Expected %d devices, got %d: %v", len(expected), len(resp.Devices), resp.Devices)
+	}
+	for i, want := range expected {
+		got := resp.Devices[i]
+		if got.ID != want.id || got.Health != want.health || got.GetTopology().String() != want.topology.String() {
+			t.Errorf("Device %d: expected %s %s %v, got %s %s %v", i, want.id, want.health, want.topology, got.ID, got.Health, got.Topology)
+		}
+	}
+
+	// Rebinding a VF to vfio-pci makes it unusable as a netdevice
+	inventory := devicePluginInventory()
+	inventory[0].VFs[1].Driver = "vfio-pci"
+	inventory[0].VFs[1].NetDev = ""
+	plugin.Update(inventory)
+	resp, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive updated device list: %v", err)
+	}
+	if resp.Devices[1].ID != "0000:31:00.3" || resp.Devices[1].Health != pluginapi.Unhealthy {
+		t.Errorf("Expected 0000:31:00.3 to turn unhealthy, got %v", resp.Devices[1])
+	}
+
+	alloc, err := client.Allocate(ctx, &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{
+			{DevicesIds: []string{"0000:31:00.2", "0000:32:00.2"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	expectedEnvs := map[string]string{"PCIDEVICE_EXAMPLE_COM_CX7_VF": "0000:31:00.2,0000:32:00.2"}
+	if got := alloc.ContainerResponses[0].Envs; !reflect.DeepEqual(got, expectedEnvs) {
+		t.Errorf("Expected envs %v, got %v", expectedEnvs, got)
+	}
+	if len(alloc.ContainerResponses[0].Devices) != 0 {
+		t.Errorf("Expected no device specs for a netdevice pool, got %v", alloc.ContainerResponses[0].Devices)
+	}
+
+	_, err = client.Allocate(ctx, &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIds: []string{"0000:99:00.2"}}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unknown device, got %v", err)
+	}
+}
+
+// TestDevicePluginServeRetries tests that registration is retried while the
+// kubelet is unreachable, both at start and after a kubelet restart
+func TestDevicePluginServeRetries(t *testing.T) {
+	originalTimeout, originalRetry := kubeletRegisterTimeout, kubeletRegisterRetry
+	kubeletRegisterTimeout, kubeletRegisterRetry = 200*time.Millisecond, 10*time.Millisecond
+	defer func() { kubeletRegisterTimeout, kubeletRegisterRetry = originalTimeout, originalRetry }()
+
+	dir := t.TempDir()
+	plugin := NewDevicePlugin(ResourcePool{ResourceName: "example.com/cx7_vf"}, dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- plugin.Serve(ctx) }()
+
+	registered := func(kubelet *fakeKubelet) bool {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			kubelet.mu.Lock()
+			n := len(kubelet.requests)
+			kubelet.mu.Unlock()
+			if n > 0 {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	// The kubelet comes up after the plugin
+	time.Sleep(50 * time.Millisecond)
+	if !registered(startFakeKubelet(t, dir)) {
+		t.Fatal("Expected the plugin to register once the kubelet is up")
+	}
+
+	// The kubelet restarts, but is not serving yet when the plugin notices
+	socket := filepath.Join(dir, pluginapi.KubeletSocket)
+	if err := os.Remove(socket); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(socket, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if err := os.Remove(socket); err != nil {
+		t.Fatal(err)
+	}
+	if !registered(startFakeKubelet(t, dir)) {
+		t.Fatal("Expected the plugin to re-register after the kubelet restarted")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected Serve to stop cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Serve did not return after cancel")
+	}
+}
+
+// TestDevicePluginVFIOPool tests pool selection and vfio device specs
+func TestDevicePluginVFIOPool(t *testing.T) {
+	plugin := NewDevicePlugin(ResourcePool{
+		ResourceName: "example.com/cx7_vfio",
+		PFNames:      []string{"ens60f0np0"},
+		Selector:     &DeviceSelector{Drivers: []string{"vfio-pci"}},
+		DeviceType:   PoolDeviceVFIO,
+	}, t.TempDir())
+	plugin.Update(devicePluginInventory())
+
+	if len(plugin.devices) != 1 || plugin.devices[0].ID != "0000:31:00.4" || plugin.devices[0].Health != pluginapi.Healthy {
+		t.Fatalf("Expected only a healthy 0000:31:00.4, got %v", plugin.devices)
+	}
+
+	alloc, err := plugin.Allocate(context.Background(), &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIds: []string{"0000:31:00.4"}}},
+	})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	var paths []string
+	for _, spec := range alloc.ContainerResponses[0].Devices {
+		if spec.HostPath != spec.ContainerPath || spec.Permissions != "rw" {
+			t.Errorf("Unexpected device spec %v", spec)
+		}
+		paths = append(paths, spec.HostPath)
+	}
+	if expected := []string{"/dev/vfio/vfio", "/dev/vfio/32"}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected device specs %v, got %v", expected, paths)
+	}
+}
+
+// TestDevicePluginPreferredAllocation tests that preferred allocations stay on
+// the NUMA node with the most available VFs
+func TestDevicePluginPreferredAllocation(t *testing.T) {
+	plugin := NewDevicePlugin(ResourcePool{ResourceName: "example.com/cx7_vf"}, t.TempDir())
+	plugin.Update(devicePluginInventory())
+
+	resp, err := plugin.GetPreferredAllocation(context.Background(), &pluginapi.PreferredAllocationRequest{
+		ContainerRequests: []*pluginapi.ContainerPreferredAllocationRequest{{
+			AvailableDeviceIDs: []string{"0000:32:00.2", "0000:31:00.3", "0000:31:00.2"},
+			AllocationSize:     2,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("GetPreferredAllocation failed: %v", err)
+	}
+	expected := []string{"0000:31:00.2", "0000:31:00.3"}
+	if got := resp.ContainerResponses[0].DeviceIDs; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+// TestDevicePluginLeasedVFs tests that a pool served to the kubelet cannot be
+// leased and that VFs leased before it was served are not handed out
+func TestDevicePluginLeasedVFs(t *testing.T) {
+	pool := ResourcePool{ResourceName: "example.com/cx7_vf"}
+	leases, err := NewLeaseManager([]ResourcePool{pool}, "")
+	if err != nil {
+		t.Fatalf("Failed to create lease manager: %v", err)
+	}
+	leases.Update(devicePluginInventory())
+	lease, err := leases.Allocate(LeaseRequest{Pool: pool.ResourceName, Count: 1, NUMANode: -1})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	leased := lease.VFs[0]
+
+	plugin := NewDevicePlugin(pool, t.TempDir())
+	plugin.SetLeaseManager(leases)
+	plugin.Update(devicePluginInventory())
+	health := func() map[string]string {
+		health := make(map[string]string)
+		for _, device := range plugin.devices {
+			health[device.ID] = device.Health
+		}
+		return health
+	}
+	if got := health(); got[leased] != pluginapi.Unhealthy || got["0000:32:00.2"] != pluginapi.Healthy {
+		t.Errorf("Expected only the leased %s to be unhealthy, got %v", leased, got)
+	}
+
+	_, err = plugin.Allocate(context.Background(), &pluginapi.AllocateRequest{
+		ContainerRequests: []*pluginapi.ContainerAllocateRequest{{DevicesIds: []string{leased}}},
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition allocating a leased VF, got %v", err)
+	}
+	if _, err := leases.Allocate(LeaseRequest{Pool: pool.ResourceName, Count: 1, NUMANode: -1}); !errors.Is(err, ErrPoolServedToKubelet) {
+		t.Errorf("Expected ErrPoolServedToKubelet, got %v", err)
+	}
+
+	if err := leases.Release(lease.ID); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	plugin.Update(devicePluginInventory())
+	if got := health(); got[leased] != pluginapi.Healthy {
+		t.Errorf("Expected the released %s to be healthy, got %v", leased, got)
+	}
+}
@@ -0,0 +1,254 @@
+package pkg
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DeviceSelector narrows a DevicePolicy to specific devices. Every non-empty
+// field must match; within a field any one entry may match. String entries
+// accept shell glob patterns such as "0000:31:00.*" or "ens60f*".
+type DeviceSelector struct {
+	PCIAddresses   []string `json:"pci_addresses,omitempty"`
+	InterfaceNames []string `json:"interface_names,omitempty"`
+	Drivers        []string `json:"drivers,omitempty"`
+	NUMANodes      []int    `json:"numa_nodes,omitempty"`
+	// Subsystem IDs in "vendor:device" form, e.g. "15b3:0016" or "15b3:*"
+	SubsystemIDs []string `json:"subsystem_ids,omitempty"`
+}
+
+// Matches reports whether the selector matches a device
+func (s *DeviceSelector) Matches(device Device) bool {
+	if s == nil {
+		return true
+	}
+	if len(s.PCIAddresses) > 0 && !matchAnyPattern(s.PCIAddresses, device.PCIAddress) {
+		return false
+	}
+	if len(s.InterfaceNames) > 0 && !matchAnyPattern(s.InterfaceNames, device.Name) {
+		return false
+	}
+	if len(s.Drivers) > 0 && !matchAnyPattern(s.Drivers, device.Driver) {
+		return false
+	}
+	if len(s.NUMANodes) > 0 && !containsInt(s.NUMANodes, device.NUMANode) {
+		return false
+	}
+	if len(s.SubsystemIDs) > 0 {
+		subsystemID := device.SubVendorID + ":" + device.SubDeviceID
+		if !matchAnyPattern(s.SubsystemIDs, subsystemID) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that all selector patterns are well formed
+func (s *DeviceSelector) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, group := range [][]string{s.PCIAddresses, s.InterfaceNames, s.Drivers, s.SubsystemIDs} {
+		for _, pattern := range group {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+			}
+		}
+	}
+	for _, id := range s.SubsystemIDs {
+		if !strings.Contains(id, ":") {
+			return fmt.Errorf("invalid subsystem id %q: expected vendor:device", id)
+		}
+	}
+	return nil
+}
+
+// matchPattern matches a value against a case-insensitive glob pattern
+func matchPattern(pattern, value string) bool {
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+// matchAnyPattern reports whether any pattern matches the value
+func matchAnyPattern(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt reports whether a slice contains a value
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether some device could match both selectors. A nil
+// selector or an empty field matches any device.
+func (s *DeviceSelector) overlaps(other *DeviceSelector) bool {
+	if s == nil || other == nil {
+		return true
+	}
+	fields := [][2][]string{
+		{s.PCIAddresses, other.PCIAddresses},
+		{s.InterfaceNames, other.InterfaceNames},
+		{s.Drivers, other.Drivers},
+		{s.SubsystemIDs, other.SubsystemIDs},
+	}
+	for _, field := range fields {
+		if len(field[0]) > 0 && len(field[1]) > 0 && !anyPatternsOverlap(field[0], field[1]) {
+			return false
+		}
+	}
+	if len(s.NUMANodes) > 0 && len(other.NUMANodes) > 0 {
+		for _, node := range s.NUMANodes {
+			if containsInt(other.NUMANodes, node) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// anyPatternsOverlap reports whether a pattern of a and a pattern of b
+// match a common value
+func anyPatternsOverlap(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if patternsOverlap(x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// globToken is one element of a glob pattern: a star, or a matcher for a
+// single character
+type globToken struct {
+	star  bool
+	match func(c byte) bool
+}
+
+// patternsOverlap reports whether some value matches both case-insensitive
+// glob patterns. Malformed patterns are assumed to overlap.
+func patternsOverlap(a, b string) bool {
+	ta, errA := parseGlob(strings.ToLower(a))
+	tb, errB := parseGlob(strings.ToLower(b))
+	if errA != nil || errB != nil {
+		return true
+	}
+
+	// Walk both patterns together, consuming one character at a time,
+	// until both are exhausted
+	visited := make(map[[2]int]bool)
+	var walk func(i, j int) bool
+	walk = func(i, j int) bool {
+		if visited[[2]int{i, j}] {
+			return false
+		}
+		visited[[2]int{i, j}] = true
+		if i == len(ta) && j == len(tb) {
+			return true
+		}
+		// A star may match nothing
+		if i < len(ta) && ta[i].star && walk(i+1, j) {
+			return true
+		}
+		if j < len(tb) && tb[j].star && walk(i, j+1) {
+			return true
+		}
+		if i == len(ta) || j == len(tb) {
+			return false
+		}
+		switch {
+		case ta[i].star && tb[j].star:
+			return false
+		case ta[i].star:
+			return walk(i, j+1)
+		case tb[j].star:
+			return walk(i+1, j)
+		}
+		for c := 0; c < 256; c++ {
+			if ta[i].match(byte(c)) && tb[j].match(byte(c)) {
+				return walk(i+1, j+1)
+			}
+		}
+		return false
+	}
+	return walk(0, 0)
+}
+
+// parseGlob splits a path.Match pattern into tokens
+func parseGlob(pattern string) ([]globToken, error) {
+	var tokens []globToken
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			tokens = append(tokens, globToken{star: true})
+		case '?':
+			tokens = append(tokens, globToken{match: func(byte) bool { return true }})
+		case '[':
+			end := i + 1
+			negate := end < len(pattern) && pattern[end] == '^'
+			if negate {
+				end++
+			}
+			var ranges [][2]byte
+			for end < len(pattern) && pattern[end] != ']' {
+				lo, next, err := globClassChar(pattern, end)
+				if err != nil {
+					return nil, err
+				}
+				hi := lo
+				if next+1 < len(pattern) && pattern[next] == '-' && pattern[next+1] != ']' {
+					if hi, next, err = globClassChar(pattern, next+1); err != nil {
+						return nil, err
+					}
+				}
+				ranges = append(ranges, [2]byte{lo, hi})
+				end = next
+			}
+			if end == len(pattern) || len(ranges) == 0 {
+				return nil, path.ErrBadPattern
+			}
+			tokens = append(tokens, globToken{match: func(c byte) bool {
+				for _, r := range ranges {
+					if r[0] <= c && c <= r[1] {
+						return !negate
+					}
+				}
+				return negate
+			}})
+			i = end
+		default:
+			if c == '\\' {
+				if i++; i == len(pattern) {
+					return nil, path.ErrBadPattern
+				}
+				c = pattern[i]
+			}
+			tokens = append(tokens, globToken{match: func(v byte) bool { return v == c }})
+		}
+	}
+	return tokens, nil
+}
+
+// globClassChar reads a possibly escaped character of a character class
+func globClassChar(pattern string, i int) (byte, int, error) {
+	if pattern[i] == '\\' {
+		i++
+	}
+	if i >= len(pattern) {
+		return 0, i, path.ErrBadPattern
+	}
+	return pattern[i], i + 1, nil
+}
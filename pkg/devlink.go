@@ -0,0 +1,242 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Eswitch modes of a PF
+const (
+	EswitchModeLegacy    = "legacy"
+	EswitchModeSwitchdev = "switchdev"
+)
+
+// EswitchState is the embedded switch configuration of a PF as exposed by
+// devlink. Names follow devlink(8); empty fields are unknown or, in a policy,
+// left unchanged.
+type EswitchState struct {
+	// Mode is legacy or switchdev
+	Mode string `json:"mode,omitempty"`
+	// InlineMode is none, link, network or transport
+	InlineMode string `json:"inline_mode,omitempty"`
+	// EncapMode is none or basic
+	EncapMode string `json:"encap_mode,omitempty"`
+}
+
+// devlink attribute values by name
+var (
+	eswitchModes = map[string]uint16{
+		EswitchModeLegacy:    nl.DEVLINK_ESWITCH_MODE_LEGACY,
+		EswitchModeSwitchdev: nl.DEVLINK_ESWITCH_MODE_SWITCHDEV,
+	}
+	eswitchInlineModes = map[string]uint8{
+		"none":      nl.DEVLINK_ESWITCH_INLINE_MODE_NONE,
+		"link":      nl.DEVLINK_ESWITCH_INLINE_MODE_LINK,
+		"network":   nl.DEVLINK_ESWITCH_INLINE_MODE_NETWORK,
+		"transport": nl.DEVLINK_ESWITCH_INLINE_MODE_TRANSPORT,
+	}
+	eswitchEncapModes = map[string]uint8{
+		"none":  nl.DEVLINK_ESWITCH_ENCAP_MODE_NONE,
+		"basic": nl.DEVLINK_ESWITCH_ENCAP_MODE_BASIC,
+	}
+)
+
+// getEswitchState and setEswitchState are defined as variables so they can
+// be overridden in tests
+var (
+	getEswitchState = netlinkEswitchState
+	setEswitchState = netlinkSetEswitchState
+)
+
+// IsZero reports whether no field is set
+func (s EswitchState) IsZero() bool {
+	return s == EswitchState{}
+}
+
+// String formats the set fields the way devlink(8) takes them
+func (s EswitchState) String() string {
+	var parts []string
+	if s.Mode != "" {
+		parts = append(parts, "mode "+s.Mode)
+	}
+	if s.InlineMode != "" {
+		parts = append(parts, "inline-mode "+s.InlineMode)
+	}
+	if s.EncapMode != "" {
+		parts = append(parts, "encap-mode "+s.EncapMode)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Validate checks that every set field has a value devlink accepts
+func (s *EswitchState) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if _, ok := eswitchModes[s.Mode]; s.Mode != "" && !ok {
+		return fmt.Errorf("invalid mode %q, expected legacy or switchdev", s.Mode)
+	}
+	if _, ok := eswitchInlineModes[s.InlineMode]; s.InlineMode != "" && !ok {
+		return fmt.Errorf("invalid inline_mode %q, expected none, link, network or transport", s.InlineMode)
+	}
+	if _, ok := eswitchEncapModes[s.EncapMode]; s.EncapMode != "" && !ok {
+		return fmt.Errorf("invalid encap_mode %q, expected none or basic", s.EncapMode)
+	}
+	return nil
+}
+
+// changes returns the fields of s that differ from current
+func (s EswitchState) changes(current EswitchState) EswitchState {
+	var diff EswitchState
+	if s.Mode != "" && s.Mode != current.Mode {
+		diff.Mode = s.Mode
+	}
+	if s.InlineMode != "" && s.InlineMode != current.InlineMode {
+		diff.InlineMode = s.InlineMode
+	}
+	if s.EncapMode != "" && s.EncapMode != current.EncapMode {
+		diff.EncapMode = s.EncapMode
+	}
+	return diff
+}
+
+// DesiredEswitch returns the eswitch state a policy asks for. enable_switch
+// is shorthand for switchdev mode.
+func (p *DevicePolicy) DesiredEswitch() EswitchState {
+	var desired EswitchState
+	if p.Eswitch != nil {
+		desired = *p.Eswitch
+	}
+	if p.EnableSwitch && desired.Mode == "" {
+		desired.Mode = EswitchModeSwitchdev
+	}
+	return desired
+}
+
+// netlinkEswitchState reads the eswitch state of a PCI device from devlink
+func netlinkEswitchState(pciAddress string) (EswitchState, error) {
+	dev, err := netlink.DevLinkGetDeviceByName("pci", pciAddress)
+	if err != nil {
+		return EswitchState{}, fmt.Errorf("failed to get devlink device pci/%s: %v", pciAddress, err)
+	}
+
+	state := EswitchState{
+		Mode:       dev.Attrs.Eswitch.Mode,
+		InlineMode: dev.Attrs.Eswitch.InlineMode,
+		EncapMode:  dev.Attrs.Eswitch.EncapMode,
+	}
+	// The netlink library reports encap modes by their pre-5.x names
+	switch state.EncapMode {
+	case "disable":
+		state.EncapMode = "none"
+	case "enable":
+		state.EncapMode = "basic"
+	}
+	for _, field := range []*string{&state.Mode, &state.InlineMode, &state.EncapMode} {
+		if *field == "unknown" {
+			*field = ""
+		}
+	}
+	return state, nil
+}
+
+// netlinkSetEswitchState sends one DEVLINK_CMD_ESWITCH_SET carrying every set
+// field. The netlink library only sets the mode, so the request is built here.
+func netlinkSetEswitchState(pciAddress string, state EswitchState) error {
+	family, err := netlink.GenlFamilyGet(nl.GENL_DEVLINK_NAME)
+	if err != nil {
+		return fmt.Errorf("devlink is not available: %v", err)
+	}
+
+	req := nl.NewNetlinkRequest(int(family.ID), unix.NLM_F_REQUEST|unix.NLM_F_ACK)
+	req.AddData(&nl.Genlmsg{Command: nl.DEVLINK_CMD_ESWITCH_SET, Version: nl.GENL_DEVLINK_VERSION})
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_BUS_NAME, nl.ZeroTerminated("pci")))
+	req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_DEV_NAME, nl.ZeroTerminated(pciAddress)))
+	if state.Mode != "" {
+		req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_ESWITCH_MODE, nl.Uint16Attr(eswitchModes[state.Mode])))
+	}
+	if state.InlineMode != "" {
+		req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_ESWITCH_INLINE_MODE, nl.Uint8Attr(eswitchInlineModes[state.InlineMode])))
+	}
+	if state.EncapMode != "" {
+		req.AddData(nl.NewRtAttr(nl.DEVLINK_ATTR_ESWITCH_ENCAP_MODE, nl.Uint8Attr(eswitchEncapModes[state.EncapMode])))
+	}
+
+	if _, err := req.Execute(unix.NETLINK_GENERIC, 0); err != nil {
+		return fmt.Errorf("devlink eswitch set pci/%s %s: %v", pciAddress, state, err)
+	}
+	return nil
+}
+
+// AttachEswitchInfo adds the devlink eswitch state to each SR-IOV capable PF.
+// Devices whose driver has no eswitch support are left without one.
+func AttachEswitchInfo(devices []Device) ([]Device, error) {
+	cache := make(map[string]*EswitchState)
+	for i := range devices {
+		if !devices[i].SRIOVCapable || devices[i].IsVF() {
+			continue
+		}
+		address := devices[i].PCIAddress
+		state, ok := cache[address]
+		if !ok {
+			if current, err := getEswitchState(address); err != nil {
+				WithField("pci", address).WithError(err).Debug("No eswitch state")
+			} else if !current.IsZero() {
+				state = &current
+			}
+			cache[address] = state
+		}
+		devices[i].Eswitch = state
+	}
+	return devices, nil
+}
+
+// ensureEswitch brings the eswitch of a PF to the desired state and returns
+// the fields it changed. Drivers refuse eswitch changes while VFs
+// exist, so existing VFs are destroyed first; enableSRIOV recreates them.
+func (m *SRIOVManager) ensureEswitch(device Device, desired EswitchState) (EswitchState, error) {
+	if desired.IsZero() {
+		return EswitchState{}, nil
+	}
+	current, err := getEswitchState(device.PCIAddress)
+	if err != nil {
+		return EswitchState{}, fmt.Errorf("failed to read eswitch state of %s: %v", device.Name, err)
+	}
+	change := desired.changes(current)
+	if change.IsZero() {
+		return EswitchState{}, nil
+	}
+
+	sriovPath := filepath.Join(PciDevicePath(device.PCIAddress), "sriov_numvfs")
+	if value, err := m.readSysfsValue(sriovPath); err == nil && value != "0" {
+		if m.Config().GuardInUseVFs {
+			inUse, err := inUseVFs(device.PCIAddress)
+			if err != nil {
+				return EswitchState{}, fmt.Errorf("failed to check VF usage: %v", err)
+			}
+			if len(inUse) > 0 {
+				return EswitchState{}, fmt.Errorf("refusing to change eswitch %s on %s: %w: %s",
+					change, device.Name, ErrVFsInUse, strings.Join(inUse, ", "))
+			}
+		}
+		if err := m.writeSysfs(fmt.Sprintf("destroy VFs on %s before changing eswitch", device.Name), sriovPath, "0"); err != nil {
+			return EswitchState{}, fmt.Errorf("failed to reset VFs: %v", err)
+		}
+	}
+
+	target := fmt.Sprintf("pci/%s eswitch", device.PCIAddress)
+	err = m.applyNetlink(fmt.Sprintf("set eswitch %s on %s", change, device.Name), target, current.String(), change.String(), func() error {
+		return setEswitchState(device.PCIAddress, change)
+	})
+	if err != nil {
+		return EswitchState{}, fmt.Errorf("failed to set eswitch %s on %s: %v", change, device.Name, err)
+	}
+
+	Info("Eswitch of %s set to %s", device.Name, change)
+	return change, nil
+}
@@ -0,0 +1,278 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// Devlink port flavours as named by devlink(8)
+const (
+	PortFlavourPhysical = "physical"
+	PortFlavourPCIPF    = "pcipf"
+	PortFlavourPCIVF    = "pcivf"
+	PortFlavourPCISF    = "pcisf"
+	PortFlavourVirtual  = "virtual"
+)
+
+// DevlinkPort is a port of a devlink instance. In switchdev mode the pcipf,
+// pcivf and pcisf ports are the eswitch representors of those functions.
+// JSON names match `devlink -j port show`.
+type DevlinkPort struct {
+	// Handle is the devlink port handle, e.g. pci/0000:31:00.0/1
+	Handle     string `json:"handle,omitempty"`
+	PCIAddress string `json:"pci_address,omitempty"`
+	Index      uint32 `json:"index,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Netdev     string `json:"netdev,omitempty"`
+	Flavour    string `json:"flavour,omitempty"`
+	// Number is the physical port number of a physical port
+	Number     uint32 `json:"port,omitempty"`
+	Controller uint32 `json:"controller,omitempty"`
+	PFNum      uint32 `json:"pfnum,omitempty"`
+	VFNum      uint32 `json:"vfnum,omitempty"`
+	SFNum      uint32 `json:"sfnum,omitempty"`
+	// External is set for functions of another host's controller
+	External   bool          `json:"external,omitempty"`
+	Splittable bool          `json:"splittable,omitempty"`
+	Function   *PortFunction `json:"function,omitempty"`
+}
+
+// PortFunction is the function behind a representor port as configured
+// through the eswitch. Empty fields are not reported by the driver.
+type PortFunction struct {
+	HwAddr  string `json:"hw_addr,omitempty"`
+	State   string `json:"state,omitempty"`   // active or inactive
+	OpState string `json:"opstate,omitempty"` // attached or detached
+	// Roce and Migratable are "enable" or "disable"
+	Roce       string `json:"roce,omitempty"`
+	Migratable string `json:"migratable,omitempty"`
+}
+
+// listDevlinkPorts is defined as a variable so it can be overridden in tests
+var listDevlinkPorts = netlinkDevlinkPorts
+
+// devlink port flavour and type names by attribute value
+var (
+	devlinkPortFlavours = map[uint16]string{
+		nl.DEVLINK_PORT_FLAVOUR_PHYSICAL: PortFlavourPhysical,
+		nl.DEVLINK_PORT_FLAVOUR_CPU:      "cpu",
+		nl.DEVLINK_PORT_FLAVOUR_DSA:      "dsa",
+		nl.DEVLINK_PORT_FLAVOUR_PCI_PF:   PortFlavourPCIPF,
+		nl.DEVLINK_PORT_FLAVOUR_PCI_VF:   PortFlavourPCIVF,
+		nl.DEVLINK_PORT_FLAVOUR_VIRTUAL:  PortFlavourVirtual,
+		nl.DEVLINK_PORT_FLAVOUR_UNUSED:   "unused",
+		nl.DEVLINK_PORT_FLAVOUR_PCI_SF:   PortFlavourPCISF,
+	}
+	devlinkPortTypes = map[uint16]string{
+		nl.DEVLINK_PORT_TYPE_NOTSET: "notset",
+		nl.DEVLINK_PORT_TYPE_AUTO:   "auto",
+		nl.DEVLINK_PORT_TYPE_ETH:    "eth",
+		nl.DEVLINK_PORT_TYPE_IB:     "ib",
+	}
+)
+
+// ParseDevlinkPorts parses the output of `devlink -j port show`, ordered by
+// device and port index
+func ParseDevlinkPorts(data []byte) ([]DevlinkPort, error) {
+	var raw struct {
+		Port map[string]DevlinkPort `json:"port"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse devlink port output: %v", err)
+	}
+
+	ports := make([]DevlinkPort, 0, len(raw.Port))
+	for handle, port := range raw.Port {
+		parts := strings.Split(handle, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid devlink port handle %q", handle)
+		}
+		index, err := strconv.ParseUint(parts[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid devlink port handle %q: %v", handle, err)
+		}
+		port.Handle = handle
+		port.Index = uint32(index)
+		if parts[0] == "pci" {
+			port.PCIAddress = parts[1]
+		}
+		ports = append(ports, port)
+	}
+	sortDevlinkPorts(ports)
+	return ports, nil
+}
+
+// sortDevlinkPorts orders ports by device and port index
+func sortDevlinkPorts(ports []DevlinkPort) {
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].PCIAddress != ports[j].PCIAddress {
+			return ports[i].PCIAddress < ports[j].PCIAddress
+		}
+		return ports[i].Index < ports[j].Index
+	})
+}
+
+// netlinkDevlinkPorts dumps every devlink port. The netlink library drops
+// the pfnum, vfnum and function capability attributes, so the dump is
+// parsed here. Kernels without devlink have no ports.
+func netlinkDevlinkPorts() ([]DevlinkPort, error) {
+	family, err := netlink.GenlFamilyGet(nl.GENL_DEVLINK_NAME)
+	if err != nil {
+		Debug("devlink is not available: %v", err)
+		return nil, nil
+	}
+
+	req := nl.NewNetlinkRequest(int(family.ID), unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	req.AddData(&nl.Genlmsg{Command: nl.DEVLINK_CMD_PORT_GET, Version: nl.GENL_DEVLINK_VERSION})
+	msgs, err := req.Execute(unix.NETLINK_GENERIC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump devlink ports: %v", err)
+	}
+
+	var ports []DevlinkPort
+	for _, msg := range msgs {
+		attrs, err := nl.ParseRouteAttr(msg[nl.SizeofGenlmsg:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse devlink port: %v", err)
+		}
+		ports = append(ports, parseDevlinkPortAttrs(attrs))
+	}
+	sortDevlinkPorts(ports)
+	return ports, nil
+}
+
+// parseDevlinkPortAttrs converts the attributes of one DEVLINK_CMD_PORT_GET reply
+func parseDevlinkPortAttrs(attrs []syscall.NetlinkRouteAttr) DevlinkPort {
+	var port DevlinkPort
+	var bus, device string
+	native := nl.NativeEndian()
+	for _, attr := range attrs {
+		value := attr.Value
+		switch attr.Attr.Type & nl.NLA_TYPE_MASK {
+		case nl.DEVLINK_ATTR_BUS_NAME:
+			bus = nlString(value)
+		case nl.DEVLINK_ATTR_DEV_NAME:
+			device = nlString(value)
+		case nl.DEVLINK_ATTR_PORT_INDEX:
+			port.Index = native.Uint32(value)
+		case nl.DEVLINK_ATTR_PORT_TYPE:
+			port.Type = devlinkPortTypes[native.Uint16(value)]
+		case nl.DEVLINK_ATTR_PORT_NETDEV_NAME:
+			port.Netdev = nlString(value)
+		case nl.DEVLINK_ATTR_PORT_FLAVOUR:
+			port.Flavour = devlinkPortFlavours[native.Uint16(value)]
+		case unix.DEVLINK_ATTR_PORT_NUMBER:
+			port.Number = native.Uint32(value)
+		case unix.DEVLINK_ATTR_PORT_CONTROLLER_NUMBER:
+			port.Controller = native.Uint32(value)
+		case unix.DEVLINK_ATTR_PORT_PCI_PF_NUMBER:
+			port.PFNum = uint32(native.Uint16(value))
+		case unix.DEVLINK_ATTR_PORT_PCI_VF_NUMBER:
+			port.VFNum = uint32(native.Uint16(value))
+		case unix.DEVLINK_ATTR_PORT_PCI_SF_NUMBER:
+			port.SFNum = native.Uint32(value)
+		case unix.DEVLINK_ATTR_PORT_EXTERNAL:
+			port.External = value[0] != 0
+		case unix.DEVLINK_ATTR_PORT_SPLITTABLE:
+			port.Splittable = value[0] != 0
+		case unix.DEVLINK_ATTR_PORT_FUNCTION:
+			if nested, err := nl.ParseRouteAttr(value); err == nil {
+				port.Function = parsePortFunctionAttrs(nested)
+			}
+		}
+	}
+	port.Handle = fmt.Sprintf("%s/%s/%d", bus, device, port.Index)
+	if bus == "pci" {
+		port.PCIAddress = device
+	}
+	return port
+}
+
+// parsePortFunctionAttrs converts a nested DEVLINK_ATTR_PORT_FUNCTION
+func parsePortFunctionAttrs(attrs []syscall.NetlinkRouteAttr) *PortFunction {
+	function := &PortFunction{}
+	native := nl.NativeEndian()
+	for _, attr := range attrs {
+		value := attr.Value
+		switch attr.Attr.Type & nl.NLA_TYPE_MASK {
+		case unix.DEVLINK_PORT_FUNCTION_ATTR_HW_ADDR:
+			function.HwAddr = net.HardwareAddr(value).String()
+		case unix.DEVLINK_PORT_FN_ATTR_STATE:
+			function.State = map[bool]string{true: "active", false: "inactive"}[value[0] == nl.DEVLINK_PORT_FN_STATE_ACTIVE]
+		case unix.DEVLINK_PORT_FN_ATTR_OPSTATE:
+			function.OpState = map[bool]string{true: "attached", false: "detached"}[value[0] == nl.DEVLINK_PORT_FN_OPSTATE_ATTACHED]
+		case unix.DEVLINK_PORT_FN_ATTR_CAPS:
+			// struct nla_bitfield32: the values, then the mask of reported bits
+			if len(value) < 8 {
+				continue
+			}
+			caps, selector := native.Uint32(value[0:4]), native.Uint32(value[4:8])
+			if selector&unix.DEVLINK_PORT_FN_CAP_ROCE != 0 {
+				function.Roce = enableDisable(caps&unix.DEVLINK_PORT_FN_CAP_ROCE != 0)
+			}
+			if selector&unix.DEVLINK_PORT_FN_CAP_MIGRATABLE != 0 {
+				function.Migratable = enableDisable(caps&unix.DEVLINK_PORT_FN_CAP_MIGRATABLE != 0)
+			}
+		}
+	}
+	return function
+}
+
+// nlString converts a NUL terminated netlink string attribute
+func nlString(value []byte) string {
+	return strings.TrimRight(string(value), "\x00")
+}
+
+// enableDisable formats a flag the way devlink(8) does
+func enableDisable(value bool) string {
+	return map[bool]string{true: "enable", false: "disable"}[value]
+}
+
+// AttachDevlinkPorts adds the devlink ports of each PF's devlink instance to
+// the PF, maps every VF to its representor port and lists the PF's SFs
+func AttachDevlinkPorts(devices []Device) ([]Device, error) {
+	ports, err := listDevlinkPorts()
+	if err != nil {
+		return devices, err
+	}
+
+	byAddress := make(map[string][]DevlinkPort)
+	for _, port := range ports {
+		if port.PCIAddress != "" {
+			byAddress[port.PCIAddress] = append(byAddress[port.PCIAddress], port)
+		}
+	}
+
+	for i := range devices {
+		devices[i].DevlinkPorts = byAddress[devices[i].PCIAddress]
+		if len(devices[i].DevlinkPorts) > 0 {
+			devices[i].SFs = listSubFunctions(devices[i].PCIAddress, devices[i].DevlinkPorts)
+		}
+		for j := range devices[i].VFs {
+			if port := vfRepresentor(devices[i].DevlinkPorts, devices[i].VFs[j].Index); port != nil {
+				devices[i].VFs[j].Representor = port.Netdev
+				devices[i].VFs[j].PortFunction = port.Function
+			}
+		}
+	}
+	return devices, nil
+}
+
+// vfRepresentor returns the local pcivf port of VF index among a PF's ports
+func vfRepresentor(ports []DevlinkPort, index int) *DevlinkPort {
+	for i := range ports {
+		if ports[i].Flavour == PortFlavourPCIVF && !ports[i].External && int(ports[i].VFNum) == index {
+			return &ports[i]
+		}
+	}
+	return nil
+}
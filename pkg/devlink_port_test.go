@@ -0,0 +1,146 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// TestParseDevlinkPorts tests parsing of the devlink port sample
+func TestParseDevlinkPorts(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "sample-outputs", "devlink.json"))
+	if err != nil {
+		t.Fatalf("failed to read devlink sample: %v", err)
+	}
+	ports, err := ParseDevlinkPorts(data)
+	if err != nil {
+		t.Fatalf("ParseDevlinkPorts failed: %v", err)
+	}
+	if len(ports) != 26 {
+		t.Fatalf("expected 26 ports, got %d", len(ports))
+	}
+
+	var pf0 []DevlinkPort
+	for _, port := range ports {
+		if port.PCIAddress == "0000:31:00.0" {
+			pf0 = append(pf0, port)
+		}
+	}
+	if len(pf0) != 5 {
+		t.Fatalf("expected 5 ports on 0000:31:00.0, got %d", len(pf0))
+	}
+	// Ports are ordered by index, so the physical port 65535 comes last
+	physical := pf0[4]
+	if physical.Flavour != PortFlavourPhysical || physical.Netdev != "ens60f0np0" || physical.Index != 65535 || physical.Number != 0 {
+		t.Errorf("unexpected physical port %+v", physical)
+	}
+	expected := DevlinkPort{
+		Handle:     "pci/0000:31:00.0/3",
+		PCIAddress: "0000:31:00.0",
+		Index:      3,
+		Type:       "eth",
+		Netdev:     "ens60f0npf0vf2",
+		Flavour:    PortFlavourPCIVF,
+		VFNum:      2,
+		Function:   &PortFunction{HwAddr: "00:00:00:00:00:00"},
+	}
+	if !reflect.DeepEqual(pf0[2], expected) {
+		t.Errorf("expected %+v, got %+v", expected, pf0[2])
+	}
+
+	if _, err := ParseDevlinkPorts([]byte(`{"port": {"pci/0000:31:00.0": {}}}`)); err == nil {
+		t.Error("expected an error for a handle without a port index")
+	}
+}
+
+// TestParseDevlinkPortAttrs tests decoding of a DEVLINK_CMD_PORT_GET reply
+func TestParseDevlinkPortAttrs(t *testing.T) {
+	function := nl.NewRtAttr(unix.DEVLINK_ATTR_PORT_FUNCTION|int(nl.NLA_F_NESTED), nil)
+	function.AddRtAttr(unix.DEVLINK_PORT_FUNCTION_ATTR_HW_ADDR, []byte{0x02, 0, 0, 0, 0x01, 0x05})
+	function.AddRtAttr(unix.DEVLINK_PORT_FN_ATTR_STATE, []byte{nl.DEVLINK_PORT_FN_STATE_ACTIVE})
+	function.AddRtAttr(unix.DEVLINK_PORT_FN_ATTR_OPSTATE, []byte{nl.DEVLINK_PORT_FN_OPSTATE_ATTACHED})
+	// RoCE and migratable reported, only RoCE enabled
+	caps := append(nl.Uint32Attr(unix.DEVLINK_PORT_FN_CAP_ROCE),
+		nl.Uint32Attr(unix.DEVLINK_PORT_FN_CAP_ROCE|unix.DEVLINK_PORT_FN_CAP_MIGRATABLE)...)
+	function.AddRtAttr(unix.DEVLINK_PORT_FN_ATTR_CAPS, caps)
+
+	var msg []byte
+	for _, attr := range []*nl.RtAttr{
+		nl.NewRtAttr(nl.DEVLINK_ATTR_BUS_NAME, nl.ZeroTerminated("pci")),
+		nl.NewRtAttr(nl.DEVLINK_ATTR_DEV_NAME, nl.ZeroTerminated("0000:31:00.0")),
+		nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_INDEX, nl.Uint32Attr(6)),
+		nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_TYPE, nl.Uint16Attr(nl.DEVLINK_PORT_TYPE_ETH)),
+		nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_NETDEV_NAME, nl.ZeroTerminated("ens60f0npf0vf5")),
+		nl.NewRtAttr(nl.DEVLINK_ATTR_PORT_FLAVOUR, nl.Uint16Attr(nl.DEVLINK_PORT_FLAVOUR_PCI_VF)),
+		nl.NewRtAttr(unix.DEVLINK_ATTR_PORT_PCI_PF_NUMBER, nl.Uint16Attr(0)),
+		nl.NewRtAttr(unix.DEVLINK_ATTR_PORT_PCI_VF_NUMBER, nl.Uint16Attr(5)),
+		nl.NewRtAttr(unix.DEVLINK_ATTR_PORT_EXTERNAL, []byte{0}),
+		function,
+	} {
+		msg = append(msg, attr.Serialize()...)
+	}
+	attrs, err := nl.ParseRouteAttr(msg)
+	if err != nil {
+		t.Fatalf("failed to parse attributes: %v", err)
+	}
+
+	expected := DevlinkPort{
+		Handle:     "pci/0000:31:00.0/6",
+		PCIAddress: "0000:31:00.0",
+		Index:      6,
+		Type:       "eth",
+		Netdev:     "ens60f0npf0vf5",
+		Flavour:    PortFlavourPCIVF,
+		VFNum:      5,
+		Function: &PortFunction{
+			HwAddr:     "02:00:00:00:01:05",
+			State:      "active",
+			OpState:    "attached",
+			Roce:       "enable",
+			Migratable: "disable",
+		},
+	}
+	if port := parseDevlinkPortAttrs(attrs); !reflect.DeepEqual(port, expected) {
+		t.Errorf("expected %+v, got %+v", expected, port)
+	}
+}
+
+// TestAttachDevlinkPorts tests that VFs are mapped to their representors
+func TestAttachDevlinkPorts(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "sample-outputs", "devlink.json"))
+	if err != nil {
+		t.Fatalf("failed to read devlink sample: %v", err)
+	}
+	original := listDevlinkPorts
+	listDevlinkPorts = func() ([]DevlinkPort, error) { return ParseDevlinkPorts(data) }
+	defer func() { listDevlinkPorts = original }()
+
+	devices, err := AttachDevlinkPorts([]Device{
+		{Name: "ens60f1np1", PCIAddress: "0000:31:00.1", VFs: []VirtualFunction{{Index: 0}, {Index: 3}, {Index: 4}}},
+		{Name: "eno1np0", PCIAddress: "0000:69:00.0"},
+	})
+	if err != nil {
+		t.Fatalf("AttachDevlinkPorts failed: %v", err)
+	}
+
+	pf := devices[0]
+	if len(pf.DevlinkPorts) != 5 {
+		t.Errorf("expected 5 devlink ports, got %d", len(pf.DevlinkPorts))
+	}
+	if pf.VFs[0].Representor != "ens60f1npf1vf0" || pf.VFs[1].Representor != "ens60f1npf1vf3" {
+		t.Errorf("unexpected representors %q and %q", pf.VFs[0].Representor, pf.VFs[1].Representor)
+	}
+	if pf.VFs[0].PortFunction == nil || pf.VFs[0].PortFunction.HwAddr != "00:00:00:00:00:00" {
+		t.Errorf("expected the port function of vf0, got %+v", pf.VFs[0].PortFunction)
+	}
+	if pf.VFs[2].Representor != "" || pf.VFs[2].PortFunction != nil {
+		t.Errorf("expected no representor for vf4, got %+v", pf.VFs[2])
+	}
+	if len(devices[1].DevlinkPorts) != 1 || devices[1].DevlinkPorts[0].Netdev != "eno1np0" {
+		t.Errorf("unexpected ports for eno1np0: %+v", devices[1].DevlinkPorts)
+	}
+}
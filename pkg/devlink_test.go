@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeEswitch holds the eswitch state of each PF, starting from a shared
+// initial state, and records the order of sysfs writes and eswitch changes
+type fakeEswitch struct {
+	initial EswitchState
+	states  map[string]EswitchState
+	calls   []string
+}
+
+// state returns the eswitch state of a PF
+func (f *fakeEswitch) state(pciAddress string) EswitchState {
+	if state, ok := f.states[pciAddress]; ok {
+		return state
+	}
+	return f.initial
+}
+
+// useFakeEswitch routes devlink reads and writes and sysfs writes to a fake
+func useFakeEswitch(t *testing.T, initial EswitchState) *fakeEswitch {
+	fake := &fakeEswitch{initial: initial, states: make(map[string]EswitchState)}
+
+	originalGet, originalSet, originalWrite := getEswitchState, setEswitchState, writeSysfsFile
+	getEswitchState = func(pciAddress string) (EswitchState, error) {
+		return fake.state(pciAddress), nil
+	}
+	setEswitchState = func(pciAddress string, change EswitchState) error {
+		state := fake.state(pciAddress)
+		if change.Mode != "" {
+			state.Mode = change.Mode
+		}
+		if change.InlineMode != "" {
+			state.InlineMode = change.InlineMode
+		}
+		if change.EncapMode != "" {
+			state.EncapMode = change.EncapMode
+		}
+		fake.states[pciAddress] = state
+		fake.calls = append(fake.calls, "eswitch "+change.String())
+		return nil
+	}
+	writeSysfsFile = func(path string, data []byte, perm os.FileMode) error {
+		fake.calls = append(fake.calls, filepath.Base(path)+"="+string(data))
+		return os.WriteFile(path, data, perm)
+	}
+	t.Cleanup(func() {
+		getEswitchState, setEswitchState, writeSysfsFile = originalGet, originalSet, originalWrite
+	})
+	return fake
+}
+
+// TestReconcileEswitch tests that VFs are destroyed before the eswitch mode
+// changes and recreated afterwards
+func TestReconcileEswitch(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	writeSysfsFiles(t, filepath.Join(root, "bus", "pci", "devices", "0000:31:00.0"), map[string]string{"sriov_numvfs": "4\n"})
+
+	fake := useFakeEswitch(t, EswitchState{Mode: EswitchModeLegacy, InlineMode: "none", EncapMode: "basic"})
+	policies := []DevicePolicy{{
+		VendorID: "15b3", DeviceID: "101e", NumVFs: 4, EnableSwitch: true,
+		Eswitch: &EswitchState{EncapMode: "none"},
+	}}
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	dryRun := NewSRIOVManager(&SRIOVConfig{DryRun: true, DevicePolicies: policies})
+	if status := dryRun.reconcileDevice(device); status.State != ReconcilePlanned {
+		t.Fatalf("expected planned, got %s (%s)", status.State, status.Message)
+	}
+	var planned []string
+	for _, action := range dryRun.Plan().Actions {
+		planned = append(planned, string(action.Type)+" "+action.Desired)
+	}
+	expectedPlan := []string{"write-sysfs 0", "netlink mode switchdev encap-mode none", "write-sysfs 4"}
+	if !reflect.DeepEqual(planned, expectedPlan) || len(fake.calls) != 0 {
+		t.Errorf("expected plan %v and no changes, got %v and %v", expectedPlan, planned, fake.calls)
+	}
+
+	manager := NewSRIOVManager(&SRIOVConfig{DevicePolicies: policies})
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileApplied {
+		t.Fatalf("expected applied, got %s (%s)", status.State, status.Message)
+	}
+	expectedCalls := []string{"sriov_numvfs=0", "eswitch mode switchdev encap-mode none", "sriov_numvfs=4"}
+	if !reflect.DeepEqual(fake.calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, fake.calls)
+	}
+	if len(status.Actions) == 0 || !strings.HasPrefix(status.Actions[0], "set eswitch mode switchdev") {
+		t.Errorf("expected an eswitch action first, got %v", status.Actions)
+	}
+
+	fake.calls = nil
+	if status := manager.reconcileDevice(device); status.State != ReconcileInSync || len(fake.calls) != 0 {
+		t.Errorf("expected in-sync without changes, got %s %v", status.State, fake.calls)
+	}
+}
+
+// TestEnsureEswitchWithoutVFs tests that a PF without VFs switches mode
+// without touching sriov_numvfs
+func TestEnsureEswitchWithoutVFs(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	writeSysfsFiles(t, filepath.Join(root, "bus", "pci", "devices", "0000:31:00.0"), map[string]string{"sriov_numvfs": "0\n"})
+
+	fake := useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0"}
+
+	change, err := manager.ensureEswitch(device, EswitchState{Mode: EswitchModeLegacy})
+	if err != nil {
+		t.Fatalf("ensureEswitch failed: %v", err)
+	}
+	if change.Mode != EswitchModeLegacy || !reflect.DeepEqual(fake.calls, []string{"eswitch mode legacy"}) {
+		t.Errorf("expected only an eswitch change, got %+v %v", change, fake.calls)
+	}
+}
+
+// TestAttachEswitchInfo tests that only SR-IOV capable PFs get an eswitch state
+func TestAttachEswitchInfo(t *testing.T) {
+	useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev, InlineMode: "none", EncapMode: "basic"})
+
+	devices, _ := AttachEswitchInfo([]Device{
+		{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", SRIOVCapable: true},
+		{Name: "ens60f0v0", PCIAddress: "0000:31:00.2", SRIOVCapable: true, PhysFn: "0000:31:00.0"},
+		{Name: "eno1", PCIAddress: "0000:01:00.0"},
+	})
+	if devices[0].Eswitch == nil || devices[0].Eswitch.Mode != EswitchModeSwitchdev {
+		t.Errorf("expected switchdev on the PF, got %+v", devices[0].Eswitch)
+	}
+	if devices[1].Eswitch != nil || devices[2].Eswitch != nil {
+		t.Errorf("expected no eswitch on the VF and non-SR-IOV device, got %+v %+v", devices[1].Eswitch, devices[2].Eswitch)
+	}
+}
+
+// TestValidateEswitch tests validation of policy eswitch settings
+func TestValidateEswitch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy DevicePolicy
+		valid  bool
+	}{
+		{"valid", DevicePolicy{Eswitch: &EswitchState{Mode: "switchdev", InlineMode: "transport", EncapMode: "basic"}}, true},
+		{"enable_switch only", DevicePolicy{EnableSwitch: true}, true},
+		{"invalid mode", DevicePolicy{Eswitch: &EswitchState{Mode: "offload"}}, false},
+		{"invalid inline mode", DevicePolicy{Eswitch: &EswitchState{InlineMode: "ip"}}, false},
+		{"invalid encap mode", DevicePolicy{Eswitch: &EswitchState{EncapMode: "vxlan"}}, false},
+		{"conflicting mode", DevicePolicy{EnableSwitch: true, Eswitch: &EswitchState{Mode: "legacy"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.policy.VendorID, tc.policy.DeviceID, tc.policy.NumVFs = "15b3", "101e", 4
+			err := (&SRIOVConfig{DevicePolicies: []DevicePolicy{tc.policy}}).ValidateConfig()
+			if tc.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
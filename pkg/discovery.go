@@ -0,0 +1,79 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	DiscoveryLshw  = "lshw"
+	DiscoverySysfs = "sysfs"
+	// DefaultDiscovery is the backend used unless another one is selected
+	DefaultDiscovery = DiscoverySysfs
+)
+
+// DiscoveryBackend lists the network devices of the host. The result carries
+// the identity fields lshw reports; PCI, ethtool and VF details are attached
+// afterwards by AttachPciInfo, AttachEthtoolInfo and AttachVFInfo.
+type DiscoveryBackend interface {
+	Name() string
+	Discover() ([]Device, error)
+}
+
+// LshwDiscovery discovers devices by running lshw
+type LshwDiscovery struct{}
+
+// Name returns the backend name
+func (LshwDiscovery) Name() string { return DiscoveryLshw }
+
+// Discover runs lshw -class network -json
+func (LshwDiscovery) Discover() ([]Device, error) {
+	return ParseLshwDynamic()
+}
+
+var discoveryBackends = map[string]DiscoveryBackend{
+	DiscoveryLshw:  LshwDiscovery{},
+	DiscoverySysfs: SysfsDiscovery{},
+}
+
+// discoveryBackend is the backend used by DiscoverNetworkDevices
+var discoveryBackend DiscoveryBackend = discoveryBackends[DefaultDiscovery]
+
+// NewDiscoveryBackend returns the backend registered under name
+func NewDiscoveryBackend(name string) (DiscoveryBackend, error) {
+	backend, ok := discoveryBackends[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown discovery backend %q, expected one of: %s", name, strings.Join(DiscoveryBackendNames(), ", "))
+	}
+	return backend, nil
+}
+
+// DiscoveryBackendNames returns the names of all registered backends
+func DiscoveryBackendNames() []string {
+	names := make([]string, 0, len(discoveryBackends))
+	for name := range discoveryBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDiscoveryBackend selects the backend used by DiscoverNetworkDevices by name
+func SetDiscoveryBackend(name string) error {
+	backend, err := NewDiscoveryBackend(name)
+	if err != nil {
+		return err
+	}
+	discoveryBackend = backend
+	return nil
+}
+
+// DiscoverNetworkDevices lists network devices with the selected backend
+func DiscoverNetworkDevices() ([]Device, error) {
+	devices, err := discoveryBackend.Discover()
+	if err != nil {
+		return nil, fmt.Errorf("%s discovery failed: %v", discoveryBackend.Name(), err)
+	}
+	return devices, nil
+}
@@ -0,0 +1,365 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SysfsDiscovery discovers devices from <sysfs root>/class/net, PCI sysfs and
+// ethtool ioctls, producing the same fields as the lshw backend
+type SysfsDiscovery struct{}
+
+// Name returns the backend name
+func (SysfsDiscovery) Name() string { return DiscoverySysfs }
+
+const (
+	arphrdEther      = 1
+	arphrdInfiniband = 32
+	iffUp            = 0x1
+	iffBroadcast     = 0x2
+	iffMulticast     = 0x1000
+)
+
+// representorPortName matches phys_port_name of VF and SF representors
+var representorPortName = regexp.MustCompile(`^(c\d+)?pf\d+(vf|sf)\d+$`)
+
+// interfaceIPv4 returns the first IPv4 address of an interface, or ""
+var interfaceIPv4 = lookupInterfaceIPv4
+
+// pciCapabilityNames maps PCI capability IDs to the names lshw reports
+var pciCapabilityNames = map[byte]string{
+	0x01: "pm",
+	0x03: "vpd",
+	0x05: "msi",
+	0x07: "pcix",
+	0x10: "pciexpress",
+	0x11: "msix",
+}
+
+// linkModeCapabilities maps legacy ethtool SUPPORTED_* bits to lshw capabilities
+var linkModeCapabilities = []struct {
+	bit  uint
+	name string
+}{
+	{0, "10bt"},
+	{1, "10bt-fd"},
+	{2, "100bt"},
+	{3, "100bt-fd"},
+	{4, "1000bt"},
+	{5, "1000bt-fd"},
+	{7, "tp"},
+	{8, "aui"},
+	{9, "mii"},
+	{10, "fibre"},
+	{11, "bnc"},
+	{12, "10000bt-fd"},
+	{15, "2500bt-fd"},
+	{17, "1000bt-fd"},
+	{18, "10000bt-fd"},
+	{19, "10000bt-fd"},
+	{21, "20000bt-fd"},
+	{22, "20000bt-fd"},
+	{23, "40000bt-fd"},
+	{24, "40000bt-fd"},
+	{25, "40000bt-fd"},
+	{26, "40000bt-fd"},
+	{27, "56000bt-fd"},
+	{28, "56000bt-fd"},
+	{29, "56000bt-fd"},
+	{30, "56000bt-fd"},
+	{31, "25000bt-fd"},
+	{6, "autonegotiation"},
+}
+
+// portNames maps ethtool PORT_* values to lshw port names
+var portNames = map[uint8]string{
+	0: "twisted pair",
+	1: "aui",
+	2: "mii",
+	3: "fibre",
+	4: "bnc",
+	5: "direct attach",
+}
+
+// Discover lists PCI network interfaces and network functions without a netdev
+func (SysfsDiscovery) Discover() ([]Device, error) {
+	vendorDB, err := loadVendorDatabase()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vendor database: %v", err)
+	}
+
+	entries, err := os.ReadDir(NetClassPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", NetClassPath(), err)
+	}
+
+	var devices []Device
+	claimed := make(map[string]bool)
+	for _, entry := range entries {
+		device, ok := discoverNetdev(entry.Name(), vendorDB)
+		if !ok {
+			continue
+		}
+		claimed[device.PCIAddress] = true
+		devices = append(devices, device)
+	}
+
+	// Network functions without a netdev, e.g. VFs bound to vfio-pci
+	if pciEntries, err := os.ReadDir(PciDevicesPath()); err == nil {
+		for _, entry := range pciEntries {
+			if claimed[entry.Name()] || !isPciAddress(entry.Name()) {
+				continue
+			}
+			if device, ok := discoverPciFunction(entry.Name(), vendorDB); ok {
+				devices = append(devices, device)
+			}
+		}
+	}
+
+	sort.SliceStable(devices, func(i, j int) bool {
+		if devices[i].PCIAddress != devices[j].PCIAddress {
+			return devices[i].PCIAddress < devices[j].PCIAddress
+		}
+		return devices[i].Name < devices[j].Name
+	})
+	return devices, nil
+}
+
+// discoverNetdev builds a Device for a PCI backed interface
+func discoverNetdev(name string, vendorDB *VendorDatabase) (Device, bool) {
+	netPath := filepath.Join(NetClassPath(), name)
+	devicePath := filepath.Join(netPath, "device")
+
+	// Virtual interfaces have no device link
+	target, err := os.Readlink(devicePath)
+	if err != nil {
+		return Device{}, false
+	}
+	if subsystem, err := os.Readlink(filepath.Join(devicePath, "subsystem")); err != nil || filepath.Base(subsystem) != "pci" {
+		return Device{}, false
+	}
+	pciAddress := filepath.Base(target)
+
+	device := Device{
+		PCIAddress:    pciAddress,
+		Name:          name,
+		LogicalName:   name,
+		BusInfo:       "pci@" + pciAddress,
+		Class:         "network",
+		Serial:        readSysfsAttr(netPath, "address"),
+		Configuration: make(map[string]interface{}),
+	}
+
+	arpType, _ := strconv.Atoi(readSysfsAttr(netPath, "type"))
+	switch arpType {
+	case arphrdEther:
+		device.Description = "Ethernet interface"
+		device.Capabilities = append(device.Capabilities, "ethernet")
+	case arphrdInfiniband:
+		device.Description = "InfiniBand interface"
+		device.Capabilities = append(device.Capabilities, "infiniband")
+	default:
+		device.Description = "Network interface"
+	}
+	if arpType < 256 {
+		device.Capabilities = append(device.Capabilities, "physical")
+	} else {
+		device.Capabilities = append(device.Capabilities, "logical")
+	}
+
+	// Representors share the PF's PCI function but are not the PCI device
+	if !representorPortName.MatchString(readSysfsAttr(netPath, "phys_port_name")) {
+		attachPciFunctionInfo(&device, PciDevicePath(pciAddress), vendorDB)
+	}
+
+	if info, err := GetEthtoolDriverInfo(name); err == nil && info.Driver != "" {
+		device.Configuration["driver"] = info.Driver
+		if info.Version != "" {
+			device.Configuration["driverversion"] = info.Version
+		}
+		if info.FirmwareVersion != "" && info.FirmwareVersion != "N/A" {
+			device.Configuration["firmware"] = info.FirmwareVersion
+		}
+	} else if driver := readSysfsLink(devicePath, "driver"); driver != "" {
+		device.Configuration["driver"] = driver
+	}
+
+	if settings, err := ioctlEthtoolLinkSettings(name); err == nil {
+		attachLinkSettings(&device, settings)
+	}
+
+	if readSysfsAttr(netPath, "carrier") == "1" {
+		device.Configuration["link"] = "yes"
+	} else {
+		device.Configuration["link"] = "no"
+	}
+	if flags, err := strconv.ParseUint(readSysfsAttr(netPath, "flags"), 0, 32); err == nil {
+		if flags&iffBroadcast != 0 {
+			device.Configuration["broadcast"] = "yes"
+		}
+		if flags&iffMulticast != 0 {
+			device.Configuration["multicast"] = "yes"
+		}
+	}
+	if ip := interfaceIPv4(name); ip != "" {
+		device.Configuration["ip"] = ip
+	}
+
+	device.Driver, _ = device.Configuration["driver"].(string)
+	return device, true
+}
+
+// discoverPciFunction builds a Device for a network class PCI function without a netdev
+func discoverPciFunction(pciAddress string, vendorDB *VendorDatabase) (Device, bool) {
+	devicePath := PciDevicePath(pciAddress)
+	class := strings.TrimPrefix(readSysfsAttr(devicePath, "class"), "0x")
+	if !strings.HasPrefix(class, "02") || len(class) < 4 {
+		return Device{}, false
+	}
+
+	device := Device{
+		PCIAddress:    pciAddress,
+		BusInfo:       "pci@" + pciAddress,
+		Class:         "network",
+		Configuration: make(map[string]interface{}),
+	}
+	switch class[2:4] {
+	case "00":
+		device.Description = "Ethernet controller"
+	case "07":
+		device.Description = "Infiniband controller"
+	default:
+		device.Description = "Network controller"
+	}
+
+	attachPciFunctionInfo(&device, devicePath, vendorDB)
+	if driver := readSysfsLink(devicePath, "driver"); driver != "" {
+		device.Configuration["driver"] = driver
+		device.Driver = driver
+	}
+	return device, true
+}
+
+// attachPciFunctionInfo adds vendor, product, latency and PCI capabilities
+func attachPciFunctionInfo(device *Device, devicePath string, vendorDB *VendorDatabase) {
+	vendorID := normalizePciID(readSysfsAttr(devicePath, "vendor"))
+	deviceID := normalizePciID(readSysfsAttr(devicePath, "device"))
+	if vendor, ok := vendorDB.Vendors[vendorID]; ok {
+		device.Vendor = vendor.Name
+		if dev, ok := vendor.Devices[deviceID]; ok {
+			device.Product = dev.Name
+		}
+	}
+
+	config, err := os.ReadFile(filepath.Join(devicePath, "config"))
+	if err == nil && len(config) >= 64 {
+		device.Configuration["latency"] = strconv.Itoa(int(config[0x0d]))
+		device.Capabilities = append(device.Capabilities, pciConfigCapabilities(config)...)
+	}
+	if _, err := os.Stat(filepath.Join(devicePath, "rom")); err == nil {
+		device.Capabilities = append(device.Capabilities, "rom")
+	}
+}
+
+// pciConfigCapabilities walks the capability list of a PCI configuration space
+func pciConfigCapabilities(config []byte) []string {
+	var caps []string
+	command := uint16(config[0x04]) | uint16(config[0x05])<<8
+	status := uint16(config[0x06]) | uint16(config[0x07])<<8
+
+	if status&0x10 != 0 {
+		seen := make(map[byte]bool)
+		for ptr := config[0x34] &^ 0x3; ptr >= 0x40 && int(ptr)+1 < len(config) && !seen[ptr]; ptr = config[ptr+1] &^ 0x3 {
+			seen[ptr] = true
+			if name, ok := pciCapabilityNames[config[ptr]]; ok {
+				caps = append(caps, name)
+			}
+		}
+	}
+	if command&0x4 != 0 {
+		caps = append(caps, "bus_master")
+	}
+	if status&0x10 != 0 {
+		caps = append(caps, "cap_list")
+	}
+	return caps
+}
+
+// attachLinkSettings adds port, speed, duplex, autonegotiation and supported link modes
+func attachLinkSettings(device *Device, settings *ethtoolLinkSettings) {
+	seen := make(map[string]bool)
+	for _, mode := range linkModeCapabilities {
+		if settings.Supported&(1<<mode.bit) != 0 && !seen[mode.name] {
+			seen[mode.name] = true
+			device.Capabilities = append(device.Capabilities, mode.name)
+		}
+	}
+
+	if port, ok := portNames[settings.Port]; ok {
+		device.Configuration["port"] = port
+	}
+	if settings.Autoneg == 0 {
+		device.Configuration["autonegotiation"] = "off"
+	} else {
+		device.Configuration["autonegotiation"] = "on"
+	}
+	switch settings.Duplex {
+	case 0:
+		device.Configuration["duplex"] = "half"
+	case 1:
+		device.Configuration["duplex"] = "full"
+	}
+	if settings.Speed != 0 {
+		device.Configuration["speed"] = formatLinkSpeed(settings.Speed)
+	}
+}
+
+// formatLinkSpeed formats a speed in Mb/s the way lshw does, e.g. 1Gbit/s
+func formatLinkSpeed(mbps uint32) string {
+	if mbps%1000 == 0 {
+		return fmt.Sprintf("%dGbit/s", mbps/1000)
+	}
+	return fmt.Sprintf("%dMbit/s", mbps)
+}
+
+// lookupInterfaceIPv4 returns the first IPv4 address assigned to an interface
+func lookupInterfaceIPv4(name string) string {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return ""
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return ""
+}
+
+// readSysfsAttr reads a sysfs attribute, returning "" if it cannot be read
+func readSysfsAttr(dir, name string) string {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsLink returns the base name of a sysfs symlink target, or ""
+func readSysfsLink(dir, name string) string {
+	target, err := os.Readlink(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
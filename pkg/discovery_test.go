@@ -0,0 +1,305 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// samplePciIDs is a pci.ids excerpt covering the devices in the lshw sample
+const samplePciIDs = `# pci.ids excerpt
+8086  Intel Corporation
+	1521  I350 Gigabit Network Connection
+		8086 0001  Ethernet Server Adapter I350-T4
+15b3  Mellanox Technologies
+	101e  ConnectX Family mlx5Gen Virtual Function
+	1021  MT2910 Family [ConnectX-7]
+1dd8  Pensando Systems
+	1002  DSC Ethernet Controller
+C 02  Network controller
+	00  Ethernet controller
+`
+
+// sampleProductIDs maps lshw product names to vendor and device IDs
+var sampleProductIDs = map[string][2]string{
+	"DSC Ethernet Controller":                  {"1dd8", "1002"},
+	"MT2910 Family [ConnectX-7]":               {"15b3", "1021"},
+	"ConnectX Family mlx5Gen Virtual Function": {"15b3", "101e"},
+	"I350 Gigabit Network Connection":          {"8086", "1521"},
+}
+
+// TestLoadVendorDatabase tests parsing of vendor and device names from pci.ids
+func TestLoadVendorDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pci.ids")
+	if err := os.WriteFile(path, []byte(samplePciIDs), 0644); err != nil {
+		t.Fatalf("failed to write pci.ids: %v", err)
+	}
+	original := pciIDsPaths
+	pciIDsPaths = []string{path}
+	defer func() { pciIDsPaths = original }()
+
+	db, err := loadVendorDatabase()
+	if err != nil {
+		t.Fatalf("loadVendorDatabase failed: %v", err)
+	}
+	if len(db.Vendors) != 3 {
+		t.Errorf("expected 3 vendors, got %d: %+v", len(db.Vendors), db.Vendors)
+	}
+	if db.Vendors["15b3"].Name != "Mellanox Technologies" {
+		t.Errorf("unexpected vendor name: %q", db.Vendors["15b3"].Name)
+	}
+	if db.Vendors["15b3"].Devices["1021"].Name != "MT2910 Family [ConnectX-7]" {
+		t.Errorf("unexpected device name: %q", db.Vendors["15b3"].Devices["1021"].Name)
+	}
+	if len(db.Vendors["8086"].Devices) != 1 {
+		t.Errorf("subsystem lines must not be parsed as devices: %+v", db.Vendors["8086"].Devices)
+	}
+}
+
+// sampleInterface is the fixture state derived from one lshw entry
+type sampleInterface struct {
+	conf map[string]any
+	caps map[string]bool
+}
+
+// buildSampleSysfs creates a sysfs tree and ethtool responses matching the lshw sample
+func buildSampleSysfs(t *testing.T, root string, raw []map[string]any) map[string]sampleInterface {
+	t.Helper()
+	interfaces := make(map[string]sampleInterface)
+	pciBus := filepath.Join(root, "bus", "pci")
+
+	for _, item := range raw {
+		name, _ := item["logicalname"].(string)
+		businfo, _ := item["businfo"].(string)
+		pciAddress := strings.TrimPrefix(businfo, "pci@")
+		conf, _ := item["configuration"].(map[string]any)
+		caps := make(map[string]bool)
+		if m, ok := item["capabilities"].(map[string]any); ok {
+			for c := range m {
+				caps[c] = true
+			}
+		}
+		interfaces[name] = sampleInterface{conf: conf, caps: caps}
+
+		pfDir := filepath.Join(pciBus, "devices", pciAddress)
+		if product, ok := item["product"].(string); ok {
+			ids := sampleProductIDs[product]
+			writeSysfsFiles(t, pfDir, map[string]string{
+				"vendor": "0x" + ids[0] + "\n",
+				"device": "0x" + ids[1] + "\n",
+				"class":  "0x020000\n",
+				"config": string(samplePciConfig(caps)),
+			})
+			if caps["rom"] {
+				writeSysfsFiles(t, pfDir, map[string]string{"rom": ""})
+			}
+			driverDir := filepath.Join(pciBus, "drivers", conf["driver"].(string))
+			os.MkdirAll(driverDir, 0755)
+			os.Symlink(driverDir, filepath.Join(pfDir, "driver"))
+			os.Symlink(pciBus, filepath.Join(pfDir, "subsystem"))
+		}
+
+		carrier := "0"
+		if conf["link"] == "yes" {
+			carrier = "1"
+		}
+		netDir := filepath.Join(root, "class", "net", name)
+		files := map[string]string{
+			"address": item["serial"].(string) + "\n",
+			"type":    "1\n",
+			"carrier": carrier + "\n",
+			"flags":   "0x1003\n",
+		}
+		if i := strings.Index(name, "npf"); i > 0 {
+			files["phys_port_name"] = name[i+1:] + "\n"
+		}
+		writeSysfsFiles(t, netDir, files)
+		if err := os.Symlink(pfDir, filepath.Join(netDir, "device")); err != nil {
+			t.Fatalf("failed to link device: %v", err)
+		}
+	}
+	return interfaces
+}
+
+// samplePciConfig builds a configuration space with the PCI capabilities lshw reported
+func samplePciConfig(caps map[string]bool) []byte {
+	config := make([]byte, 256)
+	if caps["bus_master"] {
+		config[0x04] = 0x4
+	}
+	if !caps["cap_list"] {
+		return config
+	}
+	config[0x06] = 0x10
+
+	ptr := byte(0x40)
+	last := byte(0x34)
+	var ids []int
+	for id, name := range pciCapabilityNames {
+		if caps[name] {
+			ids = append(ids, int(id))
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		config[last] = ptr
+		config[ptr] = byte(id)
+		last = ptr + 1
+		ptr += 0x10
+	}
+	return config
+}
+
+// fakeSampleEthtool answers GDRVINFO and GSET from the lshw sample
+func fakeSampleEthtool(interfaces map[string]sampleInterface) func(string, []byte) error {
+	ports := make(map[string]uint8)
+	for value, name := range portNames {
+		ports[name] = value
+	}
+	modes := make(map[string]uint)
+	for _, mode := range linkModeCapabilities {
+		if _, ok := modes[mode.name]; !ok {
+			modes[mode.name] = mode.bit
+		}
+	}
+
+	return func(ifname string, data []byte) error {
+		sample, ok := interfaces[ifname]
+		if !ok {
+			return unix.ENODEV
+		}
+		conf := func(key string) string {
+			value, _ := sample.conf[key].(string)
+			return value
+		}
+
+		switch binary.NativeEndian.Uint32(data[0:4]) {
+		case unix.ETHTOOL_GDRVINFO:
+			copy(data[4:36], conf("driver"))
+			copy(data[36:68], conf("driverversion"))
+			copy(data[68:100], conf("firmware"))
+		case unix.ETHTOOL_GSET:
+			var supported uint32
+			for c := range sample.caps {
+				if bit, ok := modes[c]; ok {
+					supported |= 1 << bit
+				}
+			}
+			binary.NativeEndian.PutUint32(data[4:8], supported)
+
+			speed := uint32(0xffffffff)
+			if s := conf("speed"); s != "" {
+				n, _ := strconv.Atoi(strings.TrimSuffix(s, "Gbit/s"))
+				speed = uint32(n) * 1000
+			}
+			binary.NativeEndian.PutUint16(data[12:14], uint16(speed))
+			binary.NativeEndian.PutUint16(data[28:30], uint16(speed>>16))
+
+			data[14] = 0xff
+			if conf("duplex") == "full" {
+				data[14] = 1
+			}
+			data[15] = ports[conf("port")]
+			if conf("autonegotiation") == "on" {
+				data[18] = 1
+			}
+		default:
+			return unix.EOPNOTSUPP
+		}
+		return nil
+	}
+}
+
+// TestSysfsDiscoveryMatchesLshw tests that the sysfs backend reproduces the lshw sample
+func TestSysfsDiscoveryMatchesLshw(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "sample-outputs", "lshw-network.json"))
+	if err != nil {
+		t.Fatalf("failed to read lshw sample: %v", err)
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("failed to decode lshw sample: %v", err)
+	}
+	expected, err := parseLshwData(raw)
+	if err != nil {
+		t.Fatalf("parseLshwData failed: %v", err)
+	}
+
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	interfaces := buildSampleSysfs(t, root, raw)
+
+	idsPath := filepath.Join(t.TempDir(), "pci.ids")
+	if err := os.WriteFile(idsPath, []byte(samplePciIDs), 0644); err != nil {
+		t.Fatalf("failed to write pci.ids: %v", err)
+	}
+	originalPaths, originalIoctl, originalIPv4 := pciIDsPaths, ethtoolIoctl, interfaceIPv4
+	pciIDsPaths = []string{idsPath}
+	ethtoolIoctl = fakeSampleEthtool(interfaces)
+	interfaceIPv4 = func(name string) string {
+		ip, _ := interfaces[name].conf["ip"].(string)
+		return ip
+	}
+	defer func() { pciIDsPaths, ethtoolIoctl, interfaceIPv4 = originalPaths, originalIoctl, originalIPv4 }()
+
+	backend, err := NewDiscoveryBackend(DiscoverySysfs)
+	if err != nil {
+		t.Fatalf("NewDiscoveryBackend failed: %v", err)
+	}
+	devices, err := backend.Discover()
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	if len(devices) != len(expected) {
+		t.Fatalf("expected %d devices, got %d", len(expected), len(devices))
+	}
+
+	found := make(map[string]Device)
+	for _, d := range devices {
+		found[d.LogicalName] = d
+	}
+	for _, want := range expected {
+		got, ok := found[want.LogicalName]
+		if !ok {
+			t.Errorf("%s: not discovered", want.LogicalName)
+			continue
+		}
+		sort.Strings(got.Capabilities)
+		sort.Strings(want.Capabilities)
+		for _, d := range []*Device{&got, &want} {
+			if len(d.Configuration) == 0 {
+				d.Configuration = nil
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: mismatch\n got: %+v\nwant: %+v", want.LogicalName, got, want)
+		}
+	}
+}
+
+// TestSetDiscoveryBackend tests backend selection by name
+func TestSetDiscoveryBackend(t *testing.T) {
+	defer SetDiscoveryBackend(DefaultDiscovery)
+
+	if err := SetDiscoveryBackend("LSHW"); err != nil {
+		t.Fatalf("SetDiscoveryBackend(lshw) failed: %v", err)
+	}
+	if discoveryBackend.Name() != DiscoveryLshw {
+		t.Errorf("expected lshw backend, got %s", discoveryBackend.Name())
+	}
+	if err := SetDiscoveryBackend("udev"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+	if discoveryBackend.Name() != DiscoveryLshw {
+		t.Errorf("failed selection must keep the previous backend, got %s", discoveryBackend.Name())
+	}
+}
@@ -0,0 +1,169 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// clearDriverOverride is written to driver_override to remove an override;
+// the kernel ignores empty writes
+const clearDriverOverride = "\n"
+
+// BindDriver binds a PCI device to a kernel driver. driver_override is set
+// first so the reprobe after unbinding can only pick the requested driver.
+// If the device does not end up bound to it, the previous override and
+// driver are restored.
+func (m *SRIOVManager) BindDriver(pciAddress, driver string) error {
+	devicePath := PciDevicePath(pciAddress)
+	if _, err := os.Stat(devicePath); err != nil {
+		return fmt.Errorf("PCI device %s not found: %v", pciAddress, err)
+	}
+	if _, err := os.Stat(filepath.Join(PciDriversPath(), driver)); err != nil {
+		return fmt.Errorf("driver %s is not loaded: %v", driver, err)
+	}
+
+	overridePath := filepath.Join(devicePath, "driver_override")
+	previousOverride, err := m.readSysfsValue(overridePath)
+	if err != nil {
+		return fmt.Errorf("failed to read driver_override: %v", err)
+	}
+	if previousOverride == "(null)" {
+		previousOverride = ""
+	}
+	previousDriver := BoundDriver(pciAddress)
+	if previousDriver == driver && previousOverride == driver {
+		Debug("%s already bound to %s", pciAddress, driver)
+		return nil
+	}
+
+	fields := logrus.Fields{
+		"pci":             pciAddress,
+		"previous_driver": previousDriver,
+		"driver":          driver,
+	}
+	WithFields(fields).Info("Binding device to driver")
+
+	if err := m.writeSysfs(fmt.Sprintf("set driver_override on %s", pciAddress), overridePath, driver); err != nil {
+		return fmt.Errorf("failed to set driver_override on %s: %v", pciAddress, err)
+	}
+	if previousDriver == driver {
+		return nil
+	}
+
+	if previousDriver != "" {
+		unbindPath := filepath.Join(PciDriversPath(), previousDriver, "unbind")
+		if err := m.writeSysfs(fmt.Sprintf("unbind %s from %s", pciAddress, previousDriver), unbindPath, pciAddress); err != nil {
+			m.rollbackBind(pciAddress, previousOverride, previousDriver)
+			return fmt.Errorf("failed to unbind %s from %s: %v", pciAddress, previousDriver, err)
+		}
+	}
+
+	probePath := SysfsPath("bus", "pci", "drivers_probe")
+	if err := m.writeSysfs(fmt.Sprintf("probe %s", pciAddress), probePath, pciAddress); err != nil {
+		m.rollbackBind(pciAddress, previousOverride, previousDriver)
+		return fmt.Errorf("failed to probe %s: %v", pciAddress, err)
+	}
+	if m.Config().DryRun {
+		return nil
+	}
+
+	// Probing can leave the device unbound, e.g. when the driver was loaded
+	// after the override was set, so fall back to an explicit bind
+	if BoundDriver(pciAddress) == "" {
+		bindPath := filepath.Join(PciDriversPath(), driver, "bind")
+		if err := m.writeSysfs(fmt.Sprintf("bind %s to %s", pciAddress, driver), bindPath, pciAddress); err != nil {
+			WithFields(fields).WithError(err).Debug("Explicit bind failed")
+		}
+	}
+
+	if bound := BoundDriver(pciAddress); bound != driver {
+		m.rollbackBind(pciAddress, previousOverride, previousDriver)
+		if bound == "" {
+			return fmt.Errorf("%s did not bind to %s", pciAddress, driver)
+		}
+		return fmt.Errorf("%s bound to %s instead of %s", pciAddress, bound, driver)
+	}
+
+	WithFields(fields).Info("Device bound to driver")
+	return nil
+}
+
+// rollbackBind restores the driver_override and driver a device had before a
+// failed BindDriver. Failures are logged since the bind error is what matters.
+func (m *SRIOVManager) rollbackBind(pciAddress, override, driver string) {
+	fields := logrus.Fields{"pci": pciAddress, "driver": driver}
+	WithFields(fields).Warn("Rolling back driver binding")
+
+	if override == "" {
+		override = clearDriverOverride
+	}
+	overridePath := filepath.Join(PciDevicePath(pciAddress), "driver_override")
+	if err := m.writeSysfs(fmt.Sprintf("restore driver_override on %s", pciAddress), overridePath, override); err != nil {
+		WithFields(fields).WithError(err).Warn("Failed to restore driver_override")
+	}
+
+	bound := BoundDriver(pciAddress)
+	if bound == driver {
+		return
+	}
+	if bound != "" {
+		unbindPath := filepath.Join(PciDriversPath(), bound, "unbind")
+		if err := m.writeSysfs(fmt.Sprintf("unbind %s from %s", pciAddress, bound), unbindPath, pciAddress); err != nil {
+			WithFields(fields).WithError(err).Warn("Failed to unbind device during rollback")
+			return
+		}
+	}
+	if driver == "" {
+		return
+	}
+
+	probePath := SysfsPath("bus", "pci", "drivers_probe")
+	if err := m.writeSysfs(fmt.Sprintf("probe %s", pciAddress), probePath, pciAddress); err != nil {
+		WithFields(fields).WithError(err).Warn("Failed to reprobe device during rollback")
+	}
+	if BoundDriver(pciAddress) != driver {
+		bindPath := filepath.Join(PciDriversPath(), driver, "bind")
+		if err := m.writeSysfs(fmt.Sprintf("bind %s to %s", pciAddress, driver), bindPath, pciAddress); err != nil {
+			WithFields(fields).WithError(err).Warn("Failed to rebind device during rollback")
+		}
+	}
+}
+
+// bindVFDrivers binds every VF of a PF to driver and returns a description of
+// each VF that was rebound
+func (m *SRIOVManager) bindVFDrivers(device Device, driver string) ([]string, error) {
+	vfs, err := parseVirtualFunctions(PciDevicePath(device.PCIAddress))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list VFs of %s: %v", device.Name, err)
+	}
+
+	var actions []string
+	for _, vf := range vfs {
+		if vf.Driver == driver {
+			continue
+		}
+		if err := m.BindDriver(vf.PCIAddress, driver); err != nil {
+			return actions, fmt.Errorf("failed to bind vf%d (%s) to %s: %v", vf.Index, vf.PCIAddress, driver, err)
+		}
+		actions = append(actions, fmt.Sprintf("bind vf%d to %s", vf.Index, driver))
+	}
+	return actions, nil
+}
+
+// BoundDriver returns the driver a PCI device is bound to, or an empty string
+func BoundDriver(pciAddress string) string {
+	target, err := os.Readlink(filepath.Join(PciDevicePath(pciAddress), "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// PhysFnAddress returns the PF address of a VF, or an empty string if the
+// device is not a VF
+func PhysFnAddress(pciAddress string) string {
+	return parsePhysFn(PciDevicePath(pciAddress))
+}
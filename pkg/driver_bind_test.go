@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakePciBus emulates the kernel side of driver_override, unbind, bind and
+// drivers_probe on a temporary sysfs tree
+type fakePciBus struct {
+	t    *testing.T
+	root string
+	// refuse lists drivers whose probe fails
+	refuse map[string]bool
+	writes []string
+}
+
+// newFakePciBus creates a sysfs tree with the given drivers loaded and one VF
+// bound to mlx5_core, and routes sysfs writes through the emulation
+func newFakePciBus(t *testing.T, drivers ...string) *fakePciBus {
+	bus := &fakePciBus{t: t, root: t.TempDir(), refuse: make(map[string]bool)}
+	SetSysfsRoot(bus.root)
+	t.Cleanup(func() { SetSysfsRoot("") })
+
+	for _, driver := range drivers {
+		writeSysfsFiles(t, filepath.Join(bus.root, "bus", "pci", "drivers", driver), map[string]string{"bind": "", "unbind": ""})
+	}
+	writeSysfsFiles(t, filepath.Join(bus.root, "bus", "pci"), map[string]string{"drivers_probe": ""})
+	writeSysfsFiles(t, PciDevicePath("0000:31:00.0"), map[string]string{"sriov_numvfs": "1"})
+	writeSysfsFiles(t, PciDevicePath("0000:31:00.2"), map[string]string{"driver_override": "(null)\n"})
+	if err := os.Symlink("../0000:31:00.2", filepath.Join(PciDevicePath("0000:31:00.0"), "virtfn0")); err != nil {
+		t.Fatalf("failed to link virtfn0: %v", err)
+	}
+	if err := os.Symlink("../0000:31:00.0", filepath.Join(PciDevicePath("0000:31:00.2"), "physfn")); err != nil {
+		t.Fatalf("failed to link physfn: %v", err)
+	}
+	bus.link("0000:31:00.2", "mlx5_core")
+
+	original := writeSysfsFile
+	writeSysfsFile = bus.write
+	t.Cleanup(func() { writeSysfsFile = original })
+	return bus
+}
+
+// link binds a device to a driver by pointing its driver symlink at it
+func (b *fakePciBus) link(pciAddress, driver string) {
+	link := filepath.Join(PciDevicePath(pciAddress), "driver")
+	os.Remove(link)
+	if driver == "" {
+		return
+	}
+	if err := os.Symlink(filepath.Join("..", "..", "drivers", driver), link); err != nil {
+		b.t.Fatalf("failed to link driver: %v", err)
+	}
+}
+
+// probe binds a device to its override driver, as drivers_probe and bind do
+func (b *fakePciBus) probe(pciAddress, driver string) {
+	override, _ := os.ReadFile(filepath.Join(PciDevicePath(pciAddress), "driver_override"))
+	if value := strings.TrimSpace(string(override)); value != "(null)" {
+		driver = value
+	}
+	if driver != "" && !b.refuse[driver] && BoundDriver(pciAddress) == "" {
+		b.link(pciAddress, driver)
+	}
+}
+
+func (b *fakePciBus) write(path string, data []byte, perm os.FileMode) error {
+	rel, _ := filepath.Rel(b.root, path)
+	value := string(data)
+	b.writes = append(b.writes, rel+"="+strings.TrimSpace(value))
+
+	switch {
+	case filepath.Base(path) == "driver_override":
+		if strings.TrimSpace(value) == "" {
+			value = "(null)"
+		}
+		return os.WriteFile(path, []byte(value+"\n"), perm)
+	case filepath.Base(path) == "unbind":
+		b.link(value, "")
+	case filepath.Base(path) == "bind":
+		b.probe(value, filepath.Base(filepath.Dir(path)))
+	case filepath.Base(path) == "drivers_probe":
+		// Without an override the device goes back to its native driver
+		b.probe(value, "mlx5_core")
+	}
+	return nil
+}
+
+// TestBindDriver tests the write ordering of a successful rebind
+func TestBindDriver(t *testing.T) {
+	bus := newFakePciBus(t, "mlx5_core", "vfio-pci")
+	manager := NewSRIOVManager(&SRIOVConfig{})
+
+	if err := manager.BindDriver("0000:31:00.2", "vfio-pci"); err != nil {
+		t.Fatalf("BindDriver failed: %v", err)
+	}
+	if driver := BoundDriver("0000:31:00.2"); driver != "vfio-pci" {
+		t.Errorf("expected vfio-pci, got %q", driver)
+	}
+
+	expected := []string{
+		"bus/pci/devices/0000:31:00.2/driver_override=vfio-pci",
+		"bus/pci/drivers/mlx5_core/unbind=0000:31:00.2",
+		"bus/pci/drivers_probe=0000:31:00.2",
+	}
+	if !reflect.DeepEqual(bus.writes, expected) {
+		t.Errorf("expected writes %v, got %v", expected, bus.writes)
+	}
+
+	// Binding again is a no-op
+	bus.writes = nil
+	if err := manager.BindDriver("0000:31:00.2", "vfio-pci"); err != nil {
+		t.Fatalf("BindDriver failed: %v", err)
+	}
+	if len(bus.writes) != 0 {
+		t.Errorf("expected no writes for an already bound device, got %v", bus.writes)
+	}
+
+	if err := manager.BindDriver("0000:31:00.2", "igb_uio"); err == nil {
+		t.Error("expected an error for a driver that is not loaded")
+	}
+}
+
+// TestBindDriverRollback tests that a failed bind restores the previous
+// override and driver
+func TestBindDriverRollback(t *testing.T) {
+	bus := newFakePciBus(t, "mlx5_core", "vfio-pci")
+	bus.refuse["vfio-pci"] = true
+	manager := NewSRIOVManager(&SRIOVConfig{})
+
+	if err := manager.BindDriver("0000:31:00.2", "vfio-pci"); err == nil {
+		t.Fatal("expected BindDriver to fail")
+	}
+	if driver := BoundDriver("0000:31:00.2"); driver != "mlx5_core" {
+		t.Errorf("expected rollback to mlx5_core, got %q", driver)
+	}
+	override, _ := os.ReadFile(filepath.Join(PciDevicePath("0000:31:00.2"), "driver_override"))
+	if strings.TrimSpace(string(override)) != "(null)" {
+		t.Errorf("expected driver_override to be cleared, got %q", string(override))
+	}
+}
+
+// TestPolicyVFDriver tests that reconcile binds a policy's VFs and plans the
+// binding in dry-run mode
+func TestPolicyVFDriver(t *testing.T) {
+	bus := newFakePciBus(t, "mlx5_core", "vfio-pci")
+	policies := []DevicePolicy{{VendorID: "15b3", DeviceID: "101e", NumVFs: 1, VFDriver: "vfio-pci"}}
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	dryRun := NewSRIOVManager(&SRIOVConfig{DryRun: true, DevicePolicies: policies})
+	status := dryRun.reconcileDevice(device)
+	if status.State != ReconcilePlanned {
+		t.Errorf("expected planned, got %s (%s)", status.State, status.Message)
+	}
+	if len(dryRun.Plan().Actions) != 3 || len(bus.writes) != 0 {
+		t.Errorf("expected 3 planned actions and no writes, got %+v and %v", dryRun.Plan().Actions, bus.writes)
+	}
+
+	manager := NewSRIOVManager(&SRIOVConfig{DevicePolicies: policies})
+	status = manager.reconcileDevice(device)
+	if status.State != ReconcileApplied || !reflect.DeepEqual(status.Actions, []string{"bind vf0 to vfio-pci"}) {
+		t.Errorf("expected applied with a vf0 binding, got %s %v (%s)", status.State, status.Actions, status.Message)
+	}
+	if driver := BoundDriver("0000:31:00.2"); driver != "vfio-pci" {
+		t.Errorf("expected vfio-pci, got %q", driver)
+	}
+
+	if status := manager.reconcileDevice(device); status.State != ReconcileInSync {
+		t.Errorf("expected in-sync after binding, got %s %v", status.State, status.Actions)
+	}
+}
@@ -2,8 +2,6 @@ package pkg
 
 import (
 	"fmt"
-	"os/exec"
-	"strings"
 )
 
 // EthtoolFeatureInfo represents a single feature
@@ -44,26 +42,36 @@ type EthtoolInfo struct {
 	Channels EthtoolChannelInfo
 }
 
-// GetEthtoolInfo retrieves comprehensive ethtool information for a network interface
+// GetEthtoolInfo retrieves comprehensive ethtool information for a network interface.
+// It uses the ethtool generic netlink family and falls back to the SIOCETHTOOL
+// ioctl on kernels without it, so the ethtool binary is not required.
 func GetEthtoolInfo(ifname string) (*EthtoolInfo, error) {
 	info := &EthtoolInfo{}
 
+	nl, err := newEthtoolNetlink()
+	if err != nil {
+		WithField("interface", ifname).WithError(err).Debug("ethtool netlink unavailable, using ioctl")
+		nl = nil
+	} else {
+		defer nl.Close()
+	}
+
 	// Get features
-	features, err := getEthtoolFeatures(ifname)
+	features, err := getEthtoolFeatures(nl, ifname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get features: %v", err)
 	}
 	info.Features = features
 
 	// Get ring parameters
-	ring, err := getEthtoolRingParam(ifname)
+	ring, err := getEthtoolRingParam(nl, ifname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ring parameters: %v", err)
 	}
 	info.Ring = *ring
 
 	// Get channel parameters
-	channels, err := getEthtoolChannels(ifname)
+	channels, err := getEthtoolChannels(nl, ifname)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get channel parameters: %v", err)
 	}
@@ -73,175 +81,39 @@ func GetEthtoolInfo(ifname string) (*EthtoolInfo, error) {
 }
 
 // getEthtoolFeatures retrieves ethtool features for a network interface
-func getEthtoolFeatures(ifname string) ([]EthtoolFeatureInfo, error) {
-	cmd := exec.Command("ethtool", "-k", ifname)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run ethtool -k: %v", err)
-	}
-
-	features := []EthtoolFeatureInfo{}
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "Features for") {
-			continue
+func getEthtoolFeatures(nl *ethtoolNetlink, ifname string) ([]EthtoolFeatureInfo, error) {
+	if nl != nil {
+		features, err := nl.Features(ifname)
+		if err == nil {
+			return features, nil
 		}
-
-		// Parse lines like "rx-checksumming: on"
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
-			continue
-		}
-
-		name := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Check if it's enabled
-		enabled := strings.Contains(value, "on")
-
-		// Check if it's fixed
-		fixed := strings.Contains(value, "[fixed]")
-
-		features = append(features, EthtoolFeatureInfo{
-			Name:    name,
-			Enabled: enabled,
-			Fixed:   fixed,
-		})
+		WithField("interface", ifname).WithError(err).Debug("Falling back to ioctl for features")
 	}
-
-	return features, nil
+	return ioctlEthtoolFeatures(ifname)
 }
 
 // getEthtoolRingParam retrieves ethtool ring parameters for a network interface
-func getEthtoolRingParam(ifname string) (*EthtoolRingInfo, error) {
-	cmd := exec.Command("ethtool", "-g", ifname)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run ethtool -g: %v", err)
-	}
-
-	ring := &EthtoolRingInfo{}
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse lines like "RX:             8192"
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Parse numeric value
-		var num uint32
-		if value == "n/a" {
-			num = 0
-		} else {
-			fmt.Sscanf(value, "%d", &num)
-		}
-
-		switch key {
-		case "RX":
-			if strings.Contains(line, "Pre-set maximums") {
-				ring.RxMaxPending = num
-			} else {
-				ring.RxPending = num
-			}
-		case "TX":
-			if strings.Contains(line, "Pre-set maximums") {
-				ring.TxMaxPending = num
-			} else {
-				ring.TxPending = num
-			}
-		case "RX Mini":
-			if strings.Contains(line, "Pre-set maximums") {
-				ring.RxMiniMaxPending = num
-			} else {
-				ring.RxMiniPending = num
-			}
-		case "RX Jumbo":
-			if strings.Contains(line, "Pre-set maximums") {
-				ring.RxJumboMaxPending = num
-			} else {
-				ring.RxJumboPending = num
-			}
+func getEthtoolRingParam(nl *ethtoolNetlink, ifname string) (*EthtoolRingInfo, error) {
+	if nl != nil {
+		ring, err := nl.Rings(ifname)
+		if err == nil {
+			return ring, nil
 		}
+		WithField("interface", ifname).WithError(err).Debug("Falling back to ioctl for ring parameters")
 	}
-
-	return ring, nil
+	return ioctlEthtoolRings(ifname)
 }
 
 // getEthtoolChannels retrieves ethtool channel parameters for a network interface
-func getEthtoolChannels(ifname string) (*EthtoolChannelInfo, error) {
-	cmd := exec.Command("ethtool", "-l", ifname)
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to run ethtool -l: %v", err)
-	}
-
-	channels := &EthtoolChannelInfo{}
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse lines like "Combined:       63"
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
-			continue
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Parse numeric value
-		var num uint32
-		if value == "n/a" {
-			num = 0
-		} else {
-			fmt.Sscanf(value, "%d", &num)
-		}
-
-		switch key {
-		case "RX":
-			if strings.Contains(line, "Pre-set maximums") {
-				channels.MaxRx = num
-			} else {
-				channels.RxCount = num
-			}
-		case "TX":
-			if strings.Contains(line, "Pre-set maximums") {
-				channels.MaxTx = num
-			} else {
-				channels.TxCount = num
-			}
-		case "Other":
-			if strings.Contains(line, "Pre-set maximums") {
-				channels.MaxOther = num
-			} else {
-				channels.OtherCount = num
-			}
-		case "Combined":
-			if strings.Contains(line, "Pre-set maximums") {
-				channels.MaxCombined = num
-			} else {
-				channels.CombinedCount = num
-			}
+func getEthtoolChannels(nl *ethtoolNetlink, ifname string) (*EthtoolChannelInfo, error) {
+	if nl != nil {
+		channels, err := nl.Channels(ifname)
+		if err == nil {
+			return channels, nil
 		}
+		WithField("interface", ifname).WithError(err).Debug("Falling back to ioctl for channel parameters")
 	}
-
-	return channels, nil
+	return ioctlEthtoolChannels(ifname)
 }
 
 // GetEthtoolFeaturesString returns features as a formatted string
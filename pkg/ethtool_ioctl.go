@@ -0,0 +1,217 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ethtoolGStringLen     = 32 // ETH_GSTRING_LEN
+	ethtoolRingParamLen   = 9 * 4
+	ethtoolChannelsLen    = 9 * 4
+	ethtoolFeatureBlockSz = 4 * 4 // available, requested, active, never_changed
+)
+
+// ethtoolIoctl issues a SIOCETHTOOL request; data starts with the ethtool command
+var ethtoolIoctl = sysEthtoolIoctl
+
+// ifreqData is struct ifreq with the ifr_data member of the union
+type ifreqData struct {
+	name [unix.IFNAMSIZ]byte
+	data unsafe.Pointer
+	_    [24 - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// sysEthtoolIoctl issues SIOCETHTOOL on an AF_INET datagram socket
+func sysEthtoolIoctl(ifname string, data []byte) error {
+	if len(ifname) >= unix.IFNAMSIZ {
+		return fmt.Errorf("interface name %q too long", ifname)
+	}
+
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open ioctl socket: %v", err)
+	}
+	defer unix.Close(fd)
+
+	ifr := ifreqData{data: unsafe.Pointer(&data[0])}
+	copy(ifr.name[:], ifname)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.SIOCETHTOOL, uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ethtoolIoctlRequest allocates a request buffer with the command set
+func ethtoolIoctlRequest(cmd uint32, size int) []byte {
+	buf := make([]byte, size)
+	binary.NativeEndian.PutUint32(buf[0:4], cmd)
+	return buf
+}
+
+// ethtoolIoctlWord returns the n-th u32 of an ioctl buffer
+func ethtoolIoctlWord(buf []byte, n int) uint32 {
+	return binary.NativeEndian.Uint32(buf[n*4:])
+}
+
+// ioctlEthtoolRings reads ring parameters with ETHTOOL_GRINGPARAM
+func ioctlEthtoolRings(ifname string) (*EthtoolRingInfo, error) {
+	buf := ethtoolIoctlRequest(unix.ETHTOOL_GRINGPARAM, ethtoolRingParamLen)
+	if err := ethtoolIoctl(ifname, buf); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GRINGPARAM failed: %v", err)
+	}
+	return &EthtoolRingInfo{
+		RxMaxPending:      ethtoolIoctlWord(buf, 1),
+		RxMiniMaxPending:  ethtoolIoctlWord(buf, 2),
+		RxJumboMaxPending: ethtoolIoctlWord(buf, 3),
+		TxMaxPending:      ethtoolIoctlWord(buf, 4),
+		RxPending:         ethtoolIoctlWord(buf, 5),
+		RxMiniPending:     ethtoolIoctlWord(buf, 6),
+		RxJumboPending:    ethtoolIoctlWord(buf, 7),
+		TxPending:         ethtoolIoctlWord(buf, 8),
+	}, nil
+}
+
+// ioctlEthtoolChannels reads channel parameters with ETHTOOL_GCHANNELS
+func ioctlEthtoolChannels(ifname string) (*EthtoolChannelInfo, error) {
+	buf := ethtoolIoctlRequest(unix.ETHTOOL_GCHANNELS, ethtoolChannelsLen)
+	if err := ethtoolIoctl(ifname, buf); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GCHANNELS failed: %v", err)
+	}
+	return &EthtoolChannelInfo{
+		MaxRx:         ethtoolIoctlWord(buf, 1),
+		MaxTx:         ethtoolIoctlWord(buf, 2),
+		MaxOther:      ethtoolIoctlWord(buf, 3),
+		MaxCombined:   ethtoolIoctlWord(buf, 4),
+		RxCount:       ethtoolIoctlWord(buf, 5),
+		TxCount:       ethtoolIoctlWord(buf, 6),
+		OtherCount:    ethtoolIoctlWord(buf, 7),
+		CombinedCount: ethtoolIoctlWord(buf, 8),
+	}, nil
+}
+
+// ioctlEthtoolFeatures reads feature names and state with ETHTOOL_GSSET_INFO,
+// ETHTOOL_GSTRINGS and ETHTOOL_GFEATURES
+func ioctlEthtoolFeatures(ifname string) ([]EthtoolFeatureInfo, error) {
+	// struct ethtool_sset_info: cmd, reserved, u64 sset_mask, u32 data[1]
+	info := ethtoolIoctlRequest(unix.ETHTOOL_GSSET_INFO, 24)
+	binary.NativeEndian.PutUint64(info[8:16], 1<<ethSSFeatures)
+	if err := ethtoolIoctl(ifname, info); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GSSET_INFO failed: %v", err)
+	}
+	if binary.NativeEndian.Uint64(info[8:16])&(1<<ethSSFeatures) == 0 {
+		return nil, fmt.Errorf("feature string set not supported")
+	}
+	count := int(ethtoolIoctlWord(info, 4))
+
+	// struct ethtool_gstrings: cmd, string_set, len, data[len * ETH_GSTRING_LEN]
+	names := ethtoolIoctlRequest(unix.ETHTOOL_GSTRINGS, 12+count*ethtoolGStringLen)
+	binary.NativeEndian.PutUint32(names[4:8], ethSSFeatures)
+	binary.NativeEndian.PutUint32(names[8:12], uint32(count))
+	if err := ethtoolIoctl(ifname, names); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GSTRINGS failed: %v", err)
+	}
+
+	// struct ethtool_gfeatures: cmd, size, features[size]
+	blocks := (count + 31) / 32
+	state := ethtoolIoctlRequest(unix.ETHTOOL_GFEATURES, 8+blocks*ethtoolFeatureBlockSz)
+	binary.NativeEndian.PutUint32(state[4:8], uint32(blocks))
+	if err := ethtoolIoctl(ifname, state); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GFEATURES failed: %v", err)
+	}
+
+	features := make([]EthtoolFeatureInfo, 0, count)
+	for i := 0; i < count; i++ {
+		raw := names[12+i*ethtoolGStringLen : 12+(i+1)*ethtoolGStringLen]
+		if n := bytes.IndexByte(raw, 0); n >= 0 {
+			raw = raw[:n]
+		}
+		name := string(raw)
+		if name == "" {
+			continue
+		}
+
+		block := state[8+(i/32)*ethtoolFeatureBlockSz:]
+		bit := uint32(1) << (i % 32)
+		available := ethtoolIoctlWord(block, 0)&bit != 0
+		active := ethtoolIoctlWord(block, 2)&bit != 0
+		neverChanged := ethtoolIoctlWord(block, 3)&bit != 0
+
+		features = append(features, EthtoolFeatureInfo{
+			Name:    name,
+			Enabled: active,
+			Fixed:   !available || neverChanged,
+		})
+	}
+	return features, nil
+}
+
+// EthtoolDriverInfo is the driver identification reported by ETHTOOL_GDRVINFO
+type EthtoolDriverInfo struct {
+	Driver          string
+	Version         string
+	FirmwareVersion string
+	BusInfo         string
+}
+
+// ethtoolDrvInfoLen is the size of struct ethtool_drvinfo
+const ethtoolDrvInfoLen = 196
+
+// GetEthtoolDriverInfo reads the driver, driver version and firmware version of an interface
+func GetEthtoolDriverInfo(ifname string) (*EthtoolDriverInfo, error) {
+	buf := ethtoolIoctlRequest(unix.ETHTOOL_GDRVINFO, ethtoolDrvInfoLen)
+	if err := ethtoolIoctl(ifname, buf); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GDRVINFO failed: %v", err)
+	}
+
+	// driver, version, fw_version and bus_info are 32 byte strings after cmd
+	field := func(n int) string {
+		raw := buf[4+n*32 : 4+(n+1)*32]
+		if i := bytes.IndexByte(raw, 0); i >= 0 {
+			raw = raw[:i]
+		}
+		return string(raw)
+	}
+	return &EthtoolDriverInfo{
+		Driver:          field(0),
+		Version:         field(1),
+		FirmwareVersion: field(2),
+		BusInfo:         field(3),
+	}, nil
+}
+
+// ethtoolCmdLen is the size of the legacy struct ethtool_cmd
+const ethtoolCmdLen = 44
+
+// ethtoolLinkSettings is the subset of struct ethtool_cmd used for discovery
+type ethtoolLinkSettings struct {
+	Supported uint32
+	Speed     uint32 // Mb/s, 0 when unknown
+	Duplex    uint8
+	Port      uint8
+	Autoneg   uint8
+}
+
+// ioctlEthtoolLinkSettings reads link modes, speed, duplex and port with ETHTOOL_GSET
+func ioctlEthtoolLinkSettings(ifname string) (*ethtoolLinkSettings, error) {
+	buf := ethtoolIoctlRequest(unix.ETHTOOL_GSET, ethtoolCmdLen)
+	if err := ethtoolIoctl(ifname, buf); err != nil {
+		return nil, fmt.Errorf("ETHTOOL_GSET failed: %v", err)
+	}
+
+	speed := uint32(binary.NativeEndian.Uint16(buf[28:30]))<<16 | uint32(binary.NativeEndian.Uint16(buf[12:14]))
+	if speed == 0xffff || speed == 0xffffffff {
+		speed = 0
+	}
+	return &ethtoolLinkSettings{
+		Supported: ethtoolIoctlWord(buf, 1),
+		Speed:     speed,
+		Duplex:    buf[14],
+		Port:      buf[15],
+		Autoneg:   buf[18],
+	}, nil
+}
@@ -0,0 +1,501 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	nlmsgHeaderLen  = 16
+	genlHeaderLen   = 4
+	nlaHeaderLen    = 4
+	nlaTypeMask     = ^uint16(unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER)
+	ethtoolGenlVers = 1
+	// ethSSFeatures is the ETH_SS_FEATURES string set holding feature names
+	ethSSFeatures = 4
+)
+
+// genlSocket is a generic netlink socket. It is an interface so tests can
+// answer requests with a fake.
+type genlSocket interface {
+	Send(msg []byte) error
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// dialGenlSocket opens the generic netlink socket used for ethtool queries
+var dialGenlSocket = dialNetlinkGenlSocket
+
+// netlinkGenlSocket is a real NETLINK_GENERIC socket
+type netlinkGenlSocket struct {
+	fd int
+}
+
+// dialNetlinkGenlSocket opens and binds a NETLINK_GENERIC socket
+func dialNetlinkGenlSocket() (genlSocket, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open generic netlink socket: %v", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind generic netlink socket: %v", err)
+	}
+	return &netlinkGenlSocket{fd: fd}, nil
+}
+
+// Send sends a netlink message to the kernel
+func (s *netlinkGenlSocket) Send(msg []byte) error {
+	return unix.Sendto(s.fd, msg, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK})
+}
+
+// Receive reads one datagram of netlink messages
+func (s *netlinkGenlSocket) Receive() ([]byte, error) {
+	buf := make([]byte, 16*os.Getpagesize())
+	n, _, err := unix.Recvfrom(s.fd, buf, 0)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// Close closes the socket
+func (s *netlinkGenlSocket) Close() error {
+	return unix.Close(s.fd)
+}
+
+// nlAttr is a decoded netlink attribute
+type nlAttr struct {
+	Type uint16
+	Data []byte
+}
+
+// encodeAttr encodes a netlink attribute, padding it to 4 bytes
+func encodeAttr(attrType uint16, data []byte) []byte {
+	length := nlaHeaderLen + len(data)
+	buf := make([]byte, nlaAlign(length))
+	binary.NativeEndian.PutUint16(buf[0:2], uint16(length))
+	binary.NativeEndian.PutUint16(buf[2:4], attrType)
+	copy(buf[nlaHeaderLen:], data)
+	return buf
+}
+
+// encodeNestedAttr encodes already encoded attributes as a nested attribute
+func encodeNestedAttr(attrType uint16, children ...[]byte) []byte {
+	var data []byte
+	for _, child := range children {
+		data = append(data, child...)
+	}
+	return encodeAttr(attrType|unix.NLA_F_NESTED, data)
+}
+
+// encodeStringAttr encodes a NUL terminated string attribute
+func encodeStringAttr(attrType uint16, value string) []byte {
+	return encodeAttr(attrType, append([]byte(value), 0))
+}
+
+// encodeU32Attr encodes a u32 attribute
+func encodeU32Attr(attrType uint16, value uint32) []byte {
+	data := make([]byte, 4)
+	binary.NativeEndian.PutUint32(data, value)
+	return encodeAttr(attrType, data)
+}
+
+// nlaAlign rounds a length up to the netlink attribute alignment
+func nlaAlign(length int) int {
+	return (length + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+}
+
+// parseAttrs decodes a buffer of netlink attributes
+func parseAttrs(b []byte) ([]nlAttr, error) {
+	var attrs []nlAttr
+	for len(b) >= nlaHeaderLen {
+		length := int(binary.NativeEndian.Uint16(b[0:2]))
+		if length < nlaHeaderLen || length > len(b) {
+			return nil, fmt.Errorf("invalid netlink attribute length %d", length)
+		}
+		attrs = append(attrs, nlAttr{
+			Type: binary.NativeEndian.Uint16(b[2:4]) & nlaTypeMask,
+			Data: b[nlaHeaderLen:length],
+		})
+		if aligned := nlaAlign(length); aligned < len(b) {
+			b = b[aligned:]
+		} else {
+			b = nil
+		}
+	}
+	return attrs, nil
+}
+
+// attrU32 returns a u32 attribute value, 0 if the attribute is short
+func attrU32(a nlAttr) uint32 {
+	if len(a.Data) < 4 {
+		return 0
+	}
+	return binary.NativeEndian.Uint32(a.Data)
+}
+
+// attrString returns a string attribute without its NUL terminator
+func attrString(a nlAttr) string {
+	for i, c := range a.Data {
+		if c == 0 {
+			return string(a.Data[:i])
+		}
+	}
+	return string(a.Data)
+}
+
+// encodeGenlMessage builds a generic netlink request
+func encodeGenlMessage(family uint16, cmd, version uint8, seq uint32, attrs []byte) []byte {
+	length := nlmsgHeaderLen + genlHeaderLen + len(attrs)
+	msg := make([]byte, length)
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(length))
+	binary.NativeEndian.PutUint16(msg[4:6], family)
+	binary.NativeEndian.PutUint16(msg[6:8], unix.NLM_F_REQUEST)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	msg[nlmsgHeaderLen] = cmd
+	msg[nlmsgHeaderLen+1] = version
+	copy(msg[nlmsgHeaderLen+genlHeaderLen:], attrs)
+	return msg
+}
+
+// ethtoolNetlink queries the ethtool generic netlink family
+type ethtoolNetlink struct {
+	sock   genlSocket
+	family uint16
+	seq    uint32
+}
+
+// newEthtoolNetlink opens a socket and resolves the ethtool family ID
+func newEthtoolNetlink() (*ethtoolNetlink, error) {
+	sock, err := dialGenlSocket()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ethtoolNetlink{sock: sock}
+	attrs, err := c.request(unix.GENL_ID_CTRL, unix.CTRL_CMD_GETFAMILY, 1,
+		encodeStringAttr(unix.CTRL_ATTR_FAMILY_NAME, unix.ETHTOOL_GENL_NAME))
+	if err != nil {
+		sock.Close()
+		return nil, fmt.Errorf("failed to resolve %s netlink family: %v", unix.ETHTOOL_GENL_NAME, err)
+	}
+	for _, a := range attrs {
+		if a.Type == unix.CTRL_ATTR_FAMILY_ID && len(a.Data) >= 2 {
+			c.family = binary.NativeEndian.Uint16(a.Data)
+		}
+	}
+	if c.family == 0 {
+		sock.Close()
+		return nil, fmt.Errorf("%s netlink family ID missing from reply", unix.ETHTOOL_GENL_NAME)
+	}
+
+	return c, nil
+}
+
+// Close closes the underlying socket
+func (c *ethtoolNetlink) Close() error {
+	return c.sock.Close()
+}
+
+// request sends a generic netlink request and returns the reply attributes
+func (c *ethtoolNetlink) request(family uint16, cmd, version uint8, attrs []byte) ([]nlAttr, error) {
+	seq := atomic.AddUint32(&c.seq, 1)
+	if err := c.sock.Send(encodeGenlMessage(family, cmd, version, seq, attrs)); err != nil {
+		return nil, fmt.Errorf("netlink send failed: %v", err)
+	}
+
+	for {
+		buf, err := c.sock.Receive()
+		if err != nil {
+			return nil, fmt.Errorf("netlink receive failed: %v", err)
+		}
+
+		for len(buf) >= nlmsgHeaderLen {
+			length := int(binary.NativeEndian.Uint32(buf[0:4]))
+			if length < nlmsgHeaderLen || length > len(buf) {
+				return nil, fmt.Errorf("invalid netlink message length %d", length)
+			}
+			msgType := binary.NativeEndian.Uint16(buf[4:6])
+			msgSeq := binary.NativeEndian.Uint32(buf[8:12])
+			payload := buf[nlmsgHeaderLen:length]
+			buf = buf[min(nlaAlign(length), len(buf)):]
+
+			if msgSeq != seq {
+				continue
+			}
+			switch msgType {
+			case unix.NLMSG_ERROR:
+				if len(payload) < 4 {
+					return nil, fmt.Errorf("truncated netlink error")
+				}
+				if code := int32(binary.NativeEndian.Uint32(payload)); code != 0 {
+					return nil, syscall.Errno(-code)
+				}
+			case unix.NLMSG_DONE:
+				return nil, nil
+			default:
+				if len(payload) < genlHeaderLen {
+					return nil, fmt.Errorf("truncated generic netlink message")
+				}
+				return parseAttrs(payload[genlHeaderLen:])
+			}
+		}
+	}
+}
+
+// ethtoolHeader encodes the request header selecting an interface
+func ethtoolHeader(attrType uint16, ifname string) []byte {
+	return encodeNestedAttr(attrType, encodeStringAttr(unix.ETHTOOL_A_HEADER_DEV_NAME, ifname))
+}
+
+// Features returns all features of an interface ordered by feature index
+func (c *ethtoolNetlink) Features(ifname string) ([]EthtoolFeatureInfo, error) {
+	names, err := c.featureNames(ifname)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := c.request(c.family, unix.ETHTOOL_MSG_FEATURES_GET, ethtoolGenlVers,
+		ethtoolHeader(unix.ETHTOOL_A_FEATURES_HEADER, ifname))
+	if err != nil {
+		return nil, fmt.Errorf("ETHTOOL_MSG_FEATURES_GET failed: %v", err)
+	}
+
+	var hw, active, nochange map[uint32]bool
+	for _, a := range attrs {
+		switch a.Type {
+		case unix.ETHTOOL_A_FEATURES_HW:
+			hw, err = parseBitset(a.Data)
+		case unix.ETHTOOL_A_FEATURES_ACTIVE:
+			active, err = parseBitset(a.Data)
+		case unix.ETHTOOL_A_FEATURES_NOCHANGE:
+			nochange, err = parseBitset(a.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse feature bitset: %v", err)
+		}
+	}
+
+	indices := make([]uint32, 0, len(names))
+	for index := range names {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	features := make([]EthtoolFeatureInfo, 0, len(indices))
+	for _, index := range indices {
+		// Unused feature bits have empty names
+		if names[index] == "" {
+			continue
+		}
+		features = append(features, EthtoolFeatureInfo{
+			Name:    names[index],
+			Enabled: active[index],
+			// Same rule as ethtool -k: fixed unless the driver allows changing it
+			Fixed: !hw[index] || nochange[index],
+		})
+	}
+	return features, nil
+}
+
+// featureNames reads the ETH_SS_FEATURES string set
+func (c *ethtoolNetlink) featureNames(ifname string) (map[uint32]string, error) {
+	attrs, err := c.request(c.family, unix.ETHTOOL_MSG_STRSET_GET, ethtoolGenlVers, append(
+		ethtoolHeader(unix.ETHTOOL_A_STRSET_HEADER, ifname),
+		encodeNestedAttr(unix.ETHTOOL_A_STRSET_STRINGSETS,
+			encodeNestedAttr(unix.ETHTOOL_A_STRINGSETS_STRINGSET,
+				encodeU32Attr(unix.ETHTOOL_A_STRINGSET_ID, ethSSFeatures)))...))
+	if err != nil {
+		return nil, fmt.Errorf("ETHTOOL_MSG_STRSET_GET failed: %v", err)
+	}
+
+	names := make(map[uint32]string)
+	for _, sets := range attrs {
+		if sets.Type != unix.ETHTOOL_A_STRSET_STRINGSETS {
+			continue
+		}
+		setAttrs, err := parseAttrs(sets.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, set := range setAttrs {
+			if err := parseStringSet(set.Data, names); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return names, nil
+}
+
+// parseStringSet adds the strings of an ETH_SS_FEATURES string set to names
+func parseStringSet(b []byte, names map[uint32]string) error {
+	attrs, err := parseAttrs(b)
+	if err != nil {
+		return err
+	}
+
+	var id uint32
+	var stringsAttr []byte
+	for _, a := range attrs {
+		switch a.Type {
+		case unix.ETHTOOL_A_STRINGSET_ID:
+			id = attrU32(a)
+		case unix.ETHTOOL_A_STRINGSET_STRINGS:
+			stringsAttr = a.Data
+		}
+	}
+	if id != ethSSFeatures {
+		return nil
+	}
+
+	entries, err := parseAttrs(stringsAttr)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		fields, err := parseAttrs(entry.Data)
+		if err != nil {
+			return err
+		}
+		var index uint32
+		var value string
+		for _, f := range fields {
+			switch f.Type {
+			case unix.ETHTOOL_A_STRING_INDEX:
+				index = attrU32(f)
+			case unix.ETHTOOL_A_STRING_VALUE:
+				value = attrString(f)
+			}
+		}
+		names[index] = value
+	}
+	return nil
+}
+
+// parseBitset decodes a compact or verbose ethtool bitset into the set bits
+func parseBitset(b []byte) (map[uint32]bool, error) {
+	attrs, err := parseAttrs(b)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := make(map[uint32]bool)
+	noMask := false
+	for _, a := range attrs {
+		if a.Type == unix.ETHTOOL_A_BITSET_NOMASK {
+			noMask = true
+		}
+	}
+
+	for _, a := range attrs {
+		switch a.Type {
+		case unix.ETHTOOL_A_BITSET_VALUE:
+			// Compact form: a bitmap of u32 words
+			for word := 0; word+4 <= len(a.Data); word += 4 {
+				value := binary.NativeEndian.Uint32(a.Data[word:])
+				for bit := uint32(0); bit < 32; bit++ {
+					if value&(1<<bit) != 0 {
+						bits[uint32(word/4)*32+bit] = true
+					}
+				}
+			}
+		case unix.ETHTOOL_A_BITSET_BITS:
+			// Verbose form: one nested entry per listed bit
+			entries, err := parseAttrs(a.Data)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				fields, err := parseAttrs(entry.Data)
+				if err != nil {
+					return nil, err
+				}
+				var index uint32
+				set := noMask
+				for _, f := range fields {
+					switch f.Type {
+					case unix.ETHTOOL_A_BITSET_BIT_INDEX:
+						index = attrU32(f)
+					case unix.ETHTOOL_A_BITSET_BIT_VALUE:
+						set = true
+					}
+				}
+				if set {
+					bits[index] = true
+				}
+			}
+		}
+	}
+	return bits, nil
+}
+
+// Rings returns the ring parameters of an interface
+func (c *ethtoolNetlink) Rings(ifname string) (*EthtoolRingInfo, error) {
+	attrs, err := c.request(c.family, unix.ETHTOOL_MSG_RINGS_GET, ethtoolGenlVers,
+		ethtoolHeader(unix.ETHTOOL_A_RINGS_HEADER, ifname))
+	if err != nil {
+		return nil, fmt.Errorf("ETHTOOL_MSG_RINGS_GET failed: %v", err)
+	}
+
+	ring := &EthtoolRingInfo{}
+	for _, a := range attrs {
+		switch a.Type {
+		case unix.ETHTOOL_A_RINGS_RX_MAX:
+			ring.RxMaxPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_RX_MINI_MAX:
+			ring.RxMiniMaxPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_RX_JUMBO_MAX:
+			ring.RxJumboMaxPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_TX_MAX:
+			ring.TxMaxPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_RX:
+			ring.RxPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_RX_MINI:
+			ring.RxMiniPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_RX_JUMBO:
+			ring.RxJumboPending = attrU32(a)
+		case unix.ETHTOOL_A_RINGS_TX:
+			ring.TxPending = attrU32(a)
+		}
+	}
+	return ring, nil
+}
+
+// Channels returns the channel parameters of an interface
+func (c *ethtoolNetlink) Channels(ifname string) (*EthtoolChannelInfo, error) {
+	attrs, err := c.request(c.family, unix.ETHTOOL_MSG_CHANNELS_GET, ethtoolGenlVers,
+		ethtoolHeader(unix.ETHTOOL_A_CHANNELS_HEADER, ifname))
+	if err != nil {
+		return nil, fmt.Errorf("ETHTOOL_MSG_CHANNELS_GET failed: %v", err)
+	}
+
+	channels := &EthtoolChannelInfo{}
+	for _, a := range attrs {
+		switch a.Type {
+		case unix.ETHTOOL_A_CHANNELS_RX_MAX:
+			channels.MaxRx = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_TX_MAX:
+			channels.MaxTx = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_OTHER_MAX:
+			channels.MaxOther = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_COMBINED_MAX:
+			channels.MaxCombined = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_RX_COUNT:
+			channels.RxCount = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_TX_COUNT:
+			channels.TxCount = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_OTHER_COUNT:
+			channels.OtherCount = attrU32(a)
+		case unix.ETHTOOL_A_CHANNELS_COMBINED_COUNT:
+			channels.CombinedCount = attrU32(a)
+		}
+	}
+	return channels, nil
+}
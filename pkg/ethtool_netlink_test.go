@@ -0,0 +1,341 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+const fakeEthtoolFamily = 0x1f
+
+// fakeGenlSocket answers generic netlink requests for a single interface
+type fakeGenlSocket struct {
+	t       *testing.T
+	ifname  string
+	replies map[uint8][]byte
+	errors  map[uint8]syscall.Errno
+	pending [][]byte
+	closed  bool
+}
+
+// Send decodes a request and queues the canned reply
+func (s *fakeGenlSocket) Send(msg []byte) error {
+	msgType := binary.NativeEndian.Uint16(msg[4:6])
+	seq := binary.NativeEndian.Uint32(msg[8:12])
+	cmd := msg[nlmsgHeaderLen]
+	attrs, err := parseAttrs(msg[nlmsgHeaderLen+genlHeaderLen:])
+	if err != nil {
+		s.t.Fatalf("fake socket got malformed request: %v", err)
+	}
+
+	if msgType == unix.GENL_ID_CTRL {
+		if len(attrs) != 1 || attrString(attrs[0]) != unix.ETHTOOL_GENL_NAME {
+			s.t.Errorf("unexpected family lookup: %+v", attrs)
+		}
+		id := make([]byte, 2)
+		binary.NativeEndian.PutUint16(id, fakeEthtoolFamily)
+		s.queue(unix.GENL_ID_CTRL, seq, unix.CTRL_CMD_NEWFAMILY, encodeAttr(unix.CTRL_ATTR_FAMILY_ID, id))
+		return nil
+	}
+
+	if msgType != fakeEthtoolFamily {
+		s.t.Fatalf("request sent to family %d, expected %d", msgType, fakeEthtoolFamily)
+	}
+	if got := requestDevName(attrs); got != s.ifname {
+		s.t.Errorf("command %d: expected header for %s, got %q", cmd, s.ifname, got)
+	}
+
+	if errno, ok := s.errors[cmd]; ok {
+		payload := make([]byte, 4+len(msg))
+		binary.NativeEndian.PutUint32(payload, uint32(-int32(errno)))
+		copy(payload[4:], msg)
+		s.pending = append(s.pending, encodeNetlinkMessage(unix.NLMSG_ERROR, seq, payload))
+		return nil
+	}
+	reply, ok := s.replies[cmd]
+	if !ok {
+		s.t.Fatalf("no reply for ethtool command %d", cmd)
+	}
+	s.queue(fakeEthtoolFamily, seq, cmd+1, reply)
+	return nil
+}
+
+// Receive returns the next queued reply
+func (s *fakeGenlSocket) Receive() ([]byte, error) {
+	if len(s.pending) == 0 {
+		return nil, fmt.Errorf("no pending reply")
+	}
+	msg := s.pending[0]
+	s.pending = s.pending[1:]
+	return msg, nil
+}
+
+// Close marks the socket closed
+func (s *fakeGenlSocket) Close() error {
+	s.closed = true
+	return nil
+}
+
+// queue adds a generic netlink reply
+func (s *fakeGenlSocket) queue(msgType uint16, seq uint32, cmd uint8, attrs []byte) {
+	payload := append([]byte{cmd, 1, 0, 0}, attrs...)
+	s.pending = append(s.pending, encodeNetlinkMessage(msgType, seq, payload))
+}
+
+// encodeNetlinkMessage wraps a payload in a netlink header
+func encodeNetlinkMessage(msgType uint16, seq uint32, payload []byte) []byte {
+	msg := make([]byte, nlmsgHeaderLen+len(payload))
+	binary.NativeEndian.PutUint32(msg[0:4], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:6], msgType)
+	binary.NativeEndian.PutUint32(msg[8:12], seq)
+	copy(msg[nlmsgHeaderLen:], payload)
+	return msg
+}
+
+// requestDevName extracts the interface name from an ethtool request header
+func requestDevName(attrs []nlAttr) string {
+	for _, a := range attrs {
+		// Every ethtool request carries its header as attribute 1
+		if a.Type != 1 {
+			continue
+		}
+		header, err := parseAttrs(a.Data)
+		if err != nil {
+			return ""
+		}
+		for _, h := range header {
+			if h.Type == unix.ETHTOOL_A_HEADER_DEV_NAME {
+				return attrString(h)
+			}
+		}
+	}
+	return ""
+}
+
+// verboseBitset encodes set bits as a verbose bitset without a mask
+func verboseBitset(attrType uint16, names map[uint32]string, bits ...uint32) []byte {
+	var entries [][]byte
+	for _, bit := range bits {
+		entries = append(entries, encodeNestedAttr(unix.ETHTOOL_A_BITSET_BITS_BIT,
+			encodeU32Attr(unix.ETHTOOL_A_BITSET_BIT_INDEX, bit),
+			encodeStringAttr(unix.ETHTOOL_A_BITSET_BIT_NAME, names[bit])))
+	}
+	return encodeNestedAttr(attrType,
+		encodeAttr(unix.ETHTOOL_A_BITSET_NOMASK, nil),
+		encodeU32Attr(unix.ETHTOOL_A_BITSET_SIZE, uint32(len(names))),
+		encodeNestedAttr(unix.ETHTOOL_A_BITSET_BITS, entries...))
+}
+
+// compactBitset encodes set bits as a compact bitmap
+func compactBitset(attrType uint16, size uint32, bits ...uint32) []byte {
+	words := make([]byte, 4*((size+31)/32))
+	for _, bit := range bits {
+		word := binary.NativeEndian.Uint32(words[(bit/32)*4:])
+		binary.NativeEndian.PutUint32(words[(bit/32)*4:], word|1<<(bit%32))
+	}
+	return encodeNestedAttr(attrType,
+		encodeAttr(unix.ETHTOOL_A_BITSET_NOMASK, nil),
+		encodeU32Attr(unix.ETHTOOL_A_BITSET_SIZE, size),
+		encodeAttr(unix.ETHTOOL_A_BITSET_VALUE, words))
+}
+
+// newFakeEthtoolSocket returns a fake socket describing one interface
+func newFakeEthtoolSocket(t *testing.T, ifname string) *fakeGenlSocket {
+	names := map[uint32]string{
+		0:  "tx-scatter-gather",
+		1:  "tx-checksum-ipv4",
+		2:  "",
+		3:  "rx-gro",
+		33: "rx-hashing",
+	}
+
+	var strings [][]byte
+	for index := uint32(0); index < 34; index++ {
+		name, ok := names[index]
+		if !ok {
+			continue
+		}
+		strings = append(strings, encodeNestedAttr(unix.ETHTOOL_A_STRINGS_STRING,
+			encodeU32Attr(unix.ETHTOOL_A_STRING_INDEX, index),
+			encodeStringAttr(unix.ETHTOOL_A_STRING_VALUE, name)))
+	}
+	strset := append(ethtoolHeader(unix.ETHTOOL_A_STRSET_HEADER, ifname),
+		encodeNestedAttr(unix.ETHTOOL_A_STRSET_STRINGSETS,
+			encodeNestedAttr(unix.ETHTOOL_A_STRINGSETS_STRINGSET,
+				encodeU32Attr(unix.ETHTOOL_A_STRINGSET_ID, ethSSFeatures),
+				encodeU32Attr(unix.ETHTOOL_A_STRINGSET_COUNT, uint32(len(strings))),
+				encodeNestedAttr(unix.ETHTOOL_A_STRINGSET_STRINGS, strings...)))...)
+
+	var features []byte
+	for _, attr := range [][]byte{
+		ethtoolHeader(unix.ETHTOOL_A_FEATURES_HEADER, ifname),
+		verboseBitset(unix.ETHTOOL_A_FEATURES_HW, names, 0, 3, 33),
+		verboseBitset(unix.ETHTOOL_A_FEATURES_WANTED, names, 0, 1),
+		compactBitset(unix.ETHTOOL_A_FEATURES_ACTIVE, 34, 0, 1, 33),
+		compactBitset(unix.ETHTOOL_A_FEATURES_NOCHANGE, 34, 3),
+	} {
+		features = append(features, attr...)
+	}
+
+	var rings []byte
+	for attrType, value := range map[uint16]uint32{
+		unix.ETHTOOL_A_RINGS_RX_MAX: 8192,
+		unix.ETHTOOL_A_RINGS_TX_MAX: 8192,
+		unix.ETHTOOL_A_RINGS_RX:     1024,
+		unix.ETHTOOL_A_RINGS_TX:     2048,
+	} {
+		rings = append(rings, encodeU32Attr(attrType, value)...)
+	}
+
+	var channels []byte
+	for attrType, value := range map[uint16]uint32{
+		unix.ETHTOOL_A_CHANNELS_OTHER_MAX:      1,
+		unix.ETHTOOL_A_CHANNELS_COMBINED_MAX:   63,
+		unix.ETHTOOL_A_CHANNELS_OTHER_COUNT:    1,
+		unix.ETHTOOL_A_CHANNELS_COMBINED_COUNT: 32,
+	} {
+		channels = append(channels, encodeU32Attr(attrType, value)...)
+	}
+
+	return &fakeGenlSocket{
+		t:      t,
+		ifname: ifname,
+		replies: map[uint8][]byte{
+			unix.ETHTOOL_MSG_STRSET_GET:   strset,
+			unix.ETHTOOL_MSG_FEATURES_GET: features,
+			unix.ETHTOOL_MSG_RINGS_GET:    rings,
+			unix.ETHTOOL_MSG_CHANNELS_GET: channels,
+		},
+		errors: map[uint8]syscall.Errno{},
+	}
+}
+
+// withFakeGenlSocket routes ethtool netlink queries to sock for the test
+func withFakeGenlSocket(t *testing.T, sock genlSocket) {
+	original := dialGenlSocket
+	dialGenlSocket = func() (genlSocket, error) { return sock, nil }
+	t.Cleanup(func() { dialGenlSocket = original })
+}
+
+// TestEthtoolNetlink tests feature, ring and channel queries against a fake netlink socket
+func TestEthtoolNetlink(t *testing.T) {
+	sock := newFakeEthtoolSocket(t, "ens60f0np0")
+	withFakeGenlSocket(t, sock)
+
+	// Any ioctl use would mean the netlink path was not taken
+	original := ethtoolIoctl
+	ethtoolIoctl = func(ifname string, data []byte) error {
+		t.Errorf("unexpected ioctl fallback for %s", ifname)
+		return syscall.EOPNOTSUPP
+	}
+	defer func() { ethtoolIoctl = original }()
+
+	info, err := GetEthtoolInfo("ens60f0np0")
+	if err != nil {
+		t.Fatalf("GetEthtoolInfo failed: %v", err)
+	}
+
+	expectedFeatures := []EthtoolFeatureInfo{
+		{Name: "tx-scatter-gather", Enabled: true, Fixed: false},
+		{Name: "tx-checksum-ipv4", Enabled: true, Fixed: true},
+		{Name: "rx-gro", Enabled: false, Fixed: true},
+		{Name: "rx-hashing", Enabled: true, Fixed: false},
+	}
+	if !reflect.DeepEqual(info.Features, expectedFeatures) {
+		t.Errorf("unexpected features:\n got  %+v\n want %+v", info.Features, expectedFeatures)
+	}
+
+	expectedRing := EthtoolRingInfo{RxMaxPending: 8192, TxMaxPending: 8192, RxPending: 1024, TxPending: 2048}
+	if info.Ring != expectedRing {
+		t.Errorf("unexpected ring parameters: %+v", info.Ring)
+	}
+
+	expectedChannels := EthtoolChannelInfo{MaxOther: 1, MaxCombined: 63, OtherCount: 1, CombinedCount: 32}
+	if info.Channels != expectedChannels {
+		t.Errorf("unexpected channel parameters: %+v", info.Channels)
+	}
+
+	if !sock.closed {
+		t.Error("netlink socket was not closed")
+	}
+}
+
+// TestEthtoolIoctlFallback tests that unsupported netlink requests fall back to ioctl
+func TestEthtoolIoctlFallback(t *testing.T) {
+	sock := newFakeEthtoolSocket(t, "eno1")
+	sock.errors[unix.ETHTOOL_MSG_RINGS_GET] = syscall.EOPNOTSUPP
+	withFakeGenlSocket(t, sock)
+
+	var cmds []uint32
+	original := ethtoolIoctl
+	ethtoolIoctl = func(ifname string, data []byte) error {
+		cmd := binary.NativeEndian.Uint32(data)
+		cmds = append(cmds, cmd)
+		if cmd != unix.ETHTOOL_GRINGPARAM {
+			return syscall.EOPNOTSUPP
+		}
+		for i, value := range []uint32{4096, 0, 0, 4096, 512, 0, 0, 512} {
+			binary.NativeEndian.PutUint32(data[4*(i+1):], value)
+		}
+		return nil
+	}
+	defer func() { ethtoolIoctl = original }()
+
+	info, err := GetEthtoolInfo("eno1")
+	if err != nil {
+		t.Fatalf("GetEthtoolInfo failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmds, []uint32{unix.ETHTOOL_GRINGPARAM}) {
+		t.Errorf("expected only the ring ioctl, got commands %v", cmds)
+	}
+	expectedRing := EthtoolRingInfo{RxMaxPending: 4096, TxMaxPending: 4096, RxPending: 512, TxPending: 512}
+	if info.Ring != expectedRing {
+		t.Errorf("unexpected ring parameters: %+v", info.Ring)
+	}
+	if len(info.Features) != 4 || info.Channels.CombinedCount != 32 {
+		t.Errorf("netlink results missing after fallback: %+v", info)
+	}
+}
+
+// TestEthtoolIoctlFeatures tests feature decoding from the ioctl interface
+func TestEthtoolIoctlFeatures(t *testing.T) {
+	names := []string{"tx-scatter-gather", "tx-checksum-ipv4", "", "rx-gro"}
+
+	original := ethtoolIoctl
+	ethtoolIoctl = func(ifname string, data []byte) error {
+		switch binary.NativeEndian.Uint32(data) {
+		case unix.ETHTOOL_GSSET_INFO:
+			binary.NativeEndian.PutUint32(data[16:], uint32(len(names)))
+		case unix.ETHTOOL_GSTRINGS:
+			for i, name := range names {
+				copy(data[12+i*ethtoolGStringLen:], name)
+			}
+		case unix.ETHTOOL_GFEATURES:
+			// available, requested, active, never_changed
+			for i, value := range []uint32{0b1001, 0b0011, 0b0011, 0b1000} {
+				binary.NativeEndian.PutUint32(data[8+4*i:], value)
+			}
+		default:
+			return syscall.EOPNOTSUPP
+		}
+		return nil
+	}
+	defer func() { ethtoolIoctl = original }()
+
+	features, err := ioctlEthtoolFeatures("eno1")
+	if err != nil {
+		t.Fatalf("ioctlEthtoolFeatures failed: %v", err)
+	}
+	expected := []EthtoolFeatureInfo{
+		{Name: "tx-scatter-gather", Enabled: true, Fixed: false},
+		{Name: "tx-checksum-ipv4", Enabled: true, Fixed: true},
+		{Name: "rx-gro", Enabled: false, Fixed: true},
+	}
+	if !reflect.DeepEqual(features, expected) {
+		t.Errorf("unexpected features:\n got  %+v\n want %+v", features, expected)
+	}
+}
@@ -71,6 +71,10 @@ type SysfsPciDevice struct {
 	// NUMA topology information
 	NUMANode     int
 	NUMADistance map[int]int // Distance to other NUMA nodes
+	// Virtual functions of a PF, ordered by VF index
+	VFs []VirtualFunction
+	// Parent PF address when this device is itself a VF
+	PhysFn string
 }
 
 // DetailedCapability holds detailed information about a PCI capability
@@ -83,6 +87,18 @@ type DetailedCapability struct {
 	Description string
 }
 
+// pciIDsPaths lists the locations searched for the pci.ids database
+var pciIDsPaths = []string{
+	"/usr/share/hwdata/pci.ids",
+	"/usr/share/pci.ids",
+	"/usr/share/misc/pci.ids",
+}
+
+var (
+	pciIDsVendorLine = regexp.MustCompile(`^([0-9a-f]{4})\s+(.+)$`)
+	pciIDsDeviceLine = regexp.MustCompile(`^\t([0-9a-f]{4})\s+(.+)$`)
+)
+
 // loadVendorDatabase loads and parses the PCI vendor database
 func loadVendorDatabase() (*VendorDatabase, error) {
 	db := &VendorDatabase{
@@ -90,14 +106,8 @@ func loadVendorDatabase() (*VendorDatabase, error) {
 	}
 
 	// Try to load from system location first
-	paths := []string{
-		"/usr/share/hwdata/pci.ids",
-		"/usr/share/pci.ids",
-		"/usr/share/misc/pci.ids",
-	}
-
 	var pciIDsPath string
-	for _, path := range paths {
+	for _, path := range pciIDsPaths {
 		if _, err := os.Stat(path); err == nil {
 			pciIDsPath = path
 			break
@@ -116,43 +126,53 @@ func loadVendorDatabase() (*VendorDatabase, error) {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	var currentID string
 	var currentVendor *VendorInfo
-	var currentDevice *DeviceInfo
 
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+		line := strings.TrimRight(scanner.Text(), " \r")
 
 		// Skip comments and empty lines
-		if line == "" || strings.HasPrefix(line, "#") {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
 		// Parse vendor line (format: 8086  Intel Corporation)
-		if match := regexp.MustCompile(`^([0-9a-f]{4})\s+(.+)$`).FindStringSubmatch(line); len(match) > 2 {
+		if match := pciIDsVendorLine.FindStringSubmatch(line); len(match) > 2 {
 			if currentVendor != nil {
-				db.Vendors[currentVendor.Name] = *currentVendor
+				db.Vendors[currentID] = *currentVendor
 			}
+			currentID = match[1]
 			currentVendor = &VendorInfo{
-				Name:    match[1],
+				Name:    match[2],
 				Devices: make(map[string]DeviceInfo),
 			}
-			currentDevice = nil
+			continue
+		}
+
+		// The device class section at the end of the file has no vendors
+		if strings.HasPrefix(line, "C ") {
+			if currentVendor != nil {
+				db.Vendors[currentID] = *currentVendor
+			}
+			currentVendor = nil
+			continue
 		}
 
 		// Parse device line (format: 	1572  Ethernet Controller X710 for 10GbE SFP+)
-		if strings.HasPrefix(line, "\t") && currentVendor != nil {
-			if match := regexp.MustCompile(`^\t([0-9a-f]{4})\s+(.+)$`).FindStringSubmatch(line); len(match) > 2 {
-				currentDevice = &DeviceInfo{
+		// Subsystem lines are indented with two tabs and do not match
+		if currentVendor != nil {
+			if match := pciIDsDeviceLine.FindStringSubmatch(line); len(match) > 2 {
+				currentVendor.Devices[match[1]] = DeviceInfo{
 					Name: match[2],
 				}
-				currentVendor.Devices[match[1]] = *currentDevice
 			}
 		}
 	}
 
 	// Add the last vendor
 	if currentVendor != nil {
-		db.Vendors[currentVendor.Name] = *currentVendor
+		db.Vendors[currentID] = *currentVendor
 	}
 
 	return db, scanner.Err()
@@ -168,7 +188,8 @@ func createMinimalVendorDB() *VendorDatabase {
 	vendors := map[string]string{
 		"8086": "Intel Corporation",
 		"15b3": "Mellanox Technologies",
-		"1b4b": "Pensando Systems",
+		"1dd8": "Pensando Systems",
+		"1b4b": "Marvell Technology Group Ltd.",
 		"1d0f": "Amazon.com, Inc.",
 		"10ee": "Xilinx Corporation",
 		"14e4": "Broadcom Inc.",
@@ -201,7 +222,7 @@ func enrichDeviceWithVendorDB(device *SysfsPciDevice, db *VendorDatabase) {
 	}
 }
 
-// ParseSysfsPciDevices parses PCI device information from /sys/bus/pci/devices
+// ParseSysfsPciDevices parses PCI device information from <sysfs root>/bus/pci/devices
 func ParseSysfsPciDevices() ([]SysfsPciDevice, error) {
 	// Load vendor database for name resolution
 	vendorDB, err := loadVendorDatabase()
@@ -210,7 +231,7 @@ func ParseSysfsPciDevices() ([]SysfsPciDevice, error) {
 	}
 
 	var devices []SysfsPciDevice
-	sysfsPath := "/sys/bus/pci/devices"
+	sysfsPath := PciDevicesPath()
 
 	// Read all entries in sysfs
 	entries, err := os.ReadDir(sysfsPath)
@@ -276,6 +297,14 @@ func parseSysfsPciDevice(devicePath, deviceName string, vendorDB *VendorDatabase
 		// SR-IOV might not be available, continue
 	}
 
+	// Parse VF inventory for PFs and the parent link for VFs
+	if device.SRIOVCapable {
+		if vfs, err := parseVirtualFunctions(devicePath); err == nil {
+			device.VFs = vfs
+		}
+	}
+	device.PhysFn = parsePhysFn(devicePath)
+
 	// Parse PCI capabilities
 	if err := parsePciCapabilities(devicePath, &device); err != nil {
 		// Capabilities might not be accessible, continue
@@ -331,7 +360,7 @@ func parseDeviceIds(devicePath string, device *SysfsPciDevice) error {
 	if err != nil {
 		return err
 	}
-	device.VendorID = strings.TrimSpace(string(vendorData))
+	device.VendorID = normalizePciID(string(vendorData))
 
 	// Read device ID
 	devicePathFile := filepath.Join(devicePath, "device")
@@ -339,18 +368,18 @@ func parseDeviceIds(devicePath string, device *SysfsPciDevice) error {
 	if err != nil {
 		return err
 	}
-	device.DeviceID = strings.TrimSpace(string(deviceData))
+	device.DeviceID = normalizePciID(string(deviceData))
 
 	// Read subsystem vendor ID (optional)
 	subVendorPath := filepath.Join(devicePath, "subsystem_vendor")
 	if subVendorData, err := os.ReadFile(subVendorPath); err == nil {
-		device.SubVendorID = strings.TrimSpace(string(subVendorData))
+		device.SubVendorID = normalizePciID(string(subVendorData))
 	}
 
 	// Read subsystem device ID (optional)
 	subDevicePath := filepath.Join(devicePath, "subsystem_device")
 	if subDeviceData, err := os.ReadFile(subDevicePath); err == nil {
-		device.SubDeviceID = strings.TrimSpace(string(subDeviceData))
+		device.SubDeviceID = normalizePciID(string(subDeviceData))
 	}
 
 	// Read revision (optional)
@@ -362,6 +391,12 @@ func parseDeviceIds(devicePath string, device *SysfsPciDevice) error {
 	return nil
 }
 
+// normalizePciID converts a sysfs ID such as "0x15B3\n" to "15b3"
+func normalizePciID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	return strings.TrimPrefix(id, "0x")
+}
+
 // parseDeviceClass parses device class information from sysfs
 func parseDeviceClass(devicePath string, device *SysfsPciDevice) error {
 	// Read class
@@ -435,7 +470,7 @@ func parseSRIOVParameters(devicePath string, sriov *SRIOVInfo) error {
 
 	// Parse VF device ID
 	if vfDeviceIDData, err := os.ReadFile(filepath.Join(devicePath, "sriov_vf_device")); err == nil {
-		sriov.VFDeviceID = strings.TrimSpace(string(vfDeviceIDData))
+		sriov.VFDeviceID = normalizePciID(string(vfDeviceIDData))
 	}
 
 	// Parse VF vendor ID
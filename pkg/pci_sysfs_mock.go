@@ -38,6 +38,12 @@ func MockParseSysfsPciDevices() ([]SysfsPciDevice, error) {
 			SRIOVCapable: true,
 			NUMANode:     0,
 			NUMADistance: map[int]int{0: 10, 1: 20},
+			VFs: []VirtualFunction{
+				{Index: 0, PCIAddress: "0000:01:00.2", Driver: "mlx5_core", NetDev: "eth0v0", IOMMUGroup: "30", PFAddress: "0000:01:00.0"},
+				{Index: 1, PCIAddress: "0000:01:00.3", Driver: "mlx5_core", NetDev: "eth0v1", IOMMUGroup: "31", PFAddress: "0000:01:00.0"},
+				{Index: 2, PCIAddress: "0000:01:00.4", Driver: "mlx5_core", NetDev: "eth0v2", IOMMUGroup: "32", PFAddress: "0000:01:00.0"},
+				{Index: 3, PCIAddress: "0000:01:00.5", Driver: "mlx5_core", NetDev: "eth0v3", IOMMUGroup: "33", PFAddress: "0000:01:00.0"},
+			},
 			SRIOVInfo: &SRIOVInfo{
 				IOVCap:                 "Migration-, Interrupt Message Number: 000",
 				IOVCtl:                 "Enable+ Migration- Interrupt- MSE+ ARIHierarchy+",
@@ -123,6 +129,9 @@ func MockParseSysfsPciDevices() ([]SysfsPciDevice, error) {
 			SRIOVCapable: true,
 			NUMANode:     1,
 			NUMADistance: map[int]int{0: 20, 1: 10},
+			VFs: []VirtualFunction{
+				{Index: 0, PCIAddress: "0000:02:00.1", Driver: "vfio-pci", IOMMUGroup: "40", PFAddress: "0000:02:00.0"},
+			},
 			SRIOVInfo: &SRIOVInfo{
 				IOVCap:                 "Migration-, Interrupt Message Number: 000",
 				IOVCtl:                 "Enable+ Migration- Interrupt- MSE+ ARIHierarchy+",
@@ -0,0 +1,442 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+)
+
+// ReconcileState is the outcome of reconciling a single device
+type ReconcileState string
+
+const (
+	ReconcileInSync  ReconcileState = "in-sync"
+	ReconcileApplied ReconcileState = "applied"
+	ReconcilePlanned ReconcileState = "planned"
+	ReconcileDrifted ReconcileState = "drifted"
+	ReconcileSkipped ReconcileState = "skipped"
+	ReconcileFailed  ReconcileState = "failed"
+)
+
+// reconcileDebounce coalesces bursts of device events into one pass
+var reconcileDebounce = 2 * time.Second
+
+// DesiredDeviceState is the state a device should be in according to its policy
+type DesiredDeviceState struct {
+	NumVFs   int
+	Mode     SRIOVMode
+	Eswitch  EswitchState
+	VFDriver string
+	Bond     *BondConfig
+}
+
+// ObservedDeviceState is the state a device is currently in
+type ObservedDeviceState struct {
+	NumVFs int
+}
+
+// DeviceReconcileStatus reports the result of the last reconcile of a device
+type DeviceReconcileStatus struct {
+	Device     string         `json:"device"`
+	PCIAddress string         `json:"pci_address"`
+	Policy     string         `json:"policy,omitempty"`
+	State      ReconcileState `json:"state"`
+	// FailedStep names the step that failed, after which the device was
+	// rolled back to its prior state
+	FailedStep    string    `json:"failed_step,omitempty"`
+	DesiredVFs    int       `json:"desired_vfs"`
+	ObservedVFs   int       `json:"observed_vfs"`
+	Actions       []string  `json:"actions,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	LastReconcile time.Time `json:"last_reconcile"`
+
+	// err is the error behind a failed or drifted state
+	err error
+	// waiting is set when the bond step waits for other PFs of the bond
+	// to enter switchdev mode
+	waiting bool
+}
+
+// Reconcile discovers devices and applies only the differences between the
+// configured and observed state
+func (m *SRIOVManager) Reconcile() ([]DeviceReconcileStatus, error) {
+	devices, err := m.DiscoverDevices()
+	if err != nil {
+		return nil, fmt.Errorf("device discovery failed: %v", err)
+	}
+	return m.reconcileDevices(devices), nil
+}
+
+// ReconcileStatus returns the last reconcile status of every device, sorted by PCI address
+func (m *SRIOVManager) ReconcileStatus() []DeviceReconcileStatus {
+	m.statusMu.RLock()
+	defer m.statusMu.RUnlock()
+
+	statuses := make([]DeviceReconcileStatus, 0, len(m.status))
+	for _, status := range m.status {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PCIAddress < statuses[j].PCIAddress
+	})
+	return statuses
+}
+
+// UpdateConfig validates a new configuration and queues it for the reconcile
+// loop, which switches to it and re-applies the policies. An invalid
+// configuration is rejected and the current one stays in effect.
+func (m *SRIOVManager) UpdateConfig(config *SRIOVConfig) error {
+	if err := config.ValidateConfig(); err != nil {
+		return fmt.Errorf("configuration rejected: %v", err)
+	}
+
+	// Only the most recent pending config matters
+	select {
+	case <-m.configUpdates:
+	default:
+	}
+	m.configUpdates <- config
+	return nil
+}
+
+// RunReconcileLoop reconciles immediately, then on every interval tick, after
+// device events and after config updates until the context is cancelled. report, if set, is
+// called with the status of each pass.
+func (m *SRIOVManager) RunReconcileLoop(ctx context.Context, interval time.Duration, events <-chan struct{}, report func([]DeviceReconcileStatus)) error {
+	if interval <= 0 {
+		return fmt.Errorf("reconcile interval must be > 0")
+	}
+
+	reconcile := func(trigger string) {
+		WithField("trigger", trigger).Info("Reconciling SR-IOV devices")
+		statuses, err := m.Reconcile()
+		if err != nil {
+			WithError(err).Error("Reconcile failed")
+			return
+		}
+		if report != nil {
+			report(statuses)
+		}
+	}
+
+	reconcile("startup")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			reconcile("interval")
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if debounce == nil {
+				debounce = time.After(reconcileDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			reconcile("device event")
+		case config := <-m.configUpdates:
+			m.config.Store(config)
+			WithField("policies", len(config.DevicePolicies)).Info("Configuration reloaded")
+			reconcile("reload")
+		}
+	}
+}
+
+// WatchDeviceEvents watches the PCI and network sysfs trees and signals on
+// the returned channel when devices appear, disappear or change
+func WatchDeviceEvents(ctx context.Context) (<-chan struct{}, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fsnotify watcher: %v", err)
+	}
+
+	for _, path := range []string{PciDevicesPath(), NetClassPath()} {
+		if err := watcher.Add(path); err != nil {
+			watcher.Close()
+			return nil, fmt.Errorf("failed to watch %s: %v", path, err)
+		}
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		defer watcher.Close()
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				WithField("event", event.String()).Debug("Device event")
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				WithError(err).Debug("fsnotify error")
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// reconcileDevices reconciles each device and records its status
+func (m *SRIOVManager) reconcileDevices(devices []Device) []DeviceReconcileStatus {
+	statuses := make([]DeviceReconcileStatus, 0, len(devices))
+	seen := make(map[string]bool)
+
+	var waiting []int
+	for i, device := range devices {
+		status := m.reconcileDevice(device)
+		if status.waiting {
+			waiting = append(waiting, i)
+		}
+		statuses = append(statuses, status)
+		seen[status.PCIAddress] = true
+	}
+
+	// A VF-LAG PF configured before the other PFs of its bond could not
+	// build the bond yet; those PFs have now been switched, so retry once
+	for _, i := range waiting {
+		first := statuses[i]
+		status := m.reconcileDevice(devices[i])
+		status.Actions = append(first.Actions, status.Actions...)
+		if status.State == ReconcileInSync && len(status.Actions) > 0 {
+			status.State = first.State
+		}
+		if status.waiting {
+			status.State = ReconcileDrifted
+		}
+		statuses[i] = status
+	}
+
+	for _, status := range statuses {
+		entry := WithFields(logrus.Fields{
+			"device":       status.Device,
+			"pci":          status.PCIAddress,
+			"state":        string(status.State),
+			"desired_vfs":  status.DesiredVFs,
+			"observed_vfs": status.ObservedVFs,
+		})
+		if status.Message != "" {
+			entry = entry.WithField("message", status.Message)
+		}
+		switch status.State {
+		case ReconcileFailed, ReconcileDrifted:
+			entry.Warn("Device reconcile status")
+		default:
+			entry.Info("Device reconcile status")
+		}
+	}
+
+	m.statusMu.Lock()
+	if m.status == nil {
+		m.status = make(map[string]DeviceReconcileStatus)
+	}
+	for addr := range m.status {
+		if !seen[addr] {
+			delete(m.status, addr)
+		}
+	}
+	for _, status := range statuses {
+		m.status[status.PCIAddress] = status
+	}
+	m.statusMu.Unlock()
+
+	return statuses
+}
+
+// reconcileDevice compares desired and observed state for one device and
+// applies the differences
+func (m *SRIOVManager) reconcileDevice(device Device) DeviceReconcileStatus {
+	status := DeviceReconcileStatus{
+		Device:        device.Name,
+		PCIAddress:    device.PCIAddress,
+		LastReconcile: time.Now(),
+	}
+
+	vendorID, deviceID := m.extractDeviceIDs(device)
+	if vendorID == "" || deviceID == "" {
+		status.State = ReconcileSkipped
+		status.Message = "vendor/device IDs unknown, device not found in PCI sysfs"
+		return status
+	}
+
+	policy := m.Config().GetDevicePolicyForDevice(device)
+	if policy == nil {
+		status.State = ReconcileSkipped
+		status.Message = "no matching policy"
+		return status
+	}
+	status.Policy = policy.Description
+
+	desired := m.desiredState(device, policy)
+	status.DesiredVFs = desired.NumVFs
+
+	observed, err := observeDeviceState(device)
+	if err != nil {
+		status.State = ReconcileFailed
+		status.Message = err.Error()
+		status.err = err
+		return status
+	}
+	status.ObservedVFs = observed.NumVFs
+
+	// Save each piece of state before the step that changes it; a failed
+	// step restores them and reports which step failed
+	tx := m.beginTransaction(device)
+	fail := func(step string, err error) DeviceReconcileStatus {
+		err = tx.abort(step, err)
+		status.State = ReconcileFailed
+		if errors.Is(err, ErrVFsInUse) {
+			status.State = ReconcileDrifted
+		}
+		status.FailedStep = step
+		status.Message = err.Error()
+		status.err = err
+		return status
+	}
+	tx.saveVFCount()
+	tx.saveEswitch()
+
+	// The eswitch can only change while the PF has no VFs, so a change
+	// destroys them and the VF count step below recreates them
+	change, err := VendorPluginFor(device).SetSwitchMode(m, device, desired.Eswitch)
+	if err != nil {
+		return fail(StepEswitch, err)
+	}
+	if !change.IsZero() {
+		status.Actions = append(status.Actions, fmt.Sprintf("set eswitch %s", change))
+		observed.NumVFs = 0
+	}
+
+	if observed.NumVFs != desired.NumVFs {
+		if err := m.enableSRIOV(device, desired.NumVFs); err != nil {
+			return fail(StepVFCount, err)
+		}
+		if observed.NumVFs == 0 {
+			status.Actions = append(status.Actions, fmt.Sprintf("set sriov_numvfs to %d", desired.NumVFs))
+		} else {
+			status.Actions = append(status.Actions, fmt.Sprintf("resized VFs from %d to %d", observed.NumVFs, desired.NumVFs))
+		}
+	}
+
+	if policy.HasVFSettings() {
+		actions, err := m.applyVFSettings(device, policy, desired.NumVFs)
+		status.Actions = append(status.Actions, actions...)
+		if err != nil {
+			return fail(StepVFSettings, err)
+		}
+	}
+
+	if desired.VFDriver != "" {
+		tx.saveVFDrivers()
+		actions, err := m.bindVFDrivers(device, desired.VFDriver)
+		status.Actions = append(status.Actions, actions...)
+		if err != nil {
+			return fail(StepVFDriver, err)
+		}
+	}
+
+	if policy.SubFunctions != nil {
+		actions, err := m.ensureSFs(device, policy.SubFunctions)
+		status.Actions = append(status.Actions, actions...)
+		if err != nil {
+			return fail(StepSubFunctions, err)
+		}
+	}
+
+	if desired.Mode == ModeVFLag {
+		var pending []string
+		if desired.Bond != nil {
+			pending = m.pendingVFLagSlaves(device, desired.Bond)
+		}
+		if len(pending) > 0 {
+			status.waiting = true
+			status.Message = fmt.Sprintf("bond %s waits for %s to enter switchdev mode",
+				desired.Bond.BondName, strings.Join(pending, ", "))
+		} else {
+			if desired.Bond != nil {
+				tx.saveBond(desired.Bond)
+			}
+			actions, err := m.configureVFLagMode(device, desired.Bond)
+			status.Actions = append(status.Actions, actions...)
+			if err != nil {
+				return fail(StepVFLag, err)
+			}
+		}
+	}
+
+	if len(status.Actions) > 0 && m.Config().DryRun {
+		status.State = ReconcilePlanned
+	} else if len(status.Actions) > 0 {
+		status.State = ReconcileApplied
+	} else {
+		status.State = ReconcileInSync
+	}
+	return status
+}
+
+// desiredState computes the desired state of a device from its policy
+func (m *SRIOVManager) desiredState(device Device, policy *DevicePolicy) DesiredDeviceState {
+	desired := DesiredDeviceState{
+		NumVFs:   policy.NumVFs,
+		Mode:     policy.Mode,
+		Eswitch:  policy.DesiredEswitch(),
+		VFDriver: policy.VFDriver,
+	}
+	plugin := VendorPluginFor(device)
+	if maxVFs := plugin.MaxVFs(device); maxVFs >= 0 && desired.NumVFs > maxVFs {
+		WithFields(logrus.Fields{
+			"device":    device.Name,
+			"plugin":    plugin.Name(),
+			"requested": desired.NumVFs,
+			"supported": maxVFs,
+		}).Warn("Requested VFs exceed device capability, adjusting")
+		desired.NumVFs = maxVFs
+	}
+	if desired.Mode == ModeVFLag {
+		desired.Bond = m.findBondConfig(device.Name)
+	}
+	return desired
+}
+
+// observeDeviceState reads the current VF count from sysfs
+func observeDeviceState(device Device) (ObservedDeviceState, error) {
+	var observed ObservedDeviceState
+
+	data, err := os.ReadFile(filepath.Join(PciDevicePath(device.PCIAddress), "sriov_numvfs"))
+	if err != nil {
+		return observed, fmt.Errorf("failed to read sriov_numvfs: %v", err)
+	}
+	numVFs, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return observed, fmt.Errorf("failed to parse sriov_numvfs: %v", err)
+	}
+	observed.NumVFs = numVFs
+
+	return observed, nil
+}
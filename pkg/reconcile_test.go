@@ -0,0 +1,277 @@
+package pkg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReconcileDevices tests that reconcile applies only the differences
+func TestReconcileDevices(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	writeSysfsFiles(t, filepath.Join(devicesDir, "0000:31:00.0"), map[string]string{"sriov_numvfs": "0\n"})
+	writeSysfsFiles(t, filepath.Join(devicesDir, "0000:31:00.1"), map[string]string{"sriov_numvfs": "2\n"})
+	writeSysfsFiles(t, filepath.Join(devicesDir, "0000:09:00.0"), map[string]string{"sriov_numvfs": "1\n"})
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeSingleHome, Description: "ConnectX-7"},
+			{VendorID: "1dd8", DeviceID: "1003", NumVFs: 1, Mode: ModeSingleHome, Description: "Pensando DSC"},
+		},
+	})
+
+	devices := []Device{
+		{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e", SRIOVCapable: true},
+		{Name: "ens60f1np1", PCIAddress: "0000:31:00.1", VendorID: "15b3", DeviceID: "101e", SRIOVCapable: true},
+		{Name: "enp9s0np0", PCIAddress: "0000:09:00.0", VendorID: "1dd8", DeviceID: "1003", SRIOVCapable: true},
+		{Name: "ens61f0", PCIAddress: "0000:32:00.0", VendorID: "8086", DeviceID: "1521", SRIOVCapable: true},
+	}
+
+	expected := map[string]ReconcileState{
+		"0000:31:00.0": ReconcileApplied,
+		"0000:31:00.1": ReconcileApplied,
+		"0000:09:00.0": ReconcileInSync,
+		"0000:32:00.0": ReconcileSkipped,
+	}
+	for _, status := range manager.reconcileDevices(devices) {
+		if status.State != expected[status.PCIAddress] {
+			t.Errorf("%s: expected state %s, got %s (%s)", status.PCIAddress, expected[status.PCIAddress], status.State, status.Message)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(devicesDir, "0000:31:00.0", "sriov_numvfs"))
+	if err != nil {
+		t.Fatalf("failed to read sriov_numvfs: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "4" {
+		t.Errorf("expected sriov_numvfs 4, got %q", string(data))
+	}
+
+	// A second pass finds the applied device in sync
+	manager.reconcileDevices(devices[:1])
+	statuses := manager.ReconcileStatus()
+	if len(statuses) != 1 {
+		t.Fatalf("expected status for 1 device after it was the only one reconciled, got %d", len(statuses))
+	}
+	if statuses[0].State != ReconcileInSync || statuses[0].ObservedVFs != 4 {
+		t.Errorf("expected in-sync with 4 VFs, got %s with %d", statuses[0].State, statuses[0].ObservedVFs)
+	}
+}
+
+// TestReconcileDeviceResizeGuard tests that a resize is refused while VFs are in use
+func TestReconcileDeviceResizeGuard(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	pfDir := filepath.Join(devicesDir, "0000:31:00.0")
+	writeSysfsFiles(t, pfDir, map[string]string{"sriov_numvfs": "2\n"})
+	writeSysfsFiles(t, filepath.Join(devicesDir, "0000:31:00.2"), nil)
+	symlink(t, "../../../bus/pci/drivers/vfio-pci", filepath.Join(devicesDir, "0000:31:00.2", "driver"))
+	symlink(t, "../0000:31:00.2", filepath.Join(pfDir, "virtfn0"))
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		GuardInUseVFs: true,
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 8},
+		},
+	})
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileDrifted {
+		t.Errorf("expected drifted while a VF is bound to vfio-pci, got %s (%s)", status.State, status.Message)
+	}
+	if !strings.Contains(status.Message, "vfio-pci") {
+		t.Errorf("expected message to name the in-use VF, got %q", status.Message)
+	}
+
+	manager.Config().GuardInUseVFs = false
+	status = manager.reconcileDevice(device)
+	if status.State != ReconcileApplied {
+		t.Fatalf("expected applied without the guard, got %s (%s)", status.State, status.Message)
+	}
+	if len(status.Actions) != 1 || status.Actions[0] != "resized VFs from 2 to 8" {
+		t.Errorf("unexpected actions: %v", status.Actions)
+	}
+	data, err := os.ReadFile(filepath.Join(pfDir, "sriov_numvfs"))
+	if err != nil {
+		t.Fatalf("failed to read sriov_numvfs: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "8" {
+		t.Errorf("expected sriov_numvfs 8, got %q", string(data))
+	}
+}
+
+// TestReconcileDeviceVFLag tests that a VF-LAG device is only enslaved when its bond master differs
+func TestReconcileDeviceVFLag(t *testing.T) {
+	useVFLagSysfs(t)
+	useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	fake := useFakeBondLinks(t)
+	fake.bonds["bond0"] = &BondState{Mode: "active-backup", Up: true}
+	fake.masters["ens60f0np0"] = "bond0"
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeVFLag, EnableSwitch: true},
+		},
+		BondConfigs: []BondConfig{
+			{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"}, Mode: "active-backup"},
+		},
+	})
+
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileApplied || len(status.Actions) != 1 || status.Actions[0] != "enslave ens60f1np1 to bond0" {
+		t.Errorf("expected the second port to be enslaved, got %s (%s): %v", status.State, status.Message, status.Actions)
+	}
+
+	status = manager.reconcileDevice(device)
+	if status.State != ReconcileInSync {
+		t.Errorf("expected in-sync, got %s (%s): %v", status.State, status.Message, status.Actions)
+	}
+
+	manager.Config().BondConfigs = nil
+	status = manager.reconcileDevice(device)
+	if status.State != ReconcileFailed {
+		t.Errorf("expected failed without a bond configuration, got %s", status.State)
+	}
+}
+
+// TestReconcileVFLagFromLegacy tests building a VF-LAG bond when both PFs
+// start in legacy mode and are switched one after the other
+func TestReconcileVFLagFromLegacy(t *testing.T) {
+	useVFLagSysfs(t)
+	eswitch := useFakeEswitch(t, EswitchState{Mode: EswitchModeLegacy})
+	bonds := useFakeBondLinks(t)
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeVFLag, Eswitch: &EswitchState{Mode: EswitchModeSwitchdev}},
+		},
+		BondConfigs: []BondConfig{
+			{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"}, Mode: "802.3ad"},
+		},
+	})
+	pf0 := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+	pf1 := Device{Name: "ens60f1np1", PCIAddress: "0000:31:00.1", VendorID: "15b3", DeviceID: "101e"}
+
+	// Without the second PF in the pass the bond waits for it
+	statuses := manager.reconcileDevices([]Device{pf0})
+	if statuses[0].State != ReconcileDrifted || !strings.Contains(statuses[0].Message, "waits for ens60f1np1") {
+		t.Errorf("expected the bond to wait for ens60f1np1, got %s (%s)", statuses[0].State, statuses[0].Message)
+	}
+	if eswitch.state(pf0.PCIAddress).Mode != EswitchModeSwitchdev || len(bonds.calls) != 0 {
+		t.Errorf("expected the first PF switched and no bond, got %s %v", eswitch.state(pf0.PCIAddress).Mode, bonds.calls)
+	}
+
+	eswitch.states = make(map[string]EswitchState)
+	statuses = manager.reconcileDevices([]Device{pf0, pf1})
+	for _, status := range statuses {
+		if status.State != ReconcileApplied || status.Message != "" {
+			t.Errorf("%s: expected applied, got %s (%s)", status.Device, status.State, status.Message)
+		}
+	}
+	if !reflect.DeepEqual(statuses[0].Actions[:1], []string{"set eswitch mode switchdev"}) {
+		t.Errorf("expected the eswitch change to be reported, got %v", statuses[0].Actions)
+	}
+	for _, pf := range []Device{pf0, pf1} {
+		if mode := eswitch.state(pf.PCIAddress).Mode; mode != EswitchModeSwitchdev {
+			t.Errorf("%s: expected switchdev, got %s", pf.Name, mode)
+		}
+	}
+	expectedBond := []string{"add bond mode 802.3ad", "enslave ens60f0np0 to bond0", "enslave ens60f1np1 to bond0", "up bond0"}
+	if !reflect.DeepEqual(bonds.calls, expectedBond) {
+		t.Errorf("expected bond calls %v, got %v", expectedBond, bonds.calls)
+	}
+}
+
+// TestRunReconcileLoop tests that the loop reconciles at startup and after device events
+func TestRunReconcileLoop(t *testing.T) {
+	defer func(d time.Duration) { reconcileDebounce = d }(reconcileDebounce)
+	reconcileDebounce = 10 * time.Millisecond
+
+	// Discovery fails without lshw, which still exercises the trigger path
+	manager := NewSRIOVManager(&SRIOVConfig{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.RunReconcileLoop(ctx, time.Hour, events, nil)
+	}()
+
+	events <- struct{}{}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("RunReconcileLoop returned error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunReconcileLoop did not stop after cancel")
+	}
+
+	if err := manager.RunReconcileLoop(context.Background(), 0, nil, nil); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
+
+// TestUpdateConfig tests that invalid configs are rejected and valid ones reach the loop
+func TestUpdateConfig(t *testing.T) {
+	original := &SRIOVConfig{}
+	manager := NewSRIOVManager(original)
+
+	invalid := &SRIOVConfig{DevicePolicies: []DevicePolicy{{VendorID: "15b3", DeviceID: "101e", NumVFs: -1}}}
+	if err := manager.UpdateConfig(invalid); err == nil {
+		t.Error("expected invalid config to be rejected")
+	}
+	if len(manager.configUpdates) != 0 {
+		t.Error("rejected config should not be queued")
+	}
+
+	// Vendor ffff never matches a real device, so reconciling on a host is harmless
+	first := &SRIOVConfig{DevicePolicies: []DevicePolicy{{VendorID: "ffff", DeviceID: "0001", NumVFs: 1}}}
+	second := &SRIOVConfig{DevicePolicies: []DevicePolicy{{VendorID: "ffff", DeviceID: "0002", NumVFs: 1}}}
+	for _, config := range []*SRIOVConfig{first, second} {
+		if err := manager.UpdateConfig(config); err != nil {
+			t.Fatalf("UpdateConfig failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- manager.RunReconcileLoop(ctx, time.Hour, nil, nil)
+	}()
+	// gRPC handlers read the config while the loop swaps it; go test -race
+	// catches unsynchronized access
+	read := make(chan struct{})
+	go func() {
+		defer close(read)
+		for ctx.Err() == nil {
+			_ = len(manager.Config().DevicePolicies)
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	<-read
+	if err := <-done; err != nil {
+		t.Fatalf("RunReconcileLoop returned error: %v", err)
+	}
+
+	if manager.Config() != second {
+		t.Error("expected the most recent config to be applied")
+	}
+}
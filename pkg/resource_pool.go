@@ -0,0 +1,54 @@
+package pkg
+
+// poolVF is a VF that belongs to a resource pool
+type poolVF struct {
+	VirtualFunction
+	NUMANode int
+}
+
+// selectPoolVFs returns the VFs of the inventory that belong to a pool, by PCI address
+func selectPoolVFs(pool ResourcePool, devices []Device) map[string]poolVF {
+	byAddress := make(map[string]Device)
+	for _, device := range devices {
+		if device.IsVF() {
+			byAddress[device.PCIAddress] = device
+		}
+	}
+
+	vfs := make(map[string]poolVF)
+	for _, pf := range devices {
+		if len(pf.VFs) == 0 || pf.IsVF() {
+			continue
+		}
+		if len(pool.PFNames) > 0 && !matchAnyPattern(pool.PFNames, pf.Name) {
+			continue
+		}
+		for _, vf := range pf.VFs {
+			if _, ok := vfs[vf.PCIAddress]; ok {
+				continue
+			}
+
+			// Match the selector against the VF's own device when discovered,
+			// otherwise against what the PF knows about it
+			view, ok := byAddress[vf.PCIAddress]
+			if !ok {
+				view = Device{PCIAddress: vf.PCIAddress, PhysFn: pf.PCIAddress, NUMANode: pf.NUMANode}
+			}
+			view.Name = vf.NetDev
+			view.Driver = vf.Driver
+			if !pool.Selector.Matches(view) {
+				continue
+			}
+			vfs[vf.PCIAddress] = poolVF{VirtualFunction: vf, NUMANode: pf.NUMANode}
+		}
+	}
+	return vfs
+}
+
+// poolVFHealthy reports whether a VF is bound the way the pool's device type needs
+func poolVFHealthy(pool ResourcePool, vf poolVF) bool {
+	if pool.DeviceType == PoolDeviceVFIO {
+		return vf.Driver == "vfio-pci" && vf.IOMMUGroup != ""
+	}
+	return vf.Driver != "" && vf.Driver != "vfio-pci" && vf.NetDev != ""
+}
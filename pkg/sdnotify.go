@@ -0,0 +1,32 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// SdNotify sends a state such as "READY=1" to the service manager over
+// $NOTIFY_SOCKET. It reports false without an error when the process was
+// not started by systemd with a notify socket.
+func SdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	// A leading @ names a socket in the abstract namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to notify service manager: %v", err)
+	}
+	return true, nil
+}
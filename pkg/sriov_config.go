@@ -1,10 +1,10 @@
 package pkg
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
+	"path"
+	"regexp"
 )
 
 // SRIOVMode represents the SR-IOV configuration mode
@@ -17,12 +17,30 @@ const (
 
 // DevicePolicy defines how to configure a specific device
 type DevicePolicy struct {
-	VendorID     string    `json:"vendor_id"`
-	DeviceID     string    `json:"device_id"`
-	NumVFs       int       `json:"num_vfs"`
-	Mode         SRIOVMode `json:"mode,omitempty"`
-	EnableSwitch bool      `json:"enable_switch,omitempty"`
-	Description  string    `json:"description,omitempty"`
+	VendorID    string    `json:"vendor_id"`
+	DeviceID    string    `json:"device_id"`
+	SubVendorID string    `json:"subsystem_vendor_id,omitempty"`
+	SubDeviceID string    `json:"subsystem_device_id,omitempty"`
+	NumVFs      int       `json:"num_vfs"`
+	Mode        SRIOVMode `json:"mode,omitempty"`
+	// Deprecated: EnableSwitch is shorthand for an Eswitch mode of
+	// switchdev. Version 1.0 files are migrated to Eswitch.
+	EnableSwitch bool   `json:"enable_switch,omitempty"`
+	Description  string `json:"description,omitempty"`
+	// Selector narrows the policy beyond vendor/device IDs
+	Selector *DeviceSelector `json:"selector,omitempty"`
+	// Priority decides between matching policies, highest wins. Policies
+	// that can match the same device must not share a priority.
+	Priority int `json:"priority,omitempty"`
+	// VFDriver is the kernel driver the policy's VFs are bound to, e.g. vfio-pci
+	VFDriver string `json:"vf_driver,omitempty"`
+	// VFTemplate applies to every VF, VFOverrides replace it for single VFs
+	VFTemplate  *VFSettings  `json:"vf_template,omitempty"`
+	VFOverrides []VFOverride `json:"vf_overrides,omitempty"`
+	// Eswitch is the devlink eswitch state of the PF
+	Eswitch *EswitchState `json:"eswitch,omitempty"`
+	// SubFunctions are the scalable functions kept on the PF
+	SubFunctions *SFPolicy `json:"subfunctions,omitempty"`
 }
 
 // BondConfig defines VF-LAG bonding configuration
@@ -31,44 +49,80 @@ type BondConfig struct {
 	SlaveInterfaces []string `json:"slave_interfaces"`
 	Mode            string   `json:"mode,omitempty"`
 	MIIMonitor      int      `json:"mii_monitor,omitempty"`
+	// LACPRate is slow or fast, for mode 802.3ad
+	LACPRate string `json:"lacp_rate,omitempty"`
+	// XmitHashPolicy selects the slave for each flow, e.g. layer3+4
+	XmitHashPolicy string `json:"xmit_hash_policy,omitempty"`
+}
+
+// Device types handed to containers by a ResourcePool
+const (
+	PoolDeviceNetdevice = "netdevice"
+	PoolDeviceVFIO      = "vfio"
+)
+
+// ResourcePool groups VFs that the kubelet device plugin advertises as one
+// extended resource
+type ResourcePool struct {
+	// ResourceName is the extended resource name, e.g. "example.com/cx7_vf"
+	ResourceName string `json:"resource_name"`
+	// PFNames limits the pool to VFs of PFs with these interface names (globs)
+	PFNames []string `json:"pf_names,omitempty"`
+	// Selector matches the VFs themselves
+	Selector *DeviceSelector `json:"selector,omitempty"`
+	// DeviceType is "netdevice" (default) for VFs bound to their network
+	// driver, or "vfio" for VFs bound to vfio-pci
+	DeviceType string `json:"device_type,omitempty"`
 }
 
 // SRIOVConfig represents the main configuration for SR-IOV management
 type SRIOVConfig struct {
+	// Version is the schema version the file was written for; older
+	// versions are migrated to CurrentConfigVersion when loaded
 	Version        string         `json:"version"`
 	Description    string         `json:"description"`
 	DevicePolicies []DevicePolicy `json:"device_policies"`
 	BondConfigs    []BondConfig   `json:"bond_configs,omitempty"`
 	LogLevel       string         `json:"log_level,omitempty"`
 	DryRun         bool           `json:"dry_run,omitempty"`
+	// GuardInUseVFs refuses to change the VF count of a PF while any of its
+	// VFs is bound to vfio-pci or has an interface that is up
+	GuardInUseVFs bool `json:"guard_in_use_vfs,omitempty"`
+	// SkipLAGVerification accepts VF-LAG bonds without checking that the
+	// hardware LAG engaged, e.g. where mlx5 debugfs is not mounted
+	SkipLAGVerification bool `json:"skip_lag_verification,omitempty"`
+	// ResourcePools are served to the kubelet by `sriov server --device-plugin`
+	ResourcePools []ResourcePool `json:"resource_pools,omitempty"`
 }
 
-// LoadConfig loads SR-IOV configuration from a JSON file
+// LoadConfig loads SR-IOV configuration from a JSON, YAML or TOML file,
+// chosen by its extension. Unknown fields are rejected. Files written for an
+// older schema version are upgraded in memory, with a warning for each
+// deprecated setting.
 func LoadConfig(configPath string) (*SRIOVConfig, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
 
-	var config SRIOVConfig
-	if err := json.Unmarshal(data, &config); err != nil {
+	config, report, err := decodeConfig(data, ConfigFormatFor(configPath))
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
+	logConfigMigration(configPath, report)
 
 	// Set defaults
-	if config.Version == "" {
-		config.Version = "1.0"
-	}
 	if config.LogLevel == "" {
 		config.LogLevel = "info"
 	}
 
-	return &config, nil
+	return config, nil
 }
 
-// SaveConfig saves SR-IOV configuration to a JSON file
+// SaveConfig saves SR-IOV configuration as JSON, YAML or TOML, chosen by the
+// file's extension
 func SaveConfig(config *SRIOVConfig, configPath string) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := encodeConfig(config, ConfigFormatFor(configPath))
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %v", err)
 	}
@@ -80,15 +134,95 @@ func SaveConfig(config *SRIOVConfig, configPath string) error {
 	return nil
 }
 
-// GetDevicePolicy finds the appropriate policy for a device
+// GetDevicePolicy finds the appropriate policy for a vendor/device ID pair
 func (c *SRIOVConfig) GetDevicePolicy(vendorID, deviceID string) *DevicePolicy {
-	for _, policy := range c.DevicePolicies {
-		if strings.EqualFold(policy.VendorID, vendorID) &&
-			strings.EqualFold(policy.DeviceID, deviceID) {
-			return &policy
+	return c.selectPolicy(func(p *DevicePolicy) bool {
+		return p.MatchesIDs(vendorID, deviceID, "", "")
+	})
+}
+
+// GetDevicePolicyForDevice finds the highest-priority policy matching a device's
+// PCI IDs and selector
+func (c *SRIOVConfig) GetDevicePolicyForDevice(device Device) *DevicePolicy {
+	return c.selectPolicy(func(p *DevicePolicy) bool {
+		return p.Matches(device)
+	})
+}
+
+// selectPolicy returns the highest-priority policy accepted by match,
+// preferring the earliest policy on ties
+func (c *SRIOVConfig) selectPolicy(match func(p *DevicePolicy) bool) *DevicePolicy {
+	var selected *DevicePolicy
+	for i := range c.DevicePolicies {
+		policy := c.DevicePolicies[i]
+		if !match(&policy) {
+			continue
+		}
+		if selected == nil || policy.Priority > selected.Priority {
+			selected = &policy
 		}
 	}
-	return nil
+	return selected
+}
+
+// Matches reports whether the policy applies to a device
+func (p *DevicePolicy) Matches(device Device) bool {
+	return p.MatchesIDs(device.VendorID, device.DeviceID, device.SubVendorID, device.SubDeviceID) &&
+		p.Selector.Matches(device)
+}
+
+// MatchesIDs reports whether the policy applies to the given PCI IDs.
+// IDs may be glob patterns; subsystem IDs are only compared when set on the policy.
+func (p *DevicePolicy) MatchesIDs(vendorID, deviceID, subVendorID, subDeviceID string) bool {
+	if !matchPattern(p.VendorID, vendorID) || !matchPattern(p.DeviceID, deviceID) {
+		return false
+	}
+	if p.SubVendorID != "" && !matchPattern(p.SubVendorID, subVendorID) {
+		return false
+	}
+	if p.SubDeviceID != "" && !matchPattern(p.SubDeviceID, subDeviceID) {
+		return false
+	}
+	return true
+}
+
+// overlaps reports whether some device could match both policies
+func (p *DevicePolicy) overlaps(other *DevicePolicy) bool {
+	if !patternsOverlap(p.VendorID, other.VendorID) || !patternsOverlap(p.DeviceID, other.DeviceID) {
+		return false
+	}
+	if p.SubVendorID != "" && other.SubVendorID != "" && !patternsOverlap(p.SubVendorID, other.SubVendorID) {
+		return false
+	}
+	if p.SubDeviceID != "" && other.SubDeviceID != "" && !patternsOverlap(p.SubDeviceID, other.SubDeviceID) {
+		return false
+	}
+	return p.Selector.overlaps(other.Selector)
+}
+
+// overlaps reports whether some VF could be selected by both pools. Empty
+// pf_names match any PF.
+func (p *ResourcePool) overlaps(other *ResourcePool) bool {
+	if len(p.PFNames) > 0 && len(other.PFNames) > 0 && !anyPatternsOverlap(p.PFNames, other.PFNames) {
+		return false
+	}
+	return p.Selector.overlaps(other.Selector)
+}
+
+// hasVFLagPolicyFor reports whether a vf-lag policy can match an interface.
+// Policies without interface_names match any interface.
+func (c *SRIOVConfig) hasVFLagPolicyFor(name string) bool {
+	for i := range c.DevicePolicies {
+		policy := &c.DevicePolicies[i]
+		if policy.Mode != ModeVFLag {
+			continue
+		}
+		if policy.Selector == nil || len(policy.Selector.InterfaceNames) == 0 ||
+			matchAnyPattern(policy.Selector.InterfaceNames, name) {
+			return true
+		}
+	}
+	return false
 }
 
 // ValidateConfig validates the configuration
@@ -100,46 +234,179 @@ func (c *SRIOVConfig) ValidateConfig() error {
 		if policy.DeviceID == "" {
 			return fmt.Errorf("device policy %d: device_id is required", i)
 		}
-		if policy.NumVFs <= 0 {
-			return fmt.Errorf("device policy %d: num_vfs must be > 0", i)
+		if policy.NumVFs < 0 {
+			return fmt.Errorf("device policy %d: num_vfs must not be negative", i)
 		}
 		if policy.Mode != "" && policy.Mode != ModeSingleHome && policy.Mode != ModeVFLag {
 			return fmt.Errorf("device policy %d: invalid mode %s", i, policy.Mode)
 		}
+		if err := policy.Selector.Validate(); err != nil {
+			return fmt.Errorf("device policy %d: selector: %v", i, err)
+		}
+		if !driverNamePattern.MatchString(policy.VFDriver) {
+			return fmt.Errorf("device policy %d: invalid vf_driver %q", i, policy.VFDriver)
+		}
+		if err := policy.Eswitch.Validate(); err != nil {
+			return fmt.Errorf("device policy %d: eswitch: %v", i, err)
+		}
+		if policy.EnableSwitch && policy.Eswitch != nil && policy.Eswitch.Mode == EswitchModeLegacy {
+			return fmt.Errorf("device policy %d: enable_switch conflicts with eswitch mode legacy", i)
+		}
+		if err := policy.SubFunctions.Validate(); err != nil {
+			return fmt.Errorf("device policy %d: subfunctions: %v", i, err)
+		}
+		if policy.SubFunctions != nil && policy.SubFunctions.Count > 0 && policy.DesiredEswitch().Mode != EswitchModeSwitchdev {
+			return fmt.Errorf("device policy %d: subfunctions require eswitch mode switchdev", i)
+		}
+		if err := policy.VFTemplate.Validate(); err != nil {
+			return fmt.Errorf("device policy %d: vf_template: %v", i, err)
+		}
+		if policy.VFTemplate != nil && policy.VFTemplate.MAC != "" {
+			if _, err := offsetMAC(policy.VFTemplate.MAC, policy.NumVFs-1); err != nil {
+				return fmt.Errorf("device policy %d: vf_template: %v", i, err)
+			}
+		}
+		overridden := make(map[int]bool)
+		for _, override := range policy.VFOverrides {
+			if override.VF < 0 || override.VF >= policy.NumVFs {
+				return fmt.Errorf("device policy %d: vf_overrides: vf %d out of range for %d VFs", i, override.VF, policy.NumVFs)
+			}
+			if overridden[override.VF] {
+				return fmt.Errorf("device policy %d: vf_overrides: duplicate vf %d", i, override.VF)
+			}
+			overridden[override.VF] = true
+			if err := override.Validate(); err != nil {
+				return fmt.Errorf("device policy %d: vf_overrides: vf %d: %v", i, override.VF, err)
+			}
+		}
+	}
+
+	// Ties between policies are resolved by file order, which is easy to
+	// get wrong, so policies that can match the same device must differ
+	// in priority
+	for i := range c.DevicePolicies {
+		for j := i + 1; j < len(c.DevicePolicies); j++ {
+			a, b := &c.DevicePolicies[i], &c.DevicePolicies[j]
+			if a.Priority == b.Priority && a.overlaps(b) {
+				return fmt.Errorf("device policies %d and %d overlap at priority %d", i, j, a.Priority)
+			}
+		}
+	}
+
+	bonds := make(map[string]string)
+	for i := range c.BondConfigs {
+		bond := &c.BondConfigs[i]
+		if err := bond.Validate(); err != nil {
+			return fmt.Errorf("bond config %d: %v", i, err)
+		}
+		for _, slave := range bond.SlaveInterfaces {
+			if other, ok := bonds[slave]; ok {
+				return fmt.Errorf("bond config %d: %s is already a slave of %s", i, slave, other)
+			}
+			bonds[slave] = bond.BondName
+			if !c.hasVFLagPolicyFor(slave) {
+				return fmt.Errorf("bond config %d: slave %s is not matched by any vf-lag policy", i, slave)
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, pool := range c.ResourcePools {
+		if !resourceNamePattern.MatchString(pool.ResourceName) {
+			return fmt.Errorf("resource pool %d: invalid resource_name %q, expected domain/name", i, pool.ResourceName)
+		}
+		if names[pool.ResourceName] {
+			return fmt.Errorf("resource pool %d: duplicate resource_name %s", i, pool.ResourceName)
+		}
+		names[pool.ResourceName] = true
+		if pool.DeviceType != "" && pool.DeviceType != PoolDeviceNetdevice && pool.DeviceType != PoolDeviceVFIO {
+			return fmt.Errorf("resource pool %d: invalid device_type %s", i, pool.DeviceType)
+		}
+		for _, pattern := range pool.PFNames {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("resource pool %d: invalid pf_names pattern %q: %v", i, pattern, err)
+			}
+		}
+		if err := pool.Selector.Validate(); err != nil {
+			return fmt.Errorf("resource pool %d: selector: %v", i, err)
+		}
+		for j := 0; j < i; j++ {
+			if pool.overlaps(&c.ResourcePools[j]) {
+				return fmt.Errorf("resource pool %d: can select the same VFs as pool %d (%s)", i, j, c.ResourcePools[j].ResourceName)
+			}
+		}
 	}
 	return nil
 }
 
+// knownVFDeviceIDs are the vendor:device IDs of common VFs. Policies only
+// apply to PFs, so a policy naming one of these never matches.
+var knownVFDeviceIDs = map[string]string{
+	"15b3:1012": "Connect-IB VF",
+	"15b3:1014": "ConnectX-4 VF",
+	"15b3:1016": "ConnectX-4 Lx VF",
+	"15b3:1018": "ConnectX-5 VF",
+	"15b3:101a": "ConnectX-5 Ex VF",
+	"15b3:101c": "ConnectX-6 VF",
+	"15b3:101e": "ConnectX mlx5Gen VF",
+	"8086:10ed": "82599 VF",
+	"8086:1515": "X540 VF",
+	"8086:154c": "700 Series VF",
+	"8086:1889": "E810 Adaptive VF",
+}
+
+// Warnings returns problems that do not make the configuration invalid
+// but are likely mistakes, such as a policy naming a VF's device ID
+func (c *SRIOVConfig) Warnings() []string {
+	var warnings []string
+	for i, policy := range c.DevicePolicies {
+		id := normalizePciID(policy.VendorID) + ":" + normalizePciID(policy.DeviceID)
+		if name, ok := knownVFDeviceIDs[id]; ok {
+			warnings = append(warnings, fmt.Sprintf("device policy %d: %s is the %s device ID, policies only match PFs", i, id, name))
+		}
+	}
+	return warnings
+}
+
+// driverNamePattern matches kernel driver names, or an empty name
+var driverNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// resourceNamePattern matches extended resource names such as example.com/vf
+var resourceNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?/[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
 // CreateDefaultConfig creates a default configuration with common devices
 func CreateDefaultConfig() *SRIOVConfig {
 	return &SRIOVConfig{
-		Version:     "1.0",
+		Version:     CurrentConfigVersion,
 		Description: "SR-IOV Manager Configuration",
 		LogLevel:    "info",
 		DevicePolicies: []DevicePolicy{
 			{
-				VendorID:     "15b3", // Mellanox
-				DeviceID:     "101e", // ConnectX-7
-				NumVFs:       4,
-				Mode:         ModeSingleHome,
-				EnableSwitch: true,
-				Description:  "Mellanox ConnectX-7 in single-home mode",
+				VendorID:    "15b3", // Mellanox
+				DeviceID:    "1021", // ConnectX-7
+				NumVFs:      4,
+				Mode:        ModeSingleHome,
+				Description: "Mellanox ConnectX-7 in single-home mode",
+				Eswitch:     &EswitchState{Mode: EswitchModeSwitchdev},
 			},
 			{
-				VendorID:     "15b3", // Mellanox
-				DeviceID:     "101e", // ConnectX-7
-				NumVFs:       4,
-				Mode:         ModeVFLag,
-				EnableSwitch: true,
-				Description:  "Mellanox ConnectX-7 in VF-LAG mode",
+				VendorID:    "15b3", // Mellanox
+				DeviceID:    "1021", // ConnectX-7
+				NumVFs:      4,
+				Mode:        ModeVFLag,
+				Description: "Mellanox ConnectX-7 in VF-LAG mode",
+				Eswitch:     &EswitchState{Mode: EswitchModeSwitchdev},
+				Selector: &DeviceSelector{
+					InterfaceNames: []string{"ens60f0np0", "ens60f1np1"},
+				},
+				Priority: 10,
 			},
 			{
-				VendorID:     "1dd8", // Pensando
-				DeviceID:     "1003", // DSC
-				NumVFs:       1,
-				Mode:         ModeSingleHome,
-				EnableSwitch: false,
-				Description:  "Pensando DSC for ROCE",
+				VendorID:    "1dd8", // Pensando
+				DeviceID:    "1003", // DSC
+				NumVFs:      1,
+				Mode:        ModeSingleHome,
+				Description: "Pensando DSC for ROCE",
 			},
 		},
 		BondConfigs: []BondConfig{
@@ -3,6 +3,7 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -47,9 +48,9 @@ func TestLoadConfig(t *testing.T) {
 		t.Fatalf("LoadConfig failed: %v", err)
 	}
 
-	// Verify configuration
-	if config.Version != "1.0" {
-		t.Errorf("Expected version 1.0, got %s", config.Version)
+	// Verify configuration, migrated from version 1.0
+	if config.Version != CurrentConfigVersion {
+		t.Errorf("Expected version %s, got %s", CurrentConfigVersion, config.Version)
 	}
 	if config.Description != "Test Configuration" {
 		t.Errorf("Expected description 'Test Configuration', got %s", config.Description)
@@ -78,8 +79,8 @@ func TestLoadConfig(t *testing.T) {
 	if policy.Mode != ModeSingleHome {
 		t.Errorf("Expected mode 'single-home', got %s", policy.Mode)
 	}
-	if !policy.EnableSwitch {
-		t.Error("Expected enable_switch to be true")
+	if policy.EnableSwitch || policy.Eswitch == nil || policy.Eswitch.Mode != EswitchModeSwitchdev {
+		t.Errorf("Expected enable_switch to be migrated to eswitch mode switchdev, got %v %+v", policy.EnableSwitch, policy.Eswitch)
 	}
 
 	// Test bond config
@@ -124,8 +125,8 @@ func TestLoadConfigWithDefaults(t *testing.T) {
 	}
 
 	// Verify defaults are set
-	if config.Version != "1.0" {
-		t.Errorf("Expected default version 1.0, got %s", config.Version)
+	if config.Version != CurrentConfigVersion {
+		t.Errorf("Expected unversioned config to be migrated to %s, got %s", CurrentConfigVersion, config.Version)
 	}
 	if config.LogLevel != "info" {
 		t.Errorf("Expected default log level 'info', got %s", config.LogLevel)
@@ -175,17 +176,17 @@ func TestSaveConfig(t *testing.T) {
 
 	// Create test configuration
 	config := &SRIOVConfig{
-		Version:     "1.0",
+		Version:     CurrentConfigVersion,
 		Description: "Test Save Configuration",
 		LogLevel:    "debug",
 		DevicePolicies: []DevicePolicy{
 			{
-				VendorID:     "15b3",
-				DeviceID:     "101e",
-				NumVFs:       4,
-				Mode:         ModeSingleHome,
-				EnableSwitch: true,
-				Description:  "Mellanox ConnectX-7",
+				VendorID:    "15b3",
+				DeviceID:    "101e",
+				NumVFs:      4,
+				Mode:        ModeSingleHome,
+				Eswitch:     &EswitchState{Mode: EswitchModeSwitchdev},
+				Description: "Mellanox ConnectX-7",
 			},
 		},
 	}
@@ -261,6 +262,187 @@ func TestGetDevicePolicy(t *testing.T) {
 	}
 }
 
+// TestGetDevicePolicyForDevice tests policy lookup by the PCI IDs carried on a device
+func TestGetDevicePolicyForDevice(t *testing.T) {
+	config := &SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{
+				VendorID:    "15b3",
+				DeviceID:    "101d",
+				SubVendorID: "15b3",
+				SubDeviceID: "0016",
+				NumVFs:      8,
+				Description: "ConnectX-6 Dx OEM board",
+			},
+			{
+				VendorID:    "15b3",
+				DeviceID:    "101d",
+				NumVFs:      4,
+				Description: "ConnectX-6 Dx",
+			},
+			{
+				VendorID:    "8086",
+				DeviceID:    "1592",
+				NumVFs:      16,
+				Description: "Intel E810-C",
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		device      Device
+		description string
+	}{
+		{
+			name:        "subsystem match",
+			device:      Device{VendorID: "15b3", DeviceID: "101d", SubVendorID: "15b3", SubDeviceID: "0016"},
+			description: "ConnectX-6 Dx OEM board",
+		},
+		{
+			name:        "subsystem mismatch falls through",
+			device:      Device{VendorID: "15b3", DeviceID: "101d", SubVendorID: "15b3", SubDeviceID: "0042"},
+			description: "ConnectX-6 Dx",
+		},
+		{
+			name:        "E810 is not treated as I350",
+			device:      Device{Vendor: "Intel Corporation", VendorID: "8086", DeviceID: "1592"},
+			description: "Intel E810-C",
+		},
+		{
+			name:        "ConnectX-7 has no policy",
+			device:      Device{Vendor: "Mellanox Technologies", VendorID: "15b3", DeviceID: "101e"},
+			description: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := config.GetDevicePolicyForDevice(tc.device)
+			if tc.description == "" {
+				if policy != nil {
+					t.Errorf("Expected no policy, got %s", policy.Description)
+				}
+				return
+			}
+			if policy == nil {
+				t.Fatalf("Expected policy %s, got nil", tc.description)
+			}
+			if policy.Description != tc.description {
+				t.Errorf("Expected policy %s, got %s", tc.description, policy.Description)
+			}
+		})
+	}
+}
+
+// TestDevicePolicySelector tests selector and priority based policy matching
+func TestDevicePolicySelector(t *testing.T) {
+	config := &SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{
+				VendorID:    "15b3",
+				DeviceID:    "101e",
+				NumVFs:      4,
+				Mode:        ModeSingleHome,
+				Description: "ConnectX-7 single-home",
+			},
+			{
+				VendorID:    "15b3",
+				DeviceID:    "101e",
+				NumVFs:      8,
+				Mode:        ModeVFLag,
+				Description: "ConnectX-7 VF-LAG",
+				Selector: &DeviceSelector{
+					InterfaceNames: []string{"ens60f*"},
+				},
+				Priority: 10,
+			},
+			{
+				VendorID:    "15b3",
+				DeviceID:    "*",
+				NumVFs:      2,
+				Description: "Mellanox on NUMA 1",
+				Selector: &DeviceSelector{
+					PCIAddresses: []string{"0000:b1:00.*"},
+					Drivers:      []string{"mlx5_core"},
+					NUMANodes:    []int{1},
+				},
+				Priority: 20,
+			},
+			{
+				VendorID:    "1dd8",
+				DeviceID:    "1003",
+				NumVFs:      1,
+				Description: "Pensando OEM board",
+				Selector: &DeviceSelector{
+					SubsystemIDs: []string{"1dd8:40*"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		device      Device
+		description string
+	}{
+		{
+			name:        "no selector match uses base policy",
+			device:      Device{Name: "ens61f0np0", VendorID: "15b3", DeviceID: "101e"},
+			description: "ConnectX-7 single-home",
+		},
+		{
+			name:        "interface glob selects higher priority",
+			device:      Device{Name: "ens60f1np1", VendorID: "15b3", DeviceID: "101e"},
+			description: "ConnectX-7 VF-LAG",
+		},
+		{
+			name: "address, driver and NUMA all match",
+			device: Device{
+				Name: "ens60f0np0", PCIAddress: "0000:b1:00.0", Driver: "mlx5_core",
+				NUMANode: 1, VendorID: "15b3", DeviceID: "101e",
+			},
+			description: "Mellanox on NUMA 1",
+		},
+		{
+			name: "NUMA mismatch falls back",
+			device: Device{
+				Name: "ens60f0np0", PCIAddress: "0000:b1:00.0", Driver: "mlx5_core",
+				NUMANode: 0, VendorID: "15b3", DeviceID: "101e",
+			},
+			description: "ConnectX-7 VF-LAG",
+		},
+		{
+			name:        "subsystem selector match",
+			device:      Device{VendorID: "1dd8", DeviceID: "1003", SubVendorID: "1dd8", SubDeviceID: "4001"},
+			description: "Pensando OEM board",
+		},
+		{
+			name:        "subsystem selector mismatch",
+			device:      Device{VendorID: "1dd8", DeviceID: "1003", SubVendorID: "1dd8", SubDeviceID: "5001"},
+			description: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			policy := config.GetDevicePolicyForDevice(tc.device)
+			if tc.description == "" {
+				if policy != nil {
+					t.Errorf("Expected no policy, got %s", policy.Description)
+				}
+				return
+			}
+			if policy == nil {
+				t.Fatalf("Expected policy %s, got nil", tc.description)
+			}
+			if policy.Description != tc.description {
+				t.Errorf("Expected policy %s, got %s", tc.description, policy.Description)
+			}
+		})
+	}
+}
+
 // TestValidateConfig tests configuration validation
 func TestValidateConfig(t *testing.T) {
 	testCases := []struct {
@@ -306,7 +488,20 @@ func TestValidateConfig(t *testing.T) {
 			expectError: true,
 		},
 		{
-			name: "invalid VF count",
+			name: "negative VF count",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{
+						VendorID: "15b3",
+						DeviceID: "101e",
+						NumVFs:   -1,
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "zero VFs",
 			config: &SRIOVConfig{
 				DevicePolicies: []DevicePolicy{
 					{
@@ -316,6 +511,86 @@ func TestValidateConfig(t *testing.T) {
 					},
 				},
 			},
+			expectError: false,
+		},
+		{
+			name: "overlapping policies at equal priority",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{VendorID: "15b3", DeviceID: "101*", NumVFs: 4},
+					{VendorID: "15B3", DeviceID: "10?e", NumVFs: 8, Selector: &DeviceSelector{InterfaceNames: []string{"ens60f*"}}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "overlapping policies at different priorities",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{VendorID: "15b3", DeviceID: "101*", NumVFs: 4},
+					{VendorID: "15b3", DeviceID: "10?e", NumVFs: 8, Priority: 10},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "disjoint selectors at equal priority",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Selector: &DeviceSelector{PCIAddresses: []string{"0000:31:00.[01]"}}},
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 8, Selector: &DeviceSelector{PCIAddresses: []string{"0000:31:00.[2-7]"}}},
+					{VendorID: "15b3", DeviceID: "1021", NumVFs: 8},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "selector overlapping a narrower one",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Selector: &DeviceSelector{PCIAddresses: []string{"0000:31:00.[01]"}}},
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 8, Selector: &DeviceSelector{NUMANodes: []int{1}, PCIAddresses: []string{"0000:31:*"}}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "bond slave without vf-lag policy",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeVFLag, Selector: &DeviceSelector{InterfaceNames: []string{"ens60f0np0"}}},
+					{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeSingleHome, Priority: -1},
+				},
+				BondConfigs: []BondConfig{{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"}}},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid selector pattern",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{
+						VendorID: "15b3",
+						DeviceID: "101e",
+						NumVFs:   4,
+						Selector: &DeviceSelector{PCIAddresses: []string{"0000:31:00.["}},
+					},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid subsystem ID",
+			config: &SRIOVConfig{
+				DevicePolicies: []DevicePolicy{
+					{
+						VendorID: "15b3",
+						DeviceID: "101e",
+						NumVFs:   4,
+						Selector: &DeviceSelector{SubsystemIDs: []string{"15b30016"}},
+					},
+				},
+			},
 			expectError: true,
 		},
 		{
@@ -332,6 +607,58 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "valid resource pools",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{
+					{ResourceName: "example.com/cx7_netdev", PFNames: []string{"ens60f*"}, Selector: &DeviceSelector{Drivers: []string{"mlx5_core"}}},
+					{ResourceName: "example.com/cx7_vfio", DeviceType: PoolDeviceVFIO, Selector: &DeviceSelector{Drivers: []string{"vfio-pci"}}},
+					{ResourceName: "example.com/cx6_netdev", PFNames: []string{"ens1f*"}, Selector: &DeviceSelector{Drivers: []string{"mlx5_core"}}},
+				},
+			},
+			expectError: false,
+		},
+		{
+			name: "overlapping resource pools",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{
+					{ResourceName: "example.com/cx7_netdev", PFNames: []string{"ens60f*"}},
+					{ResourceName: "example.com/cx7_vfio", DeviceType: PoolDeviceVFIO, Selector: &DeviceSelector{Drivers: []string{"vfio-pci"}}},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "resource name without domain",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{{ResourceName: "cx7_netdev"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "duplicate resource name",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{
+					{ResourceName: "example.com/cx7"},
+					{ResourceName: "example.com/cx7", DeviceType: PoolDeviceVFIO},
+				},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid pool device type",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{{ResourceName: "example.com/cx7", DeviceType: "rdma"}},
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid pf_names pattern",
+			config: &SRIOVConfig{
+				ResourcePools: []ResourcePool{{ResourceName: "example.com/cx7", PFNames: []string{"ens60f["}}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -352,8 +679,8 @@ func TestCreateDefaultConfig(t *testing.T) {
 	config := CreateDefaultConfig()
 
 	// Verify basic structure
-	if config.Version != "1.0" {
-		t.Errorf("Expected version 1.0, got %s", config.Version)
+	if config.Version != CurrentConfigVersion {
+		t.Errorf("Expected version %s, got %s", CurrentConfigVersion, config.Version)
 	}
 	if config.Description != "SR-IOV Manager Configuration" {
 		t.Errorf("Expected description 'SR-IOV Manager Configuration', got %s", config.Description)
@@ -376,6 +703,31 @@ func TestCreateDefaultConfig(t *testing.T) {
 	if err := config.ValidateConfig(); err != nil {
 		t.Errorf("Default configuration validation failed: %v", err)
 	}
+	if warnings := config.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for the default configuration, got %v", warnings)
+	}
+}
+
+// TestConfigWarnings tests that policies naming a VF device ID are flagged,
+// including in the shipped configuration
+func TestConfigWarnings(t *testing.T) {
+	config := &SRIOVConfig{DevicePolicies: []DevicePolicy{
+		{VendorID: "15b3", DeviceID: "1021", NumVFs: 4},
+		{VendorID: "0x15B3", DeviceID: "101E", NumVFs: 4},
+		{VendorID: "15b3", DeviceID: "*", NumVFs: 4},
+	}}
+	warnings := config.Warnings()
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "device policy 1: 15b3:101e is the ConnectX mlx5Gen VF device ID") {
+		t.Errorf("Expected a warning for policy 1 only, got %v", warnings)
+	}
+
+	shipped, err := LoadConfig(filepath.Join("..", "config", "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to load the shipped configuration: %v", err)
+	}
+	if warnings := shipped.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for the shipped configuration, got %v", warnings)
+	}
 }
 
 // TestSRIOVModeConstants tests SR-IOV mode constants
@@ -18,7 +18,7 @@ func TestNewSRIOVManager(t *testing.T) {
 	if manager == nil {
 		t.Fatal("Expected non-nil manager")
 	}
-	if manager.config != config {
+	if manager.Config() != config {
 		t.Error("Expected config to be set")
 	}
 	// Logger is now handled by the global logging system, no need to check manager.logger
@@ -35,37 +35,32 @@ func TestExtractDeviceIDs(t *testing.T) {
 		expectedDevice string
 	}{
 		{
-			name: "Mellanox device",
+			name: "Mellanox ConnectX-6 Dx",
 			device: Device{
-				Vendor:  "Mellanox Technologies",
-				Product: "MT2910 Family [ConnectX-7]",
+				Vendor:   "Mellanox Technologies",
+				Product:  "MT2892 Family [ConnectX-6 Dx]",
+				VendorID: "15b3",
+				DeviceID: "101d",
 			},
 			expectedVendor: "15b3",
-			expectedDevice: "101e",
-		},
-		{
-			name: "Pensando device",
-			device: Device{
-				Vendor:  "Pensando Systems",
-				Product: "DSC Ethernet Controller",
-			},
-			expectedVendor: "1dd8",
-			expectedDevice: "1003",
+			expectedDevice: "101d",
 		},
 		{
-			name: "Intel device",
+			name: "Intel E810 with raw sysfs IDs",
 			device: Device{
-				Vendor:  "Intel Corporation",
-				Product: "I350 Gigabit Network Connection",
+				Vendor:   "Intel Corporation",
+				Product:  "Ethernet Controller E810-C for QSFP",
+				VendorID: "0x8086",
+				DeviceID: "0x1592",
 			},
 			expectedVendor: "8086",
-			expectedDevice: "1520",
+			expectedDevice: "1592",
 		},
 		{
-			name: "Unknown device",
+			name: "Vendor name without IDs",
 			device: Device{
-				Vendor:  "Unknown Vendor",
-				Product: "Unknown Product",
+				Vendor:  "Mellanox Technologies",
+				Product: "MT2910 Family [ConnectX-7]",
 			},
 			expectedVendor: "",
 			expectedDevice: "",
@@ -122,6 +117,8 @@ func TestConfigureDevice(t *testing.T) {
 				PCIAddress:   "0000:31:00.0",
 				Vendor:       "Mellanox Technologies",
 				Product:      "MT2910 Family [ConnectX-7]",
+				VendorID:     "15b3",
+				DeviceID:     "101e",
 				SRIOVCapable: true,
 				SRIOVInfo: &SRIOVInfo{
 					TotalVFs: 16,
@@ -137,6 +134,8 @@ func TestConfigureDevice(t *testing.T) {
 				PCIAddress:   "0000:09:00.0",
 				Vendor:       "Pensando Systems",
 				Product:      "DSC Ethernet Controller",
+				VendorID:     "1dd8",
+				DeviceID:     "1003",
 				SRIOVCapable: true,
 				SRIOVInfo: &SRIOVInfo{
 					TotalVFs: 1,
@@ -152,19 +151,22 @@ func TestConfigureDevice(t *testing.T) {
 				PCIAddress:   "0000:32:00.0",
 				Vendor:       "Intel Corporation",
 				Product:      "I350 Gigabit Network Connection",
+				VendorID:     "8086",
+				DeviceID:     "1521",
 				SRIOVCapable: true,
 			},
 			expectError:    false,
 			expectedPolicy: nil,
 		},
 		{
-			name: "Device without vendor info",
+			name: "Device without PCI IDs is skipped",
 			device: Device{
 				Name:         "unknown",
 				PCIAddress:   "0000:99:00.0",
+				Vendor:       "Mellanox Technologies",
 				SRIOVCapable: true,
 			},
-			expectError:    true,
+			expectError:    false,
 			expectedPolicy: nil,
 		},
 	}
@@ -218,41 +220,39 @@ func TestEnableSRIOV(t *testing.T) {
 	}
 }
 
-// TestEnableSwitchMode tests switchdev mode enabling
-func TestEnableSwitchMode(t *testing.T) {
-	manager := NewSRIOVManager(&SRIOVConfig{})
-
+// TestDesiredEswitch tests that enable_switch is shorthand for switchdev mode
+func TestDesiredEswitch(t *testing.T) {
 	testCases := []struct {
-		name        string
-		device      Device
-		expectError bool
+		name     string
+		policy   DevicePolicy
+		expected EswitchState
 	}{
 		{
-			name: "Mellanox device",
-			device: Device{
-				Name:   "ens60f0np0",
-				Vendor: "Mellanox Technologies",
-			},
-			expectError: true, // mlxconfig not available in test environment
+			name:     "No eswitch settings",
+			policy:   DevicePolicy{},
+			expected: EswitchState{},
 		},
 		{
-			name: "Non-Mellanox device",
-			device: Device{
-				Name:   "ens61f0",
-				Vendor: "Intel Corporation",
-			},
-			expectError: false,
+			name:     "Enable switch",
+			policy:   DevicePolicy{EnableSwitch: true},
+			expected: EswitchState{Mode: EswitchModeSwitchdev},
+		},
+		{
+			name:     "Enable switch with inline mode",
+			policy:   DevicePolicy{EnableSwitch: true, Eswitch: &EswitchState{InlineMode: "transport"}},
+			expected: EswitchState{Mode: EswitchModeSwitchdev, InlineMode: "transport"},
+		},
+		{
+			name:     "Explicit legacy mode",
+			policy:   DevicePolicy{Eswitch: &EswitchState{Mode: EswitchModeLegacy}},
+			expected: EswitchState{Mode: EswitchModeLegacy},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := manager.enableSwitchMode(tc.device)
-			if tc.expectError && err == nil {
-				t.Error("Expected error, got nil")
-			}
-			if !tc.expectError && err != nil {
-				t.Errorf("Expected no error, got: %v", err)
+			if desired := tc.policy.DesiredEswitch(); desired != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, desired)
 			}
 		})
 	}
@@ -260,6 +260,10 @@ func TestEnableSwitchMode(t *testing.T) {
 
 // TestConfigureVFLagMode tests VF-LAG mode configuration
 func TestConfigureVFLagMode(t *testing.T) {
+	useVFLagSysfs(t)
+	useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	fake := useFakeBondLinks(t)
+
 	config := &SRIOVConfig{
 		BondConfigs: []BondConfig{
 			{
@@ -277,30 +281,31 @@ func TestConfigureVFLagMode(t *testing.T) {
 		Name: "ens60f0np0",
 	}
 
-	policy := &DevicePolicy{
-		Mode: ModeVFLag,
-	}
-
-	err := manager.configureVFLagMode(device, policy)
+	_, err := manager.configureVFLagMode(device, manager.findBondConfig(device.Name))
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
 	}
-}
-
-// TestCreateBondInterface tests bond interface creation
-func TestCreateBondInterface(t *testing.T) {
-	manager := NewSRIOVManager(&SRIOVConfig{})
+	if fake.masters["ens60f0np0"] != "bond0" || fake.masters["ens60f1np1"] != "bond0" {
+		t.Errorf("Expected both ports enslaved to bond0, got %v", fake.masters)
+	}
 
-	bond := &BondConfig{
-		BondName:        "test-bond",
-		SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"},
-		Mode:            "active-backup",
-		MIIMonitor:      100,
+	// mlx5 debugfs must report the LAG unless verification is skipped
+	device.PCIAddress = "0000:31:00.0"
+	device.Driver = "mlx5_core"
+	if err := os.RemoveAll(filepath.Dir(mlx5LagPath(device.PCIAddress, "state"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.configureVFLagMode(device, manager.findBondConfig(device.Name)); err == nil {
+		t.Error("Expected an error without the mlx5 LAG state")
+	}
+	manager.Config().SkipLAGVerification = true
+	if _, err := manager.configureVFLagMode(device, manager.findBondConfig(device.Name)); err != nil {
+		t.Errorf("Expected the LAG check to be skipped, got: %v", err)
 	}
 
-	err := manager.createBondInterface(bond)
-	if err != nil {
-		t.Errorf("Expected no error, got: %v", err)
+	device.Name = "ens61f0np0"
+	if _, err := manager.configureVFLagMode(device, manager.findBondConfig(device.Name)); err == nil {
+		t.Error("Expected an error without a bond configuration")
 	}
 }
 
@@ -362,6 +367,8 @@ func TestConfigureDevices(t *testing.T) {
 			PCIAddress:   "0000:31:00.0",
 			Vendor:       "Mellanox Technologies",
 			Product:      "MT2910 Family [ConnectX-7]",
+			VendorID:     "15b3",
+			DeviceID:     "101e",
 			SRIOVCapable: true,
 			SRIOVInfo: &SRIOVInfo{
 				TotalVFs: 16,
@@ -372,6 +379,8 @@ func TestConfigureDevices(t *testing.T) {
 			PCIAddress:   "0000:32:00.0",
 			Vendor:       "Intel Corporation",
 			Product:      "I350 Gigabit Network Connection",
+			VendorID:     "8086",
+			DeviceID:     "1521",
 			SRIOVCapable: true,
 		},
 	}
@@ -429,6 +438,8 @@ func TestDryRunMode(t *testing.T) {
 			PCIAddress:   "0000:31:00.0",
 			Vendor:       "Mellanox Technologies",
 			Product:      "MT2910 Family [ConnectX-7]",
+			VendorID:     "15b3",
+			DeviceID:     "101e",
 			SRIOVCapable: true,
 			SRIOVInfo: &SRIOVInfo{
 				TotalVFs: 16,
@@ -0,0 +1,441 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// Port function states of an SF
+const (
+	SFStateActive   = "active"
+	SFStateInactive = "inactive"
+)
+
+// ErrSFNotFound is returned when a PF has no SF with the requested sfnum
+var ErrSFNotFound = errors.New("SF not found")
+
+// ErrSFManaged is returned when an sfnum is inside the range a subfunctions
+// policy keeps, where reconcile would undo any change made by hand
+var ErrSFManaged = errors.New("SF is managed by a subfunctions policy")
+
+// SFPolicy describes the scalable functions a policy keeps on each matching
+// PF. SFs are created as devlink pcisf ports and need switchdev mode.
+type SFPolicy struct {
+	// Count is the number of SFs
+	Count int `json:"count"`
+	// SFNumBase is the sfnum of the first SF; SF n gets the base plus n
+	SFNumBase uint32 `json:"sfnum_base,omitempty"`
+	// HwAddr is the base MAC address; SF n gets the base plus n
+	HwAddr string `json:"hw_addr,omitempty"`
+	// State is active or inactive, active when unset
+	State string `json:"state,omitempty"`
+}
+
+// SubFunction is a scalable function of a PF
+type SubFunction struct {
+	SFNum uint32
+	PFNum uint32
+	// PortIndex and Representor identify the SF's eswitch port
+	PortIndex   uint32
+	Representor string
+	// Port function state as reported by devlink
+	HwAddr  string
+	State   string
+	OpState string
+	// AuxDevice is the auxiliary bus device of an active SF, e.g.
+	// mlx5_core.sf.2, and NetDev its network interface
+	AuxDevice string
+	NetDev    string
+}
+
+// sfPortManager creates, configures and deletes SF devlink ports
+type sfPortManager interface {
+	Add(pciAddress string, pfnum, sfnum uint32) (DevlinkPort, error)
+	// SetFunction sets the hw_addr when it is not nil and the state when
+	// it is not empty
+	SetFunction(pciAddress string, index uint32, hwAddr net.HardwareAddr, state string) error
+	Delete(pciAddress string, index uint32) error
+}
+
+// sfPorts is defined as a variable so it can be overridden in tests
+var sfPorts sfPortManager = netlinkSFPorts{}
+
+// netlinkSFPorts manages SF ports through the devlink generic netlink API
+type netlinkSFPorts struct{}
+
+func (netlinkSFPorts) Add(pciAddress string, pfnum, sfnum uint32) (DevlinkPort, error) {
+	port, err := netlink.DevLinkPortAdd("pci", pciAddress, nl.DEVLINK_PORT_FLAVOUR_PCI_SF, netlink.DevLinkPortAddAttrs{
+		PfNumber:      uint16(pfnum),
+		SfNumber:      sfnum,
+		SfNumberValid: true,
+	})
+	if err != nil {
+		return DevlinkPort{}, err
+	}
+	return DevlinkPort{
+		Handle:     fmt.Sprintf("pci/%s/%d", pciAddress, port.PortIndex),
+		PCIAddress: pciAddress,
+		Index:      port.PortIndex,
+		Netdev:     port.NetdeviceName,
+		Flavour:    PortFlavourPCISF,
+		PFNum:      pfnum,
+		SFNum:      sfnum,
+	}, nil
+}
+
+func (netlinkSFPorts) SetFunction(pciAddress string, index uint32, hwAddr net.HardwareAddr, state string) error {
+	attrs := netlink.DevlinkPortFnSetAttrs{}
+	if hwAddr != nil {
+		attrs.FnAttrs.HwAddr = hwAddr
+		attrs.HwAddrValid = true
+	}
+	if state != "" {
+		attrs.FnAttrs.State = nl.DEVLINK_PORT_FN_STATE_INACTIVE
+		if state == SFStateActive {
+			attrs.FnAttrs.State = nl.DEVLINK_PORT_FN_STATE_ACTIVE
+		}
+		attrs.StateValid = true
+	}
+	return netlink.DevlinkPortFnSet("pci", pciAddress, index, attrs)
+}
+
+func (netlinkSFPorts) Delete(pciAddress string, index uint32) error {
+	return netlink.DevLinkPortDel("pci", pciAddress, index)
+}
+
+// Validate checks the policy for values devlink would reject
+func (p *SFPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	if p.Count < 0 {
+		return fmt.Errorf("count must not be negative, got %d", p.Count)
+	}
+	if uint64(p.SFNumBase)+uint64(p.Count) > 1<<32 {
+		return fmt.Errorf("sfnum_base %d plus count %d overflows", p.SFNumBase, p.Count)
+	}
+	if err := validateSFFunction(p.HwAddr, p.State); err != nil {
+		return err
+	}
+	if p.HwAddr != "" && p.Count > 0 {
+		if _, err := offsetMAC(p.HwAddr, p.Count-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Manages reports whether sfnum is one of the SFs the policy keeps
+func (p *SFPolicy) Manages(sfnum uint32) bool {
+	return p != nil && sfnum >= p.SFNumBase && uint64(sfnum) < uint64(p.SFNumBase)+uint64(p.Count)
+}
+
+// state returns the port function state the policy's SFs should be in
+func (p *SFPolicy) state() string {
+	if p.State == "" {
+		return SFStateActive
+	}
+	return p.State
+}
+
+// validateSFFunction checks an SF hw_addr and state
+func validateSFFunction(hwAddr, state string) error {
+	if hwAddr != "" {
+		mac, err := net.ParseMAC(hwAddr)
+		if err != nil || len(mac) != 6 {
+			return fmt.Errorf("invalid hw_addr %q", hwAddr)
+		}
+		if mac[0]&1 != 0 {
+			return fmt.Errorf("hw_addr %s is not a unicast address", hwAddr)
+		}
+	}
+	if state != "" && state != SFStateActive && state != SFStateInactive {
+		return fmt.Errorf("invalid state %q, expected active or inactive", state)
+	}
+	return nil
+}
+
+// localSFPorts returns the pcisf ports of a PF's own devlink instance,
+// ordered by sfnum. SFs of external controllers are left out.
+func localSFPorts(pciAddress string, ports []DevlinkPort) []DevlinkPort {
+	var sfs []DevlinkPort
+	for _, port := range ports {
+		if port.PCIAddress == pciAddress && port.Flavour == PortFlavourPCISF && !port.External {
+			sfs = append(sfs, port)
+		}
+	}
+	sort.Slice(sfs, func(i, j int) bool { return sfs[i].SFNum < sfs[j].SFNum })
+	return sfs
+}
+
+// sfPFNum returns the pfnum new SFs of a PF are created with: the pfnum of
+// the PF's own representor ports, or its PCI function number
+func sfPFNum(pciAddress string, ports []DevlinkPort) uint32 {
+	for _, port := range ports {
+		if port.PCIAddress != pciAddress || port.External {
+			continue
+		}
+		switch port.Flavour {
+		case PortFlavourPCIPF, PortFlavourPCIVF, PortFlavourPCISF:
+			return port.PFNum
+		}
+	}
+	if i := strings.LastIndex(pciAddress, "."); i >= 0 {
+		if function, err := strconv.ParseUint(pciAddress[i+1:], 16, 32); err == nil {
+			return uint32(function)
+		}
+	}
+	return 0
+}
+
+// listSubFunctions returns the SFs of a PF from its devlink ports and the
+// auxiliary devices under its PCI device
+func listSubFunctions(pciAddress string, ports []DevlinkPort) []SubFunction {
+	aux := sfAuxDevices(pciAddress)
+	var sfs []SubFunction
+	for _, port := range localSFPorts(pciAddress, ports) {
+		sf := SubFunction{
+			SFNum:       port.SFNum,
+			PFNum:       port.PFNum,
+			PortIndex:   port.Index,
+			Representor: port.Netdev,
+		}
+		if port.Function != nil {
+			sf.HwAddr = port.Function.HwAddr
+			sf.State = port.Function.State
+			sf.OpState = port.Function.OpState
+		}
+		if device, ok := aux[port.SFNum]; ok {
+			sf.AuxDevice = device.name
+			sf.NetDev = device.netdev
+		}
+		sfs = append(sfs, sf)
+	}
+	return sfs
+}
+
+// sfAuxDevice is an SF on the auxiliary bus
+type sfAuxDevice struct {
+	name   string
+	netdev string
+}
+
+// sfAuxDevices returns the auxiliary SF devices of a PF, keyed by sfnum.
+// Active SFs appear as <driver>.sf.<id> under the PF's PCI device.
+func sfAuxDevices(pciAddress string) map[uint32]sfAuxDevice {
+	devices := make(map[uint32]sfAuxDevice)
+	dirs, _ := filepath.Glob(filepath.Join(PciDevicePath(pciAddress), "*.sf.*"))
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "sfnum"))
+		if err != nil {
+			continue
+		}
+		sfnum, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+		if err != nil {
+			continue
+		}
+		device := sfAuxDevice{name: filepath.Base(dir)}
+		// The netdev hangs off the SF itself or off its eth auxiliary device
+		for _, pattern := range []string{"net/*", "*/net/*"} {
+			if netdevs, _ := filepath.Glob(filepath.Join(dir, pattern)); len(netdevs) > 0 {
+				device.netdev = filepath.Base(netdevs[0])
+				break
+			}
+		}
+		devices[uint32(sfnum)] = device
+	}
+	return devices
+}
+
+// ensureSFs brings the SFs of a PF to the policy and returns a description
+// of each change. SFs outside the policy's sfnum range were created by hand
+// and are left alone.
+func (m *SRIOVManager) ensureSFs(device Device, policy *SFPolicy) ([]string, error) {
+	ports, err := listDevlinkPorts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devlink ports: %v", err)
+	}
+	pfnum := sfPFNum(device.PCIAddress, ports)
+	existing := make(map[uint32]DevlinkPort)
+	for _, port := range localSFPorts(device.PCIAddress, ports) {
+		existing[port.SFNum] = port
+	}
+
+	var actions []string
+	for n := 0; n < policy.Count; n++ {
+		sfnum := policy.SFNumBase + uint32(n)
+		hwAddr := ""
+		if policy.HwAddr != "" {
+			if hwAddr, err = offsetMAC(policy.HwAddr, n); err != nil {
+				return actions, err
+			}
+		}
+		var port *DevlinkPort
+		if current, ok := existing[sfnum]; ok {
+			port = &current
+		}
+		changed, err := m.ensureSF(device, pfnum, sfnum, port, hwAddr, policy.state())
+		actions = append(actions, changed...)
+		if err != nil {
+			return actions, err
+		}
+	}
+
+	return actions, nil
+}
+
+// checkSFUnmanaged refuses changes by hand to an SF that the policy of a PF
+// keeps
+func (m *SRIOVManager) checkSFUnmanaged(device Device, sfnum uint32) error {
+	policy := m.Config().GetDevicePolicyForDevice(device)
+	if policy != nil && policy.SubFunctions.Manages(sfnum) {
+		return fmt.Errorf("sf %d on %s: %w", sfnum, device.Name, ErrSFManaged)
+	}
+	return nil
+}
+
+// ensureSF creates SF sfnum if port is nil and brings its port function to
+// hwAddr and state. The hw_addr can only change while the SF is inactive.
+func (m *SRIOVManager) ensureSF(device Device, pfnum, sfnum uint32, port *DevlinkPort, hwAddr, state string) ([]string, error) {
+	var actions []string
+	target := fmt.Sprintf("pci/%s sf %d", device.PCIAddress, sfnum)
+
+	if port == nil {
+		var created DevlinkPort
+		err := m.applyNetlink(fmt.Sprintf("add sf %d on %s", sfnum, device.Name), target, "", fmt.Sprintf("pfnum %d sfnum %d", pfnum, sfnum), func() error {
+			var err error
+			created, err = sfPorts.Add(device.PCIAddress, pfnum, sfnum)
+			return err
+		})
+		if err != nil {
+			return actions, fmt.Errorf("failed to add sf %d on %s: %v", sfnum, device.Name, err)
+		}
+		actions = append(actions, fmt.Sprintf("add sf %d", sfnum))
+		port = &created
+	}
+
+	function := PortFunction{State: SFStateInactive}
+	if port.Function != nil {
+		function = *port.Function
+	}
+	setFunction := func(attr, current, desired string, mac net.HardwareAddr, newState string) error {
+		err := m.applyNetlink(fmt.Sprintf("set %s of sf %d on %s", attr, sfnum, device.Name), target+" "+attr, current, desired, func() error {
+			return sfPorts.SetFunction(device.PCIAddress, port.Index, mac, newState)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set %s of sf %d on %s: %v", attr, sfnum, device.Name, err)
+		}
+		actions = append(actions, fmt.Sprintf("set sf %d %s to %s", sfnum, attr, desired))
+		return nil
+	}
+
+	if hwAddr != "" && !strings.EqualFold(hwAddr, function.HwAddr) {
+		if function.State == SFStateActive {
+			if err := setFunction("state", function.State, SFStateInactive, nil, SFStateInactive); err != nil {
+				return actions, err
+			}
+			function.State = SFStateInactive
+		}
+		mac, _ := net.ParseMAC(hwAddr)
+		if err := setFunction("hw_addr", function.HwAddr, hwAddr, mac, ""); err != nil {
+			return actions, err
+		}
+	}
+	if state != "" && state != function.State {
+		if err := setFunction("state", function.State, state, nil, state); err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// deleteSFPort deactivates an SF and deletes its port
+func (m *SRIOVManager) deleteSFPort(device Device, port DevlinkPort) error {
+	target := fmt.Sprintf("pci/%s sf %d", device.PCIAddress, port.SFNum)
+	if port.Function != nil && port.Function.State == SFStateActive {
+		err := m.applyNetlink(fmt.Sprintf("deactivate sf %d on %s", port.SFNum, device.Name), target+" state", SFStateActive, SFStateInactive, func() error {
+			return sfPorts.SetFunction(device.PCIAddress, port.Index, nil, SFStateInactive)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to deactivate sf %d on %s: %v", port.SFNum, device.Name, err)
+		}
+	}
+	err := m.applyNetlink(fmt.Sprintf("delete sf %d on %s", port.SFNum, device.Name), target, fmt.Sprintf("port %d", port.Index), "", func() error {
+		return sfPorts.Delete(device.PCIAddress, port.Index)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sf %d on %s: %v", port.SFNum, device.Name, err)
+	}
+	return nil
+}
+
+// CreateSF creates SF sfnum on a PF, or reconfigures it if it exists, and
+// returns its resulting state. An empty hwAddr leaves the address to the
+// driver, an empty state activates the SF. SFs kept by a subfunctions
+// policy are refused with ErrSFManaged.
+func (m *SRIOVManager) CreateSF(device Device, sfnum uint32, hwAddr, state string) (SubFunction, error) {
+	if err := validateSFFunction(hwAddr, state); err != nil {
+		return SubFunction{}, err
+	}
+	if err := m.checkSFUnmanaged(device, sfnum); err != nil {
+		return SubFunction{}, err
+	}
+	if state == "" {
+		state = SFStateActive
+	}
+	ports, err := listDevlinkPorts()
+	if err != nil {
+		return SubFunction{}, fmt.Errorf("failed to list devlink ports: %v", err)
+	}
+
+	var port *DevlinkPort
+	for _, current := range localSFPorts(device.PCIAddress, ports) {
+		if current.SFNum == sfnum {
+			port = &current
+			break
+		}
+	}
+	WithFields(logrus.Fields{"pf": device.Name, "sfnum": sfnum, "state": state}).Info("Creating SF")
+	if _, err := m.ensureSF(device, sfPFNum(device.PCIAddress, ports), sfnum, port, hwAddr, state); err != nil {
+		return SubFunction{}, err
+	}
+
+	if ports, err = listDevlinkPorts(); err != nil {
+		return SubFunction{}, fmt.Errorf("failed to list devlink ports: %v", err)
+	}
+	for _, sf := range listSubFunctions(device.PCIAddress, ports) {
+		if sf.SFNum == sfnum {
+			return sf, nil
+		}
+	}
+	return SubFunction{SFNum: sfnum, HwAddr: hwAddr, State: state}, nil
+}
+
+// DeleteSF deactivates and deletes SF sfnum of a PF. SFs kept by a
+// subfunctions policy are refused with ErrSFManaged.
+func (m *SRIOVManager) DeleteSF(device Device, sfnum uint32) error {
+	if err := m.checkSFUnmanaged(device, sfnum); err != nil {
+		return err
+	}
+	ports, err := listDevlinkPorts()
+	if err != nil {
+		return fmt.Errorf("failed to list devlink ports: %v", err)
+	}
+	for _, port := range localSFPorts(device.PCIAddress, ports) {
+		if port.SFNum == sfnum {
+			WithFields(logrus.Fields{"pf": device.Name, "sfnum": sfnum}).Info("Deleting SF")
+			return m.deleteSFPort(device, port)
+		}
+	}
+	return fmt.Errorf("sf %d on %s: %w", sfnum, device.Name, ErrSFNotFound)
+}
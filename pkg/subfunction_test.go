@@ -0,0 +1,244 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSFPorts keeps SF ports in memory and records every change
+type fakeSFPorts struct {
+	ports     []DevlinkPort
+	nextIndex uint32
+	calls     []string
+}
+
+// useFakeSFPorts routes SF port changes and devlink port dumps to a fake
+func useFakeSFPorts(t *testing.T, ports ...DevlinkPort) *fakeSFPorts {
+	fake := &fakeSFPorts{ports: ports, nextIndex: 32768}
+	originalPorts, originalList := sfPorts, listDevlinkPorts
+	sfPorts = fake
+	listDevlinkPorts = func() ([]DevlinkPort, error) {
+		ports := make([]DevlinkPort, len(fake.ports))
+		for i, port := range fake.ports {
+			ports[i] = port
+			if port.Function != nil {
+				function := *port.Function
+				ports[i].Function = &function
+			}
+		}
+		return ports, nil
+	}
+	t.Cleanup(func() {
+		sfPorts, listDevlinkPorts = originalPorts, originalList
+	})
+	return fake
+}
+
+func (f *fakeSFPorts) find(index uint32) *DevlinkPort {
+	for i := range f.ports {
+		if f.ports[i].Index == index {
+			return &f.ports[i]
+		}
+	}
+	return nil
+}
+
+func (f *fakeSFPorts) Add(pciAddress string, pfnum, sfnum uint32) (DevlinkPort, error) {
+	f.nextIndex++
+	port := DevlinkPort{
+		PCIAddress: pciAddress,
+		Index:      f.nextIndex,
+		Flavour:    PortFlavourPCISF,
+		Netdev:     fmt.Sprintf("en3f0pf%dsf%d", pfnum, sfnum),
+		PFNum:      pfnum,
+		SFNum:      sfnum,
+		Function:   &PortFunction{HwAddr: "00:00:00:00:00:00", State: SFStateInactive, OpState: "detached"},
+	}
+	f.ports = append(f.ports, port)
+	f.calls = append(f.calls, fmt.Sprintf("add pfnum %d sfnum %d", pfnum, sfnum))
+	return port, nil
+}
+
+func (f *fakeSFPorts) SetFunction(pciAddress string, index uint32, hwAddr net.HardwareAddr, state string) error {
+	port := f.find(index)
+	if port == nil {
+		return fmt.Errorf("port %d not found", index)
+	}
+	if hwAddr != nil {
+		if port.Function.State == SFStateActive {
+			return fmt.Errorf("hw_addr of an active SF cannot change")
+		}
+		port.Function.HwAddr = hwAddr.String()
+		f.calls = append(f.calls, fmt.Sprintf("sf %d hw_addr %s", port.SFNum, hwAddr))
+	}
+	if state != "" {
+		port.Function.State = state
+		f.calls = append(f.calls, fmt.Sprintf("sf %d state %s", port.SFNum, state))
+	}
+	return nil
+}
+
+func (f *fakeSFPorts) Delete(pciAddress string, index uint32) error {
+	for i := range f.ports {
+		if f.ports[i].Index == index {
+			f.calls = append(f.calls, fmt.Sprintf("delete sf %d", f.ports[i].SFNum))
+			f.ports = append(f.ports[:i], f.ports[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("port %d not found", index)
+}
+
+// TestReconcileSFs tests that reconcile creates, configures and deletes SFs
+// and plans the changes in dry-run mode
+func TestReconcileSFs(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	writeSysfsFiles(t, filepath.Join(root, "bus", "pci", "devices", "0000:31:00.1"), map[string]string{"sriov_numvfs": "0\n"})
+
+	useFakeEswitch(t, EswitchState{Mode: EswitchModeSwitchdev})
+	fake := useFakeSFPorts(t,
+		DevlinkPort{PCIAddress: "0000:31:00.1", Index: 131071, Flavour: PortFlavourPhysical, Netdev: "ens60f1np1"},
+		DevlinkPort{PCIAddress: "0000:31:00.1", Index: 65537, Flavour: PortFlavourPCIPF, PFNum: 1},
+		DevlinkPort{PCIAddress: "0000:31:00.1", Index: 98304, Flavour: PortFlavourPCISF, PFNum: 1, SFNum: 7,
+			Function: &PortFunction{HwAddr: "02:00:00:00:00:07", State: SFStateActive}},
+	)
+	policies := []DevicePolicy{{
+		VendorID: "15b3", DeviceID: "101e", EnableSwitch: true,
+		SubFunctions: &SFPolicy{Count: 2, SFNumBase: 88, HwAddr: "02:00:00:00:88:00"},
+	}}
+	device := Device{Name: "ens60f1np1", PCIAddress: "0000:31:00.1", VendorID: "15b3", DeviceID: "101e"}
+
+	dryRun := NewSRIOVManager(&SRIOVConfig{DryRun: true, DevicePolicies: policies})
+	if status := dryRun.reconcileDevice(device); status.State != ReconcilePlanned {
+		t.Fatalf("expected planned, got %s (%s)", status.State, status.Message)
+	}
+	if actions := dryRun.Plan().Actions; len(actions) != 6 || len(fake.calls) != 0 {
+		t.Fatalf("expected 6 planned actions and no changes, got %+v and %v", actions, fake.calls)
+	}
+
+	manager := NewSRIOVManager(&SRIOVConfig{DevicePolicies: policies})
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileApplied {
+		t.Fatalf("expected applied, got %s (%s)", status.State, status.Message)
+	}
+	expectedCalls := []string{
+		"add pfnum 1 sfnum 88", "sf 88 hw_addr 02:00:00:00:88:00", "sf 88 state active",
+		"add pfnum 1 sfnum 89", "sf 89 hw_addr 02:00:00:00:88:01", "sf 89 state active",
+	}
+	if !reflect.DeepEqual(fake.calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, fake.calls)
+	}
+
+	fake.calls = nil
+	if status := manager.reconcileDevice(device); status.State != ReconcileInSync || len(fake.calls) != 0 {
+		t.Errorf("expected in-sync without changes, got %s %v", status.State, fake.calls)
+	}
+
+	// SFs in the policy's range cannot be changed by hand, while sf 7
+	// outside it is left to whoever created it
+	if err := manager.DeleteSF(device, 88); !errors.Is(err, ErrSFManaged) {
+		t.Errorf("expected ErrSFManaged deleting a policy SF, got %v", err)
+	}
+	if _, err := manager.CreateSF(device, 89, "", SFStateInactive); !errors.Is(err, ErrSFManaged) {
+		t.Errorf("expected ErrSFManaged creating a policy SF, got %v", err)
+	}
+	if err := manager.DeleteSF(device, 7); err != nil || !reflect.DeepEqual(fake.calls, []string{"sf 7 state inactive", "delete sf 7"}) {
+		t.Errorf("expected sf 7 to be deleted by hand, got %v %v", err, fake.calls)
+	}
+}
+
+// TestCreateSFChangesHwAddr tests that an active SF is deactivated while its
+// hw_addr changes
+func TestCreateSFChangesHwAddr(t *testing.T) {
+	SetSysfsRoot(t.TempDir())
+	defer SetSysfsRoot("")
+	fake := useFakeSFPorts(t, DevlinkPort{PCIAddress: "0000:31:00.0", Index: 32769, Flavour: PortFlavourPCISF, SFNum: 88,
+		Function: &PortFunction{HwAddr: "02:00:00:00:00:01", State: SFStateActive}})
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0"}
+
+	sf, err := manager.CreateSF(device, 88, "02:00:00:00:00:02", "")
+	if err != nil {
+		t.Fatalf("CreateSF failed: %v", err)
+	}
+	expectedCalls := []string{"sf 88 state inactive", "sf 88 hw_addr 02:00:00:00:00:02", "sf 88 state active"}
+	if !reflect.DeepEqual(fake.calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, fake.calls)
+	}
+	if sf.HwAddr != "02:00:00:00:00:02" || sf.State != SFStateActive || sf.PortIndex != 32769 {
+		t.Errorf("unexpected SF %+v", sf)
+	}
+
+	if _, err := manager.CreateSF(device, 89, "", "up"); err == nil {
+		t.Error("expected an error for an invalid state")
+	}
+	if err := manager.DeleteSF(device, 89); err == nil {
+		t.Error("expected an error deleting a missing SF")
+	}
+}
+
+// TestListSubFunctions tests that SFs are matched to their auxiliary devices
+func TestListSubFunctions(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	sfDir := filepath.Join(PciDevicePath("0000:31:00.0"), "mlx5_core.sf.2")
+	writeSysfsFiles(t, sfDir, map[string]string{"sfnum": "88\n"})
+	if err := os.MkdirAll(filepath.Join(sfDir, "mlx5_core.eth.2", "net", "enp49s0f0s88"), 0755); err != nil {
+		t.Fatalf("failed to create netdev: %v", err)
+	}
+
+	ports := []DevlinkPort{
+		{PCIAddress: "0000:31:00.0", Index: 32770, Flavour: PortFlavourPCISF, SFNum: 89, Netdev: "en3f0pf0sf89"},
+		{PCIAddress: "0000:31:00.0", Index: 32769, Flavour: PortFlavourPCISF, SFNum: 88, Netdev: "en3f0pf0sf88",
+			Function: &PortFunction{HwAddr: "02:00:00:00:88:00", State: SFStateActive, OpState: "attached"}},
+		{PCIAddress: "0000:31:00.0", Index: 32771, Flavour: PortFlavourPCISF, SFNum: 90, External: true},
+		{PCIAddress: "0000:31:00.0", Index: 1, Flavour: PortFlavourPCIVF},
+	}
+	sfs := listSubFunctions("0000:31:00.0", ports)
+	expected := []SubFunction{
+		{SFNum: 88, PortIndex: 32769, Representor: "en3f0pf0sf88", HwAddr: "02:00:00:00:88:00", State: SFStateActive,
+			OpState: "attached", AuxDevice: "mlx5_core.sf.2", NetDev: "enp49s0f0s88"},
+		{SFNum: 89, PortIndex: 32770, Representor: "en3f0pf0sf89"},
+	}
+	if !reflect.DeepEqual(sfs, expected) {
+		t.Errorf("expected %+v, got %+v", expected, sfs)
+	}
+}
+
+// TestValidateSFPolicy tests validation of policy subfunctions
+func TestValidateSFPolicy(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy DevicePolicy
+		valid  bool
+	}{
+		{"valid", DevicePolicy{EnableSwitch: true, SubFunctions: &SFPolicy{Count: 4, SFNumBase: 88, HwAddr: "02:00:00:00:88:00"}}, true},
+		{"legacy eswitch", DevicePolicy{SubFunctions: &SFPolicy{Count: 4}}, false},
+		{"no sfs without switchdev", DevicePolicy{SubFunctions: &SFPolicy{Count: 0}}, true},
+		{"negative count", DevicePolicy{EnableSwitch: true, SubFunctions: &SFPolicy{Count: -1}}, false},
+		{"invalid state", DevicePolicy{EnableSwitch: true, SubFunctions: &SFPolicy{Count: 1, State: "up"}}, false},
+		{"multicast hw_addr", DevicePolicy{EnableSwitch: true, SubFunctions: &SFPolicy{Count: 1, HwAddr: "01:00:5e:00:00:01"}}, false},
+		{"sfnum overflow", DevicePolicy{EnableSwitch: true, SubFunctions: &SFPolicy{Count: 2, SFNumBase: 1<<32 - 1}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.policy.VendorID, tc.policy.DeviceID, tc.policy.NumVFs = "15b3", "101e", 4
+			err := (&SRIOVConfig{DevicePolicies: []DevicePolicy{tc.policy}}).ValidateConfig()
+			if tc.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
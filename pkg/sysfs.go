@@ -0,0 +1,52 @@
+package pkg
+
+import (
+	"path/filepath"
+)
+
+// DefaultSysfsRoot is the sysfs mount point used when no root is configured
+const DefaultSysfsRoot = "/sys"
+
+// sysfsRoot is the root of the sysfs tree used by all discovery and
+// configuration code. It is a variable so that discovery can be pointed
+// at a captured host tree in tests or a bind-mounted host /sys in a container.
+var sysfsRoot = DefaultSysfsRoot
+
+// SetSysfsRoot overrides the sysfs root used for PCI and network discovery.
+// An empty root restores the default.
+func SetSysfsRoot(root string) {
+	if root == "" {
+		root = DefaultSysfsRoot
+	}
+	sysfsRoot = filepath.Clean(root)
+}
+
+// SysfsRoot returns the currently configured sysfs root
+func SysfsRoot() string {
+	return sysfsRoot
+}
+
+// SysfsPath joins path elements onto the configured sysfs root
+func SysfsPath(elem ...string) string {
+	return filepath.Join(append([]string{sysfsRoot}, elem...)...)
+}
+
+// PciDevicesPath returns the sysfs directory holding PCI devices
+func PciDevicesPath() string {
+	return SysfsPath("bus", "pci", "devices")
+}
+
+// PciDevicePath returns the sysfs directory for a single PCI device
+func PciDevicePath(pciAddress string) string {
+	return SysfsPath("bus", "pci", "devices", pciAddress)
+}
+
+// PciDriversPath returns the sysfs directory holding PCI drivers
+func PciDriversPath() string {
+	return SysfsPath("bus", "pci", "drivers")
+}
+
+// NetClassPath returns the sysfs directory holding network interfaces
+func NetClassPath() string {
+	return SysfsPath("class", "net")
+}
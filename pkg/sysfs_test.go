@@ -0,0 +1,116 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeSysfsFiles creates a set of sysfs attribute files under dir
+func writeSysfsFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// TestSetSysfsRoot tests sysfs root overrides and path helpers
+func TestSetSysfsRoot(t *testing.T) {
+	defer SetSysfsRoot("")
+
+	if SysfsRoot() != DefaultSysfsRoot {
+		t.Errorf("expected default root %s, got %s", DefaultSysfsRoot, SysfsRoot())
+	}
+
+	SetSysfsRoot("/host/sys/")
+	if SysfsRoot() != "/host/sys" {
+		t.Errorf("expected cleaned root /host/sys, got %s", SysfsRoot())
+	}
+	if PciDevicesPath() != "/host/sys/bus/pci/devices" {
+		t.Errorf("unexpected PCI devices path: %s", PciDevicesPath())
+	}
+	if PciDevicePath("0000:01:00.0") != "/host/sys/bus/pci/devices/0000:01:00.0" {
+		t.Errorf("unexpected PCI device path: %s", PciDevicePath("0000:01:00.0"))
+	}
+	if NetClassPath() != "/host/sys/class/net" {
+		t.Errorf("unexpected net class path: %s", NetClassPath())
+	}
+
+	SetSysfsRoot("")
+	if SysfsRoot() != DefaultSysfsRoot {
+		t.Errorf("expected empty root to restore default, got %s", SysfsRoot())
+	}
+}
+
+// TestParseSysfsPciDevicesWithRoot tests discovery against a captured sysfs tree
+func TestParseSysfsPciDevicesWithRoot(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	writeSysfsFiles(t, filepath.Join(devicesDir, "0000:31:00.0"), map[string]string{
+		"vendor":         "0x15b3\n",
+		"device":         "0x101e\n",
+		"class":          "0x020000\n",
+		"numa_node":      "1\n",
+		"sriov_totalvfs": "16\n",
+		"sriov_numvfs":   "4\n",
+	})
+	// Entries that are not PCI addresses must be ignored
+	writeSysfsFiles(t, filepath.Join(devicesDir, "not-a-device"), nil)
+
+	devices, err := ParseSysfsPciDevices()
+	if err != nil {
+		t.Fatalf("ParseSysfsPciDevices returned error: %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(devices))
+	}
+
+	device := devices[0]
+	if device.Bus != "0000:31:00.0" {
+		t.Errorf("expected bus 0000:31:00.0, got %s", device.Bus)
+	}
+	if !device.SRIOVCapable || device.SRIOVInfo == nil {
+		t.Fatal("expected device to be SR-IOV capable")
+	}
+	if device.SRIOVInfo.TotalVFs != 16 || device.SRIOVInfo.NumberOfVFs != 4 {
+		t.Errorf("expected 4/16 VFs, got %d/%d", device.SRIOVInfo.NumberOfVFs, device.SRIOVInfo.TotalVFs)
+	}
+	if device.NUMANode != 1 {
+		t.Errorf("expected NUMA node 1, got %d", device.NUMANode)
+	}
+}
+
+// TestEnableSRIOVWithRoot tests that enableSRIOV writes under the configured root
+func TestEnableSRIOVWithRoot(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	deviceDir := filepath.Join(root, "bus", "pci", "devices", "0000:31:00.0")
+	writeSysfsFiles(t, deviceDir, map[string]string{
+		"sriov_numvfs": "0",
+	})
+
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0"}
+	if err := manager.enableSRIOV(device, 4); err != nil {
+		t.Fatalf("enableSRIOV failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(deviceDir, "sriov_numvfs"))
+	if err != nil {
+		t.Fatalf("failed to read sriov_numvfs: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "4" {
+		t.Errorf("expected sriov_numvfs 4, got %q", string(data))
+	}
+}
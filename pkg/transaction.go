@@ -0,0 +1,256 @@
+package pkg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Steps of a device transaction, reported when one fails
+const (
+	StepEswitch      = "eswitch"
+	StepVFCount      = "vf-count"
+	StepVFSettings   = "vf-settings"
+	StepVFDriver     = "vf-driver"
+	StepSubFunctions = "subfunctions"
+	StepVFLag        = "vf-lag"
+)
+
+// TransactionError reports the step of a device transaction that failed and
+// whether the device was restored to its prior state
+type TransactionError struct {
+	Device string
+	Step   string
+	Err    error
+	// RolledBack is set when the prior state was restored, RollbackErr
+	// when restoring it failed
+	RolledBack  bool
+	RollbackErr error
+}
+
+func (e *TransactionError) Error() string {
+	switch {
+	case e.RollbackErr != nil:
+		return fmt.Sprintf("%s step failed on %s: %v; rollback failed: %v", e.Step, e.Device, e.Err, e.RollbackErr)
+	case e.RolledBack:
+		return fmt.Sprintf("%s step failed on %s, changes rolled back: %v", e.Step, e.Device, e.Err)
+	default:
+		return fmt.Sprintf("%s step failed on %s: %v", e.Step, e.Device, e.Err)
+	}
+}
+
+func (e *TransactionError) Unwrap() error { return e.Err }
+
+// deviceTransaction records the state of a device before each step that
+// changes it, so a failed step can put the device back the way it was
+type deviceTransaction struct {
+	m      *SRIOVManager
+	device Device
+	undo   []undoStep
+}
+
+// undoStep restores one piece of saved state, doing nothing if it is
+// already in that state
+type undoStep struct {
+	description string
+	restore     func() error
+}
+
+// beginTransaction starts a transaction for the changes to one device
+func (m *SRIOVManager) beginTransaction(device Device) *deviceTransaction {
+	return &deviceTransaction{m: m, device: device}
+}
+
+func (tx *deviceTransaction) save(description string, restore func() error) {
+	tx.undo = append(tx.undo, undoStep{description: description, restore: restore})
+}
+
+// abort rolls back every saved state, most recent first, and wraps err with
+// the step that failed. Nothing is rolled back in dry-run mode, where
+// nothing was changed.
+func (tx *deviceTransaction) abort(step string, err error) error {
+	txErr := &TransactionError{Device: tx.device.Name, Step: step, Err: err}
+	if tx.m.Config().DryRun || len(tx.undo) == 0 {
+		return txErr
+	}
+
+	fields := logrus.Fields{"device": tx.device.Name, "pci": tx.device.PCIAddress, "step": step}
+	WithFields(fields).WithError(err).Warn("Device step failed, rolling back")
+
+	var failed []string
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		if err := tx.undo[i].restore(); err != nil {
+			WithFields(fields).WithError(err).Error("Failed to restore " + tx.undo[i].description)
+			failed = append(failed, fmt.Sprintf("%s: %v", tx.undo[i].description, err))
+		}
+	}
+	tx.undo = nil
+
+	if len(failed) > 0 {
+		txErr.RollbackErr = fmt.Errorf("%s", strings.Join(failed, "; "))
+	} else {
+		txErr.RolledBack = true
+		WithFields(fields).Info("Device restored to its prior state")
+	}
+	return txErr
+}
+
+// saveVFCount saves the VF count of the PF
+func (tx *deviceTransaction) saveVFCount() {
+	device := tx.device
+	sriovPath := filepath.Join(PciDevicePath(device.PCIAddress), "sriov_numvfs")
+	value, err := tx.m.readSysfsValue(sriovPath)
+	if err != nil {
+		return
+	}
+	prior, _ := strconv.Atoi(value)
+
+	tx.save("VF count", func() error {
+		current := 0
+		if value, err := tx.m.readSysfsValue(sriovPath); err == nil {
+			current, _ = strconv.Atoi(value)
+		}
+		if current == prior {
+			return nil
+		}
+		if current != 0 {
+			if err := tx.m.writeSysfs(fmt.Sprintf("roll back VFs on %s", device.Name), sriovPath, "0"); err != nil {
+				return err
+			}
+		}
+		if prior == 0 {
+			return nil
+		}
+		return tx.m.writeSysfs(fmt.Sprintf("roll back VF count on %s", device.Name), sriovPath, strconv.Itoa(prior))
+	})
+}
+
+// saveEswitch saves the eswitch state of the PF. Restoring it destroys the
+// VFs, so it must be saved after the VF count, which recreates them.
+func (tx *deviceTransaction) saveEswitch() {
+	device := tx.device
+	prior, err := getEswitchState(device.PCIAddress)
+	if err != nil || prior.IsZero() {
+		// Without an eswitch there is nothing a step could have changed
+		return
+	}
+
+	tx.save("eswitch", func() error {
+		current, err := getEswitchState(device.PCIAddress)
+		if err != nil {
+			return err
+		}
+		change := prior.changes(current)
+		if change.IsZero() {
+			return nil
+		}
+		sriovPath := filepath.Join(PciDevicePath(device.PCIAddress), "sriov_numvfs")
+		if value, err := tx.m.readSysfsValue(sriovPath); err == nil && value != "0" {
+			if err := tx.m.writeSysfs(fmt.Sprintf("destroy VFs on %s before rolling back eswitch", device.Name), sriovPath, "0"); err != nil {
+				return err
+			}
+		}
+		target := fmt.Sprintf("pci/%s eswitch", device.PCIAddress)
+		return tx.m.applyNetlink(fmt.Sprintf("roll back eswitch to %s on %s", change, device.Name), target, current.String(), change.String(), func() error {
+			return setEswitchState(device.PCIAddress, change)
+		})
+	})
+}
+
+// saveVFDrivers saves the driver and driver_override of each VF of the PF
+func (tx *deviceTransaction) saveVFDrivers() {
+	vfs, err := parseVirtualFunctions(PciDevicePath(tx.device.PCIAddress))
+	if err != nil {
+		return
+	}
+
+	for _, vf := range vfs {
+		address, driver := vf.PCIAddress, vf.Driver
+		overridePath := filepath.Join(PciDevicePath(address), "driver_override")
+		override, _ := tx.m.readSysfsValue(overridePath)
+		if override == "(null)" {
+			override = ""
+		}
+
+		tx.save("driver of "+address, func() error {
+			current, _ := tx.m.readSysfsValue(overridePath)
+			if current == "(null)" {
+				current = ""
+			}
+			if BoundDriver(address) == driver && current == override {
+				return nil
+			}
+			tx.m.rollbackBind(address, override, driver)
+			if bound := BoundDriver(address); bound != driver {
+				return fmt.Errorf("%s is bound to %q instead of %q", address, bound, driver)
+			}
+			return nil
+		})
+	}
+}
+
+// saveBond saves whether a bond exists, its options and the master of each
+// of its slaves
+func (tx *deviceTransaction) saveBond(bond *BondConfig) {
+	prior, err := bondLinks.Bond(bond.BondName)
+	if err != nil {
+		return
+	}
+	masters := make(map[string]string)
+	for _, slave := range bond.SlaveInterfaces {
+		master, err := bondLinks.Master(slave)
+		if err != nil {
+			continue
+		}
+		masters[slave] = master
+	}
+
+	tx.save("bond "+bond.BondName, func() error {
+		for _, slave := range bond.SlaveInterfaces {
+			priorMaster, ok := masters[slave]
+			if !ok {
+				continue
+			}
+			current, err := bondLinks.Master(slave)
+			if err != nil {
+				return err
+			}
+			if current == priorMaster {
+				continue
+			}
+			if priorMaster == "" {
+				err = tx.m.applyNetlink(fmt.Sprintf("release %s from %s", slave, current), slave+" master", current, "", func() error {
+					return bondLinks.Release(slave)
+				})
+			} else {
+				err = tx.m.applyNetlink(fmt.Sprintf("enslave %s back to %s", slave, priorMaster), slave+" master", current, priorMaster, func() error {
+					return bondLinks.Enslave(slave, priorMaster)
+				})
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		current, err := bondLinks.Bond(bond.BondName)
+		if err != nil || current == nil {
+			return err
+		}
+		if prior == nil {
+			return tx.m.applyNetlink(fmt.Sprintf("delete bond %s", bond.BondName), bond.BondName, "present", "absent", func() error {
+				return bondLinks.Delete(bond.BondName)
+			})
+		}
+		priorConfig := BondConfig{BondName: bond.BondName, MIIMonitor: prior.MIIMonitor, LACPRate: prior.LACPRate, XmitHashPolicy: prior.XmitHashPolicy}
+		if diff := priorConfig.changes(*current); diff.hasOptions() {
+			currentConfig := BondConfig{MIIMonitor: current.MIIMonitor, LACPRate: current.LACPRate, XmitHashPolicy: current.XmitHashPolicy}
+			return tx.m.applyNetlink(fmt.Sprintf("roll back bond %s", bond.BondName), bond.BondName, currentConfig.String(), diff.String(), func() error {
+				return bondLinks.Modify(diff)
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// useFailingVFLag sets up a ConnectX whose first port is legacy and whose
+// second port, already in switchdev, cannot join the bond, so a VF-LAG
+// policy fails after every other step has been applied
+func useFailingVFLag(t *testing.T) (*fakeEswitch, *fakeBondLinks, *SRIOVManager) {
+	useVFLagSysfs(t)
+	writeSysfsFiles(t, PciDevicePath("0000:31:00.0"), map[string]string{"sriov_numvfs": "2\n"})
+	eswitch := useFakeEswitch(t, EswitchState{Mode: EswitchModeLegacy})
+	eswitch.states["0000:31:00.1"] = EswitchState{Mode: EswitchModeSwitchdev}
+	bonds := useFakeBondLinks(t)
+	bonds.failEnslave = "ens60f1np1"
+
+	manager := NewSRIOVManager(&SRIOVConfig{
+		DevicePolicies: []DevicePolicy{
+			{VendorID: "15b3", DeviceID: "101e", NumVFs: 4, Mode: ModeVFLag, EnableSwitch: true},
+		},
+		BondConfigs: []BondConfig{
+			{BondName: "bond0", SlaveInterfaces: []string{"ens60f0np0", "ens60f1np1"}, Mode: "802.3ad"},
+		},
+	})
+	return eswitch, bonds, manager
+}
+
+// TestReconcileDeviceRollback tests that a failed step restores the VF
+// count, eswitch mode and bond membership the device had before
+func TestReconcileDeviceRollback(t *testing.T) {
+	eswitch, bonds, manager := useFailingVFLag(t)
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileFailed || status.FailedStep != StepVFLag {
+		t.Fatalf("expected the vf-lag step to fail, got %s %q (%s)", status.State, status.FailedStep, status.Message)
+	}
+	if !strings.Contains(status.Message, "rolled back") {
+		t.Errorf("expected the message to report the rollback, got %q", status.Message)
+	}
+
+	expectedEswitch := []string{
+		"sriov_numvfs=0", "eswitch mode switchdev", "sriov_numvfs=4",
+		"sriov_numvfs=0", "eswitch mode legacy", "sriov_numvfs=2",
+	}
+	if !reflect.DeepEqual(eswitch.calls, expectedEswitch) {
+		t.Errorf("expected eswitch calls %v, got %v", expectedEswitch, eswitch.calls)
+	}
+	if eswitch.state("0000:31:00.0").Mode != EswitchModeLegacy {
+		t.Errorf("expected the eswitch to be legacy again, got %s", eswitch.state("0000:31:00.0").Mode)
+	}
+
+	expectedBond := []string{
+		"add bond mode 802.3ad", "enslave ens60f0np0 to bond0",
+		"release ens60f0np0", "delete bond0",
+	}
+	if !reflect.DeepEqual(bonds.calls, expectedBond) {
+		t.Errorf("expected bond calls %v, got %v", expectedBond, bonds.calls)
+	}
+	if len(bonds.bonds) != 0 || len(bonds.masters) != 0 {
+		t.Errorf("expected no bond left behind, got %v %v", bonds.bonds, bonds.masters)
+	}
+}
+
+// TestConfigureDeviceRollback tests that configureDevice reports the failed
+// step and that an existing bond keeps its slaves
+func TestConfigureDeviceRollback(t *testing.T) {
+	eswitch, bonds, manager := useFailingVFLag(t)
+	bonds.bonds["bond0"] = &BondState{Mode: "802.3ad", LACPRate: "slow", Up: true}
+	bonds.masters["ens60f0np0"] = "bond1"
+	manager.Config().BondConfigs[0].LACPRate = "fast"
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	err := manager.configureDevice(device)
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) || txErr.Step != StepVFLag || !txErr.RolledBack {
+		t.Fatalf("expected a rolled back vf-lag step, got %v", err)
+	}
+
+	expectedBond := []string{
+		"modify bond lacp_rate fast", "enslave ens60f0np0 to bond0",
+		"enslave ens60f0np0 to bond1", "modify bond lacp_rate slow",
+	}
+	if !reflect.DeepEqual(bonds.calls, expectedBond) {
+		t.Errorf("expected bond calls %v, got %v", expectedBond, bonds.calls)
+	}
+	if bonds.masters["ens60f0np0"] != "bond1" || bonds.bonds["bond0"].LACPRate != "slow" {
+		t.Errorf("expected the prior bond state, got %v %+v", bonds.masters, bonds.bonds["bond0"])
+	}
+
+	data, err := os.ReadFile(filepath.Join(PciDevicePath("0000:31:00.0"), "sriov_numvfs"))
+	if err != nil || strings.TrimSpace(string(data)) != "2" || eswitch.state("0000:31:00.0").Mode != EswitchModeLegacy {
+		t.Errorf("expected 2 VFs in legacy mode, got %q %s (%v)", data, eswitch.state("0000:31:00.0").Mode, err)
+	}
+}
+
+// TestTransactionAbort tests rollback failures and that dry runs restore
+// nothing
+func TestTransactionAbort(t *testing.T) {
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	device := Device{Name: "ens60f0np0"}
+	stepErr := fmt.Errorf("%w: vf0", ErrVFsInUse)
+
+	tx := manager.beginTransaction(device)
+	var restored []string
+	tx.save("first", func() error { restored = append(restored, "first"); return nil })
+	tx.save("second", func() error { restored = append(restored, "second"); return fmt.Errorf("busy") })
+	err := tx.abort(StepVFCount, stepErr)
+	var txErr *TransactionError
+	if !errors.As(err, &txErr) || txErr.RolledBack || txErr.RollbackErr == nil || !errors.Is(err, ErrVFsInUse) {
+		t.Errorf("expected a failed rollback wrapping the step error, got %v", err)
+	}
+	if !reflect.DeepEqual(restored, []string{"second", "first"}) {
+		t.Errorf("expected rollback in reverse order, got %v", restored)
+	}
+	if !strings.Contains(err.Error(), "rollback failed: second: busy") {
+		t.Errorf("unexpected error %q", err)
+	}
+
+	manager.Config().DryRun = true
+	tx = manager.beginTransaction(device)
+	tx.save("first", func() error { t.Error("expected no rollback in dry-run mode"); return nil })
+	if err := tx.abort(StepVFCount, stepErr); err.Error() != "vf-count step failed on ens60f0np0: VFs in use: vf0" {
+		t.Errorf("unexpected error %q", err)
+	}
+}
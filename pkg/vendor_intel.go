@@ -0,0 +1,75 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// vfSettleTimeout bounds how long PostVFChange waits for new VFs to probe
+var vfSettleTimeout = 10 * time.Second
+
+// intelPlugin handles Intel E800 (ice) and X700 (i40e) adapters. Both probe
+// their VFs asynchronously, and VF settings written before iavf has bound
+// are refused, so PostVFChange waits for the VFs to settle.
+type intelPlugin struct {
+	genericPlugin
+	driver string
+}
+
+func (p *intelPlugin) Name() string { return p.driver }
+
+func (p *intelPlugin) Identify(device Device) bool {
+	return device.Driver == p.driver
+}
+
+// SetSwitchMode uses devlink on ice; i40e has no switchdev mode
+func (p *intelPlugin) SetSwitchMode(m *SRIOVManager, device Device, desired EswitchState) (EswitchState, error) {
+	if p.driver == "i40e" {
+		return rejectSwitchdev(p.driver, device, desired)
+	}
+	return p.genericPlugin.SetSwitchMode(m, device, desired)
+}
+
+func (p *intelPlugin) PostVFChange(m *SRIOVManager, device Device, numVFs int) error {
+	if m.Config().DryRun || numVFs == 0 {
+		return nil
+	}
+	return waitForVFDrivers(device, numVFs, vfSettleTimeout)
+}
+
+// waitForVFDrivers waits until a PF has numVFs VFs that are bound to a
+// driver. VFs are not probed when sriov_drivers_autoprobe is off, so only
+// their creation is awaited then.
+func waitForVFDrivers(device Device, numVFs int, timeout time.Duration) error {
+	devicePath := PciDevicePath(device.PCIAddress)
+	autoprobe := true
+	if data, err := os.ReadFile(filepath.Join(devicePath, "sriov_drivers_autoprobe")); err == nil {
+		autoprobe = strings.TrimSpace(string(data)) != "0"
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		vfs, err := parseVirtualFunctions(devicePath)
+		if err != nil {
+			return fmt.Errorf("failed to list VFs of %s: %v", device.Name, err)
+		}
+		pending := numVFs - len(vfs)
+		if autoprobe {
+			for _, vf := range vfs {
+				if vf.Driver == "" {
+					pending++
+				}
+			}
+		}
+		if pending <= 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%d of %d VFs on %s not ready after %s", pending, numVFs, device.Name, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,17 @@
+package pkg
+
+// ionicPlugin handles AMD Pensando DSC adapters. The ionic driver has no
+// eswitch, so switchdev policies are rejected instead of failing in devlink.
+type ionicPlugin struct {
+	genericPlugin
+}
+
+func (*ionicPlugin) Name() string { return "ionic" }
+
+func (*ionicPlugin) Identify(device Device) bool {
+	return device.Driver == "ionic" || normalizePciID(device.VendorID) == "1dd8"
+}
+
+func (*ionicPlugin) SetSwitchMode(m *SRIOVManager, device Device, desired EswitchState) (EswitchState, error) {
+	return rejectSwitchdev("ionic", device, desired)
+}
@@ -0,0 +1,189 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// mlx5Settings are the mlxconfig settings that bound SR-IOV on ConnectX
+var mlx5Settings = []string{"SRIOV_EN", "NUM_OF_VFS"}
+
+// queryMlxconfig is defined as a variable so it can be overridden in tests
+var queryMlxconfig = func(pciAddress string, names ...string) ([]byte, error) {
+	if _, err := exec.LookPath("mlxconfig"); err != nil {
+		return nil, err
+	}
+	args := append([]string{"-d", pciAddress, "-e", "q"}, names...)
+	return exec.Command("mlxconfig", args...).CombinedOutput()
+}
+
+// mlx5Plugin handles NVIDIA/Mellanox ConnectX devices. The eswitch is
+// managed through devlink; mlxconfig is only used, when installed, to read
+// the firmware settings that limit SR-IOV.
+type mlx5Plugin struct {
+	genericPlugin
+
+	// settings caches the mlxconfig query of each device; the current
+	// values only change with a firmware reset
+	settingsMu sync.Mutex
+	settings   map[string]map[string]string
+}
+
+func (*mlx5Plugin) Name() string { return "mlx5" }
+
+// Identify matches devices bound to mlx5_core and, when the driver is not
+// known, Mellanox devices in the mlx5 device ID ranges. ConnectX-3 and older
+// use mlx4, which has no devlink eswitch or mlx5 LAG, and fall back to the
+// generic plugin.
+func (*mlx5Plugin) Identify(device Device) bool {
+	if device.Driver != "" {
+		return device.Driver == "mlx5_core"
+	}
+	return normalizePciID(device.VendorID) == "15b3" && isMlx5DeviceID(device.DeviceID)
+}
+
+// isMlx5DeviceID reports whether a Mellanox device ID belongs to mlx5:
+// Connect-IB and ConnectX-4 onwards (0x1011-0x10ff) and BlueField
+// (0xa2d2-0xa2df), including their VFs
+func isMlx5DeviceID(deviceID string) bool {
+	id, err := strconv.ParseUint(normalizePciID(deviceID), 16, 16)
+	if err != nil {
+		return false
+	}
+	return (id >= 0x1011 && id <= 0x10ff) || (id >= 0xa2d2 && id <= 0xa2df)
+}
+
+// FirmwareInfo adds the SR-IOV firmware settings to the devlink versions
+func (p *mlx5Plugin) FirmwareInfo(device Device) (FirmwareInfo, error) {
+	info, err := p.genericPlugin.FirmwareInfo(device)
+	if settings := p.firmwareSettings(device.PCIAddress); settings != nil {
+		info.Settings = settings
+		return info, nil
+	}
+	return info, err
+}
+
+// firmwareSettings returns the cached mlxconfig settings of a device, nil
+// when mlxconfig is not installed or fails
+func (p *mlx5Plugin) firmwareSettings(pciAddress string) map[string]string {
+	p.settingsMu.Lock()
+	defer p.settingsMu.Unlock()
+	if settings, ok := p.settings[pciAddress]; ok {
+		return settings
+	}
+
+	var settings map[string]string
+	if output, err := queryMlxconfig(pciAddress, mlx5Settings...); err != nil {
+		WithField("pci", pciAddress).WithError(err).Debug("No mlxconfig settings")
+	} else {
+		settings = parseMlxconfigQuery(output)
+	}
+	if p.settings == nil {
+		p.settings = make(map[string]map[string]string)
+	}
+	p.settings[pciAddress] = settings
+	return settings
+}
+
+// MaxVFs also honours the firmware's SRIOV_EN and NUM_OF_VFS settings, so a
+// ConnectX with SR-IOV disabled in firmware supports no VFs
+func (p *mlx5Plugin) MaxVFs(device Device) int {
+	limit := p.genericPlugin.MaxVFs(device)
+	settings := p.firmwareSettings(device.PCIAddress)
+	if enabled, ok := settings["SRIOV_EN"]; ok && mlxconfigValue(enabled) == 0 {
+		return 0
+	}
+	if numVFs := mlxconfigValue(settings["NUM_OF_VFS"]); numVFs > 0 && (limit < 0 || numVFs < limit) {
+		return numVFs
+	}
+	return limit
+}
+
+// lagSettleTimeout bounds how long VerifyLAG waits for the hardware LAG
+var lagSettleTimeout = 5 * time.Second
+
+// mlx5LagPath returns a file of the LAG state mlx5 exposes in debugfs
+func mlx5LagPath(pciAddress, name string) string {
+	return SysfsPath("kernel", "debug", "mlx5", pciAddress, "lag", name)
+}
+
+// VerifyLAG waits for the LAG state in mlx5 debugfs to become active. The
+// driver activates it shortly after both ports of a ConnectX are enslaved
+// to the same bond while in switchdev mode. mlx5 reports the LAG state
+// nowhere else, so the bond fails when debugfs cannot be read; set
+// skip_lag_verification to accept such bonds unverified.
+func (*mlx5Plugin) VerifyLAG(device Device, bond *BondConfig) error {
+	statePath := mlx5LagPath(device.PCIAddress, "state")
+	deadline := time.Now().Add(lagSettleTimeout)
+	for {
+		data, err := os.ReadFile(statePath)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("cannot verify hardware LAG of %s on %s: %s does not exist; "+
+				"mount debugfs on /sys/kernel/debug or set skip_lag_verification",
+				bond.BondName, device.Name, statePath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read LAG state of %s: %v", device.Name, err)
+		}
+		state := strings.TrimSpace(string(data))
+		if state == "active" {
+			lagType, _ := os.ReadFile(mlx5LagPath(device.PCIAddress, "type"))
+			WithFields(logrus.Fields{
+				"device": device.Name,
+				"bond":   bond.BondName,
+				"type":   strings.TrimSpace(string(lagType)),
+			}).Debug("Hardware LAG active")
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("hardware LAG did not engage for %s on %s: mlx5 LAG state is %q; "+
+				"both ports must be enslaved to %s in switchdev mode, with bond mode active-backup, balance-xor or 802.3ad",
+				bond.BondName, device.Name, state, bond.BondName)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// parseMlxconfigQuery reads the current values from `mlxconfig -e q`, whose
+// configuration rows are name, default, current and next boot value
+func parseMlxconfigQuery(output []byte) map[string]string {
+	settings := make(map[string]string)
+	inConfig := false
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Configurations:") {
+			inConfig = true
+			continue
+		}
+		// Modified settings are marked with a leading *
+		fields := strings.Fields(strings.TrimPrefix(line, "*"))
+		if !inConfig || len(fields) < 3 {
+			continue
+		}
+		settings[fields[0]] = fields[len(fields)-2]
+	}
+	return settings
+}
+
+// mlxconfigValue converts values like True(1) or 16 to a number, -1 when
+// the value is not numeric
+func mlxconfigValue(value string) int {
+	if start := strings.Index(value, "("); start >= 0 && strings.HasSuffix(value, ")") {
+		value = value[start+1 : len(value)-1]
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
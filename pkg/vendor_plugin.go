@@ -0,0 +1,165 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+)
+
+// VendorPlugin implements the vendor and driver specific steps of device
+// configuration. The manager core only talks to devices through the plugin
+// that identifies them, so new hardware needs a plugin rather than changes
+// to the core.
+type VendorPlugin interface {
+	// Name is a short name for logs, e.g. mlx5
+	Name() string
+	// Identify reports whether the plugin handles a device
+	Identify(device Device) bool
+	// FirmwareInfo queries the firmware of a device
+	FirmwareInfo(device Device) (FirmwareInfo, error)
+	// SetSwitchMode brings the eswitch of a PF to desired and returns the
+	// change it made, if any
+	SetSwitchMode(m *SRIOVManager, device Device, desired EswitchState) (EswitchState, error)
+	// MaxVFs returns the number of VFs a PF supports, -1 when unknown
+	MaxVFs(device Device) int
+	// PreVFChange runs before the VF count of a PF changes from current
+	// to numVFs, PostVFChange after it has changed
+	PreVFChange(m *SRIOVManager, device Device, current, numVFs int) error
+	PostVFChange(m *SRIOVManager, device Device, numVFs int) error
+	// VerifyLAG checks that the hardware offloads a VF-LAG bond the PF
+	// has been enslaved to
+	VerifyLAG(device Device, bond *BondConfig) error
+}
+
+// FirmwareInfo describes the firmware of a device
+type FirmwareInfo struct {
+	// Version is the running firmware version
+	Version string
+	// Versions are the versions reported by `devlink dev info`, by name
+	Versions map[string]string
+	// Settings are non-volatile firmware settings, e.g. from mlxconfig
+	Settings map[string]string
+}
+
+// String formats the running version and any firmware settings for logs
+func (info FirmwareInfo) String() string {
+	parts := []string{info.Version}
+	names := make([]string, 0, len(info.Settings))
+	for name := range info.Settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, info.Settings[name]))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+var (
+	vendorPluginsMu sync.RWMutex
+	// vendorPlugins are consulted from the most recently registered
+	vendorPlugins []VendorPlugin
+)
+
+func init() {
+	RegisterVendorPlugin(&ionicPlugin{})
+	RegisterVendorPlugin(&intelPlugin{driver: "i40e"})
+	RegisterVendorPlugin(&intelPlugin{driver: "ice"})
+	RegisterVendorPlugin(&mlx5Plugin{})
+}
+
+// RegisterVendorPlugin adds a plugin. Plugins registered later take
+// precedence, so a built-in plugin can be replaced by registering another
+// that identifies the same devices.
+func RegisterVendorPlugin(plugin VendorPlugin) {
+	vendorPluginsMu.Lock()
+	defer vendorPluginsMu.Unlock()
+	vendorPlugins = append([]VendorPlugin{plugin}, vendorPlugins...)
+}
+
+// VendorPluginFor returns the plugin that handles a device, falling back to
+// a generic plugin that uses only kernel interfaces
+func VendorPluginFor(device Device) VendorPlugin {
+	vendorPluginsMu.RLock()
+	defer vendorPluginsMu.RUnlock()
+	for _, plugin := range vendorPlugins {
+		if plugin.Identify(device) {
+			return plugin
+		}
+	}
+	return genericPlugin{}
+}
+
+// genericPlugin configures devices through sysfs, devlink and netlink only.
+// Vendor plugins embed it and override the steps that differ.
+type genericPlugin struct{}
+
+func (genericPlugin) Name() string { return "generic" }
+
+func (genericPlugin) Identify(device Device) bool { return true }
+
+// FirmwareInfo reads the versions devlink reports, falling back to the
+// firmware version from ethtool
+func (genericPlugin) FirmwareInfo(device Device) (FirmwareInfo, error) {
+	info := FirmwareInfo{}
+	versions, err := getDevlinkInfo(device.PCIAddress)
+	if err == nil {
+		info.Versions = versions
+		for _, name := range []string{"fw.version", "fw", "fw.mgmt"} {
+			if versions[name] != "" {
+				info.Version = versions[name]
+				break
+			}
+		}
+	}
+	if info.Version == "" {
+		if firmware, ok := device.Configuration["firmware"].(string); ok {
+			info.Version = firmware
+		}
+	}
+	if info.Version == "" && info.Versions == nil {
+		return info, fmt.Errorf("no firmware information for %s: %v", device.PCIAddress, err)
+	}
+	return info, nil
+}
+
+func (genericPlugin) SetSwitchMode(m *SRIOVManager, device Device, desired EswitchState) (EswitchState, error) {
+	return m.ensureEswitch(device, desired)
+}
+
+func (genericPlugin) MaxVFs(device Device) int {
+	if device.SRIOVInfo == nil || device.SRIOVInfo.TotalVFs <= 0 {
+		return -1
+	}
+	return device.SRIOVInfo.TotalVFs
+}
+
+func (genericPlugin) PreVFChange(m *SRIOVManager, device Device, current, numVFs int) error {
+	return nil
+}
+
+func (genericPlugin) PostVFChange(m *SRIOVManager, device Device, numVFs int) error {
+	return nil
+}
+
+// VerifyLAG accepts any bond; only vendor plugins can see the hardware LAG
+func (genericPlugin) VerifyLAG(device Device, bond *BondConfig) error {
+	return nil
+}
+
+// getDevlinkInfo is defined as a variable so it can be overridden in tests
+var getDevlinkInfo = func(pciAddress string) (map[string]string, error) {
+	return netlink.DevlinkGetDeviceInfoByNameAsMap("pci", pciAddress)
+}
+
+// rejectSwitchdev implements SetSwitchMode for drivers without an eswitch:
+// anything beyond legacy mode is an error
+func rejectSwitchdev(driver string, device Device, desired EswitchState) (EswitchState, error) {
+	if desired.Mode == EswitchModeSwitchdev || desired.InlineMode != "" || desired.EncapMode != "" {
+		return EswitchState{}, fmt.Errorf("%s on %s does not support eswitch %s", driver, device.Name, desired)
+	}
+	return EswitchState{}, nil
+}
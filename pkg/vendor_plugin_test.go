@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingPlugin is a vendor plugin that records its VF hooks
+type recordingPlugin struct {
+	genericPlugin
+	driver string
+	calls  []string
+}
+
+func (p *recordingPlugin) Name() string { return "recording" }
+
+func (p *recordingPlugin) Identify(device Device) bool { return device.Driver == p.driver }
+
+func (p *recordingPlugin) PreVFChange(m *SRIOVManager, device Device, current, numVFs int) error {
+	p.calls = append(p.calls, fmt.Sprintf("pre %d->%d", current, numVFs))
+	return nil
+}
+
+func (p *recordingPlugin) PostVFChange(m *SRIOVManager, device Device, numVFs int) error {
+	p.calls = append(p.calls, fmt.Sprintf("post %d", numVFs))
+	return nil
+}
+
+// useVendorPlugin registers a plugin for the duration of a test
+func useVendorPlugin(t *testing.T, plugin VendorPlugin) {
+	vendorPluginsMu.Lock()
+	original := vendorPlugins
+	vendorPluginsMu.Unlock()
+	RegisterVendorPlugin(plugin)
+	t.Cleanup(func() {
+		vendorPluginsMu.Lock()
+		vendorPlugins = original
+		vendorPluginsMu.Unlock()
+	})
+}
+
+// TestVendorPluginFor tests that devices are handled by the right plugin
+func TestVendorPluginFor(t *testing.T) {
+	testCases := []struct {
+		device   Device
+		expected string
+	}{
+		{Device{Driver: "mlx5_core", VendorID: "15b3"}, "mlx5"},
+		{Device{VendorID: "0x15b3", DeviceID: "0x101e"}, "mlx5"},
+		{Device{VendorID: "15b3", DeviceID: "a2dc"}, "mlx5"},
+		{Device{VendorID: "15b3", DeviceID: "1007"}, "generic"},
+		{Device{Driver: "mlx4_core", VendorID: "15b3", DeviceID: "1003"}, "generic"},
+		{Device{Driver: "ionic", VendorID: "1dd8"}, "ionic"},
+		{Device{Driver: "ice", VendorID: "8086"}, "ice"},
+		{Device{Driver: "i40e", VendorID: "8086"}, "i40e"},
+		{Device{Driver: "igb", VendorID: "8086"}, "generic"},
+		{Device{}, "generic"},
+	}
+	for _, tc := range testCases {
+		if name := VendorPluginFor(tc.device).Name(); name != tc.expected {
+			t.Errorf("expected plugin %s for %+v, got %s", tc.expected, tc.device, name)
+		}
+	}
+
+	// Later registrations take precedence over the built-in plugins
+	useVendorPlugin(t, &recordingPlugin{driver: "mlx5_core"})
+	if name := VendorPluginFor(Device{Driver: "mlx5_core"}).Name(); name != "recording" {
+		t.Errorf("expected the registered plugin to replace mlx5, got %s", name)
+	}
+}
+
+// TestMlx5FirmwareInfo tests the firmware query and VF limit of ConnectX
+// devices
+func TestMlx5FirmwareInfo(t *testing.T) {
+	originalInfo, originalQuery := getDevlinkInfo, queryMlxconfig
+	defer func() { getDevlinkInfo, queryMlxconfig = originalInfo, originalQuery }()
+	getDevlinkInfo = func(pciAddress string) (map[string]string, error) {
+		return map[string]string{"driver": "mlx5_core", "fw.psid": "MT_0000000834", "fw.version": "28.39.1002"}, nil
+	}
+	queries := 0
+	numVFs := "8"
+	queryMlxconfig = func(pciAddress string, names ...string) ([]byte, error) {
+		queries++
+		return []byte(`
+Device #1:
+----------
+
+Device type:        ConnectX7
+Name:               MCX755106AS-HEA_Ax
+Device:             ` + pciAddress + `
+
+Configurations:                                      Default             Current             Next Boot
+         SRIOV_EN                                    False(0)            True(1)             True(1)
+*        NUM_OF_VFS                                  0                   ` + numVFs + `                   16
+`), nil
+	}
+
+	plugin := &mlx5Plugin{}
+	device := Device{PCIAddress: "0000:31:00.0", Driver: "mlx5_core", SRIOVInfo: &SRIOVInfo{TotalVFs: 16}}
+	info, err := plugin.FirmwareInfo(device)
+	if err != nil {
+		t.Fatalf("FirmwareInfo failed: %v", err)
+	}
+	expected := map[string]string{"SRIOV_EN": "True(1)", "NUM_OF_VFS": "8"}
+	if info.Version != "28.39.1002" || !reflect.DeepEqual(info.Settings, expected) {
+		t.Errorf("unexpected firmware info %+v", info)
+	}
+	if s := info.String(); s != "28.39.1002 NUM_OF_VFS=8 SRIOV_EN=True(1)" {
+		t.Errorf("unexpected firmware string %q", s)
+	}
+	if maxVFs := plugin.MaxVFs(device); maxVFs != 8 {
+		t.Errorf("expected NUM_OF_VFS to limit VFs to 8, got %d", maxVFs)
+	}
+	if queries != 1 {
+		t.Errorf("expected mlxconfig settings to be cached, got %d queries", queries)
+	}
+
+	// Without mlxconfig the limit comes from sysfs alone
+	queryMlxconfig = func(pciAddress string, names ...string) ([]byte, error) {
+		return nil, fmt.Errorf("mlxconfig not found")
+	}
+	plugin = &mlx5Plugin{}
+	if maxVFs := plugin.MaxVFs(device); maxVFs != 16 {
+		t.Errorf("expected sriov_totalvfs to limit VFs to 16, got %d", maxVFs)
+	}
+	if maxVFs := plugin.MaxVFs(Device{PCIAddress: "0000:31:00.1"}); maxVFs != -1 {
+		t.Errorf("expected an unknown limit, got %d", maxVFs)
+	}
+}
+
+// TestParseMlxconfigQuery tests that SRIOV_EN=False disables VFs
+func TestParseMlxconfigQuery(t *testing.T) {
+	settings := parseMlxconfigQuery([]byte(`Configurations:                     Default         Current         Next Boot
+         SRIOV_EN                   False(0)        False(0)        True(1)
+         NUM_OF_VFS                 0               0               8
+`))
+	if mlxconfigValue(settings["SRIOV_EN"]) != 0 || mlxconfigValue(settings["NUM_OF_VFS"]) != 0 {
+		t.Errorf("unexpected settings %v", settings)
+	}
+	if mlxconfigValue("Unknown") != -1 {
+		t.Error("expected -1 for a non-numeric value")
+	}
+}
+
+// TestSwitchModeWithoutEswitch tests that drivers without an eswitch reject
+// switchdev and leave legacy policies alone
+func TestSwitchModeWithoutEswitch(t *testing.T) {
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	for _, driver := range []string{"ionic", "i40e"} {
+		device := Device{Name: "eth0", Driver: driver}
+		plugin := VendorPluginFor(device)
+		if _, err := plugin.SetSwitchMode(manager, device, EswitchState{Mode: EswitchModeSwitchdev}); err == nil ||
+			!strings.Contains(err.Error(), "does not support eswitch") {
+			t.Errorf("expected %s to reject switchdev, got %v", driver, err)
+		}
+		if change, err := plugin.SetSwitchMode(manager, device, EswitchState{Mode: EswitchModeLegacy}); err != nil || !change.IsZero() {
+			t.Errorf("expected %s to accept legacy without changes, got %v %v", driver, change, err)
+		}
+	}
+}
+
+// TestEnableSRIOVRunsPluginHooks tests that the pre- and post-VF hooks run
+// around a VF count change only
+func TestEnableSRIOVRunsPluginHooks(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	writeSysfsFiles(t, filepath.Join(root, "bus", "pci", "devices", "0000:3b:00.0"), map[string]string{"sriov_numvfs": "2\n"})
+
+	plugin := &recordingPlugin{driver: "test"}
+	useVendorPlugin(t, plugin)
+	manager := NewSRIOVManager(&SRIOVConfig{})
+	device := Device{Name: "ens1f0", PCIAddress: "0000:3b:00.0", Driver: "test"}
+
+	if err := manager.enableSRIOV(device, 4); err != nil {
+		t.Fatalf("enableSRIOV failed: %v", err)
+	}
+	if err := manager.enableSRIOV(device, 4); err != nil {
+		t.Fatalf("enableSRIOV failed: %v", err)
+	}
+	if expected := []string{"pre 2->4", "post 4"}; !reflect.DeepEqual(plugin.calls, expected) {
+		t.Errorf("expected hooks %v, got %v", expected, plugin.calls)
+	}
+}
+
+// TestWaitForVFDrivers tests waiting for Intel VFs to probe
+func TestWaitForVFDrivers(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	pfPath := PciDevicePath("0000:3b:00.0")
+	writeSysfsFiles(t, pfPath, map[string]string{"sriov_numvfs": "2\n"})
+	for i, vf := range []string{"0000:3b:02.0", "0000:3b:02.1"} {
+		if err := os.MkdirAll(PciDevicePath(vf), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(filepath.Join("..", vf), filepath.Join(pfPath, fmt.Sprintf("virtfn%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "bus", "pci", "drivers", "iavf"), filepath.Join(PciDevicePath("0000:3b:02.0"), "driver")); err != nil {
+		t.Fatal(err)
+	}
+	device := Device{Name: "ens1f0", PCIAddress: "0000:3b:00.0", Driver: "ice"}
+
+	if err := waitForVFDrivers(device, 2, 150*time.Millisecond); err == nil || !strings.Contains(err.Error(), "1 of 2 VFs") {
+		t.Errorf("expected one VF not ready, got %v", err)
+	}
+
+	// Without autoprobe the VFs only need to exist
+	writeSysfsFiles(t, pfPath, map[string]string{"sriov_drivers_autoprobe": "0\n"})
+	if err := waitForVFDrivers(device, 2, 0); err != nil {
+		t.Errorf("expected VFs to be ready, got %v", err)
+	}
+}
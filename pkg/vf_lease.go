@@ -0,0 +1,385 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseStatePath is where VF leases are persisted across restarts
+const DefaultLeaseStatePath = "/var/lib/sriov-manager/leases.json"
+
+var (
+	// ErrUnknownPool is returned when a lease names a pool that is not configured
+	ErrUnknownPool = errors.New("unknown resource pool")
+	// ErrInsufficientVFs is returned when a pool cannot satisfy a lease
+	ErrInsufficientVFs = errors.New("not enough free VFs")
+	// ErrLeaseNotFound is returned when releasing a lease that does not exist
+	ErrLeaseNotFound = errors.New("lease not found")
+	// ErrPoolServedToKubelet is returned when leasing from a pool whose VFs
+	// the kubelet allocates through a device plugin
+	ErrPoolServedToKubelet = errors.New("resource pool is served to the kubelet")
+)
+
+// leaseNow returns the current time, overridden in tests
+var leaseNow = time.Now
+
+// VFLease records VFs handed out to one owner
+type VFLease struct {
+	ID       string            `json:"id"`
+	Pool     string            `json:"pool"`
+	Owner    string            `json:"owner,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// VFs are the PCI addresses of the leased VFs
+	VFs       []string  `json:"vfs"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is zero for leases without a TTL
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the lease's TTL has run out at the given time
+func (l *VFLease) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
+
+// LeaseRequest describes the VFs wanted from a pool
+type LeaseRequest struct {
+	Pool  string
+	Count int
+	// NUMANode is the preferred NUMA node, -1 for no preference
+	NUMANode int
+	// StrictNUMA fails the request rather than using VFs on other nodes
+	StrictNUMA bool
+	Owner      string
+	Metadata   map[string]string
+	// TTL is how long the lease lives, zero for no expiry
+	TTL time.Duration
+}
+
+// leaseState is the on-disk format of the lease state file
+type leaseState struct {
+	Leases []*VFLease `json:"leases"`
+}
+
+// LeaseManager hands out VFs from resource pools and tracks who holds them,
+// so the same VF is never given to two workloads
+type LeaseManager struct {
+	mu        sync.Mutex
+	statePath string
+	pools     map[string]ResourcePool
+	vfs       map[string]map[string]poolVF
+	leases    map[string]*VFLease
+	leasedVFs map[string]string
+	// kubeletPools are allocated by the kubelet, which does not know leases
+	kubeletPools map[string]bool
+}
+
+// NewLeaseManager creates a lease manager for the given pools and restores
+// unexpired leases from statePath, if it exists. An empty statePath keeps
+// leases in memory only.
+func NewLeaseManager(pools []ResourcePool, statePath string) (*LeaseManager, error) {
+	m := &LeaseManager{
+		statePath:    statePath,
+		pools:        make(map[string]ResourcePool),
+		vfs:          make(map[string]map[string]poolVF),
+		leases:       make(map[string]*VFLease),
+		leasedVFs:    make(map[string]string),
+		kubeletPools: make(map[string]bool),
+	}
+	for _, pool := range pools {
+		m.pools[pool.ResourceName] = pool
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// load restores leases from the state file, dropping expired ones
+func (m *LeaseManager) load() error {
+	if m.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read lease state: %v", err)
+	}
+
+	var state leaseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse lease state %s: %v", m.statePath, err)
+	}
+
+	now := leaseNow()
+	for _, lease := range state.Leases {
+		if lease.Expired(now) {
+			WithField("lease", lease.ID).Info("Dropping lease that expired while the server was down")
+			continue
+		}
+		if _, ok := m.pools[lease.Pool]; !ok {
+			WithFields(map[string]interface{}{"lease": lease.ID, "pool": lease.Pool}).Warn("Restored lease refers to a pool that is no longer configured")
+		}
+		m.leases[lease.ID] = lease
+		for _, vf := range lease.VFs {
+			m.leasedVFs[vf] = lease.ID
+		}
+	}
+	Info("Restored %d VF leases from %s", len(m.leases), m.statePath)
+
+	// Persist the state without the dropped leases
+	if len(m.leases) != len(state.Leases) {
+		return m.save()
+	}
+	return nil
+}
+
+// save writes all leases to the state file, replacing it atomically
+func (m *LeaseManager) save() error {
+	if m.statePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(leaseState{Leases: m.sortedLeases()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lease state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.statePath), 0755); err != nil {
+		return fmt.Errorf("failed to create lease state directory: %v", err)
+	}
+	tmp := m.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write lease state: %v", err)
+	}
+	if err := os.Rename(tmp, m.statePath); err != nil {
+		return fmt.Errorf("failed to replace lease state: %v", err)
+	}
+	return nil
+}
+
+// sortedLeases returns all leases ordered by creation time
+func (m *LeaseManager) sortedLeases() []*VFLease {
+	leases := make([]*VFLease, 0, len(m.leases))
+	for _, lease := range m.leases {
+		leases = append(leases, lease)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		if !leases[i].CreatedAt.Equal(leases[j].CreatedAt) {
+			return leases[i].CreatedAt.Before(leases[j].CreatedAt)
+		}
+		return leases[i].ID < leases[j].ID
+	})
+	return leases
+}
+
+// Update recomputes the VFs of every pool from a discovered device inventory.
+// Leases are kept even if their VFs disappear, so a VF that comes back is
+// still owned by its holder.
+func (m *LeaseManager) Update(devices []Device) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, pool := range m.pools {
+		m.vfs[name] = selectPoolVFs(pool, devices)
+	}
+}
+
+// ServeToKubelet marks a pool as allocated by the kubelet through a device
+// plugin. The kubelet never reports when a pod gives its VFs back, so such
+// pools cannot be leased without handing a VF to two workloads.
+func (m *LeaseManager) ServeToKubelet(pool string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.kubeletPools[pool] = true
+}
+
+// Leased reports whether a VF is held by an unexpired lease
+func (m *LeaseManager) Leased(address string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id, ok := m.leasedVFs[address]
+	return ok && !m.leases[id].Expired(leaseNow())
+}
+
+// Allocate leases VFs from a pool. VFs on the preferred NUMA node are used
+// first; without a preference the node with the most free VFs is used first.
+func (m *LeaseManager) Allocate(req LeaseRequest) (*VFLease, error) {
+	if req.Count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", req.Count)
+	}
+	if req.TTL < 0 {
+		return nil, fmt.Errorf("ttl must not be negative, got %v", req.TTL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pool, ok := m.pools[req.Pool]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPool, req.Pool)
+	}
+	if m.kubeletPools[req.Pool] {
+		return nil, fmt.Errorf("%w: %s, request its VFs in a pod spec instead", ErrPoolServedToKubelet, req.Pool)
+	}
+	if expired := m.expireLocked(leaseNow()); len(expired) > 0 {
+		// Persist the pruning now so a failed save of the new lease below
+		// does not leave expired leases on disk
+		if err := m.save(); err != nil {
+			WithError(err).Warn("Failed to save VF lease state after expiring leases")
+		}
+	}
+
+	var free []poolVF
+	perNode := make(map[int]int)
+	for address, vf := range m.vfs[req.Pool] {
+		if _, leased := m.leasedVFs[address]; leased || !poolVFHealthy(pool, vf) {
+			continue
+		}
+		if req.StrictNUMA && vf.NUMANode != req.NUMANode {
+			continue
+		}
+		free = append(free, vf)
+		perNode[vf.NUMANode]++
+	}
+	if len(free) < req.Count {
+		return nil, fmt.Errorf("%w in pool %s: requested %d, %d available", ErrInsufficientVFs, req.Pool, req.Count, len(free))
+	}
+
+	sort.Slice(free, func(i, j int) bool {
+		ni, nj := free[i].NUMANode, free[j].NUMANode
+		if ni != nj {
+			if req.NUMANode >= 0 && (ni == req.NUMANode || nj == req.NUMANode) {
+				return ni == req.NUMANode
+			}
+			if perNode[ni] != perNode[nj] {
+				return perNode[ni] > perNode[nj]
+			}
+			return ni < nj
+		}
+		return free[i].PCIAddress < free[j].PCIAddress
+	})
+
+	id, err := newLeaseID()
+	if err != nil {
+		return nil, err
+	}
+	now := leaseNow()
+	lease := &VFLease{
+		ID:        id,
+		Pool:      req.Pool,
+		Owner:     req.Owner,
+		Metadata:  req.Metadata,
+		CreatedAt: now,
+	}
+	if req.TTL > 0 {
+		lease.ExpiresAt = now.Add(req.TTL)
+	}
+	for _, vf := range free[:req.Count] {
+		lease.VFs = append(lease.VFs, vf.PCIAddress)
+	}
+
+	m.addLocked(lease)
+	if err := m.save(); err != nil {
+		m.removeLocked(lease)
+		return nil, err
+	}
+
+	WithFields(map[string]interface{}{
+		"lease": lease.ID,
+		"pool":  lease.Pool,
+		"owner": lease.Owner,
+		"vfs":   lease.VFs,
+	}).Info("Allocated VF lease")
+	return lease, nil
+}
+
+// Release ends a lease and returns its VFs to the pool
+func (m *LeaseManager) Release(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrLeaseNotFound, id)
+	}
+	m.removeLocked(lease)
+	if err := m.save(); err != nil {
+		m.addLocked(lease)
+		return err
+	}
+
+	WithFields(map[string]interface{}{"lease": id, "pool": lease.Pool}).Info("Released VF lease")
+	return nil
+}
+
+// Leases returns copies of the current leases ordered by creation time
+func (m *LeaseManager) Leases() []VFLease {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var leases []VFLease
+	for _, lease := range m.sortedLeases() {
+		leases = append(leases, *lease)
+	}
+	return leases
+}
+
+// ExpireLeases releases every lease whose TTL has run out and returns them
+func (m *LeaseManager) ExpireLeases() ([]VFLease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	expired := m.expireLocked(leaseNow())
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	return expired, m.save()
+}
+
+// expireLocked drops expired leases from memory; the caller saves the state
+func (m *LeaseManager) expireLocked(now time.Time) []VFLease {
+	var expired []VFLease
+	for _, lease := range m.leases {
+		if lease.Expired(now) {
+			m.removeLocked(lease)
+			expired = append(expired, *lease)
+			WithFields(map[string]interface{}{"lease": lease.ID, "pool": lease.Pool, "owner": lease.Owner}).Info("VF lease expired")
+		}
+	}
+	return expired
+}
+
+func (m *LeaseManager) addLocked(lease *VFLease) {
+	m.leases[lease.ID] = lease
+	for _, vf := range lease.VFs {
+		m.leasedVFs[vf] = lease.ID
+	}
+}
+
+func (m *LeaseManager) removeLocked(lease *VFLease) {
+	delete(m.leases, lease.ID)
+	for _, vf := range lease.VFs {
+		if m.leasedVFs[vf] == lease.ID {
+			delete(m.leasedVFs, vf)
+		}
+	}
+}
+
+// newLeaseID returns a random lease identifier
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lease ID: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
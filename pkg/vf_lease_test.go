@@ -0,0 +1,188 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// leaseInventory returns a PF with three VFs on NUMA node 0 and a PF with two
+// VFs on NUMA node 1, one of which is bound to vfio-pci
+func leaseInventory() []Device {
+	return []Device{
+		{
+			PCIAddress: "0000:31:00.0", Name: "ens60f0np0", Driver: "mlx5_core", NUMANode: 0,
+			VFs: []VirtualFunction{
+				{Index: 0, PCIAddress: "0000:31:00.2", Driver: "mlx5_core", NetDev: "ens60f0v0"},
+				{Index: 1, PCIAddress: "0000:31:00.3", Driver: "mlx5_core", NetDev: "ens60f0v1"},
+				{Index: 2, PCIAddress: "0000:31:00.4", Driver: "mlx5_core", NetDev: "ens60f0v2"},
+			},
+		},
+		{
+			PCIAddress: "0000:b1:00.0", Name: "ens61f0np0", Driver: "mlx5_core", NUMANode: 1,
+			VFs: []VirtualFunction{
+				{Index: 0, PCIAddress: "0000:b1:00.2", Driver: "mlx5_core", NetDev: "ens61f0v0"},
+				{Index: 1, PCIAddress: "0000:b1:00.3", Driver: "vfio-pci", IOMMUGroup: "80"},
+			},
+		},
+	}
+}
+
+// setLeaseNow pins the lease clock for the duration of a test
+func setLeaseNow(t *testing.T, now *time.Time) {
+	original := leaseNow
+	leaseNow = func() time.Time { return *now }
+	t.Cleanup(func() { leaseNow = original })
+}
+
+// TestLeaseManagerAllocate tests NUMA preference, exhaustion and release
+func TestLeaseManagerAllocate(t *testing.T) {
+	m, err := NewLeaseManager([]ResourcePool{{ResourceName: "example.com/cx7"}}, "")
+	if err != nil {
+		t.Fatalf("Failed to create lease manager: %v", err)
+	}
+	m.Update(leaseInventory())
+
+	lease, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 2, NUMANode: 1, Owner: "job-a"})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	// Node 1 has only one healthy netdevice VF, the rest come from node 0
+	if expected := []string{"0000:b1:00.2", "0000:31:00.2"}; !reflect.DeepEqual(lease.VFs, expected) {
+		t.Errorf("Expected VFs %v, got %v", expected, lease.VFs)
+	}
+
+	_, err = m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 1, NUMANode: 1, StrictNUMA: true})
+	if !errors.Is(err, ErrInsufficientVFs) {
+		t.Errorf("Expected ErrInsufficientVFs for a strict NUMA request, got %v", err)
+	}
+
+	second, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 2, NUMANode: -1, Owner: "job-b"})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if expected := []string{"0000:31:00.3", "0000:31:00.4"}; !reflect.DeepEqual(second.VFs, expected) {
+		t.Errorf("Expected VFs %v, got %v", expected, second.VFs)
+	}
+
+	if _, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 1, NUMANode: -1}); !errors.Is(err, ErrInsufficientVFs) {
+		t.Errorf("Expected ErrInsufficientVFs for an exhausted pool, got %v", err)
+	}
+	if _, err := m.Allocate(LeaseRequest{Pool: "example.com/other", Count: 1, NUMANode: -1}); !errors.Is(err, ErrUnknownPool) {
+		t.Errorf("Expected ErrUnknownPool, got %v", err)
+	}
+
+	if err := m.Release(lease.ID); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := m.Release(lease.ID); !errors.Is(err, ErrLeaseNotFound) {
+		t.Errorf("Expected ErrLeaseNotFound on double release, got %v", err)
+	}
+	if _, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 2, NUMANode: -1}); err != nil {
+		t.Errorf("Expected released VFs to be allocatable again, got %v", err)
+	}
+}
+
+// TestLeaseManagerPersistence tests that leases survive a restart and expire
+// after their TTL
+func TestLeaseManagerPersistence(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	setLeaseNow(t, &now)
+
+	statePath := filepath.Join(t.TempDir(), "state", "leases.json")
+	pools := []ResourcePool{{ResourceName: "example.com/cx7"}}
+
+	m, err := NewLeaseManager(pools, statePath)
+	if err != nil {
+		t.Fatalf("Failed to create lease manager: %v", err)
+	}
+	m.Update(leaseInventory())
+
+	short, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 1, NUMANode: 0, Owner: "job-a", TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	long, err := m.Allocate(LeaseRequest{
+		Pool: "example.com/cx7", Count: 1, NUMANode: 0, Owner: "job-b",
+		Metadata: map[string]string{"namespace": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+
+	// A restarted server restores both leases and does not reuse their VFs
+	restored, err := NewLeaseManager(pools, statePath)
+	if err != nil {
+		t.Fatalf("Failed to restore lease manager: %v", err)
+	}
+	restored.Update(leaseInventory())
+	leases := restored.Leases()
+	if len(leases) != 2 {
+		t.Fatalf("Expected 2 restored leases, got %d", len(leases))
+	}
+	for _, lease := range leases {
+		if lease.ID == long.ID && !reflect.DeepEqual(lease, *long) {
+			t.Errorf("Expected restored lease %+v, got %+v", *long, lease)
+		}
+	}
+	third, err := restored.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 1, NUMANode: 0})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+	if third.VFs[0] == short.VFs[0] || third.VFs[0] == long.VFs[0] {
+		t.Errorf("Restored manager handed out leased VF %s", third.VFs[0])
+	}
+
+	now = now.Add(2 * time.Minute)
+	expired, err := restored.ExpireLeases()
+	if err != nil {
+		t.Fatalf("ExpireLeases failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0].ID != short.ID {
+		t.Errorf("Expected lease %s to expire, got %+v", short.ID, expired)
+	}
+
+	again, err := NewLeaseManager(pools, statePath)
+	if err != nil {
+		t.Fatalf("Failed to restore lease manager: %v", err)
+	}
+	if got := len(again.Leases()); got != 2 {
+		t.Errorf("Expected 2 leases after expiry, got %d", got)
+	}
+}
+
+// TestLeaseManagerAllocateSavesExpiry tests that leases expired by Allocate
+// are removed from the state file even when the allocation fails
+func TestLeaseManagerAllocateSavesExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	setLeaseNow(t, &now)
+
+	statePath := filepath.Join(t.TempDir(), "leases.json")
+	pools := []ResourcePool{{ResourceName: "example.com/cx7"}}
+	m, err := NewLeaseManager(pools, statePath)
+	if err != nil {
+		t.Fatalf("Failed to create lease manager: %v", err)
+	}
+	m.Update(leaseInventory())
+
+	short, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 1, NUMANode: 0, TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("Allocate failed: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := m.Allocate(LeaseRequest{Pool: "example.com/cx7", Count: 10, NUMANode: -1}); !errors.Is(err, ErrInsufficientVFs) {
+		t.Fatalf("Expected ErrInsufficientVFs, got %v", err)
+	}
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("Failed to read lease state: %v", err)
+	}
+	if strings.Contains(string(data), short.ID) {
+		t.Errorf("Expected expired lease %s to be removed from the state file", short.ID)
+	}
+}
@@ -0,0 +1,198 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// getVFAdminInfo is defined as a variable so it can be
+// overridden in tests.
+var getVFAdminInfo = netlinkVFAdminInfo
+
+// SetGetVFAdminInfo allows overriding the VF administrative state lookup for testing
+func SetGetVFAdminInfo(fn func(pfName string) (map[int]VirtualFunction, error)) {
+	getVFAdminInfo = fn
+}
+
+// netlinkVFAdminInfo reads per-VF administrative state of a PF netdev
+// from the IFLA_VFINFO_LIST attribute, keyed by VF index
+func netlinkVFAdminInfo(pfName string) (map[int]VirtualFunction, error) {
+	link, err := netlink.LinkByName(pfName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get link %s: %v", pfName, err)
+	}
+
+	info := make(map[int]VirtualFunction)
+	for _, vf := range link.Attrs().Vfs {
+		entry := VirtualFunction{
+			Index:     vf.ID,
+			Vlan:      vf.Vlan,
+			Qos:       vf.Qos,
+			Trust:     vf.Trust != 0,
+			SpoofChk:  vf.Spoofchk,
+			LinkState: vfLinkStateString(vf.LinkState),
+			MinTxRate: vf.MinTxRate,
+			MaxTxRate: vf.MaxTxRate,
+			QueryRSS:  vf.RssQuery != 0,
+		}
+		if len(vf.Mac) > 0 {
+			entry.MAC = vf.Mac.String()
+		}
+		info[vf.ID] = entry
+	}
+
+	return info, nil
+}
+
+// vfLinkStateString converts an IFLA_VF_LINK_STATE value to its ip(8) name
+func vfLinkStateString(state uint32) string {
+	switch state {
+	case netlink.VF_LINK_STATE_AUTO:
+		return "auto"
+	case netlink.VF_LINK_STATE_ENABLE:
+		return "enable"
+	case netlink.VF_LINK_STATE_DISABLE:
+		return "disable"
+	default:
+		return fmt.Sprintf("unknown(%d)", state)
+	}
+}
+
+// AttachVFInfo enriches the VFs of each PF with administrative state from its netdev
+func AttachVFInfo(devices []Device) ([]Device, error) {
+	for i := range devices {
+		if len(devices[i].VFs) == 0 || devices[i].Name == "" {
+			continue
+		}
+
+		adminInfo, err := getVFAdminInfo(devices[i].Name)
+		if err != nil {
+			WithField("device", devices[i].Name).WithError(err).Warn("Failed to get VF info")
+			continue
+		}
+
+		for j := range devices[i].VFs {
+			if admin, ok := adminInfo[devices[i].VFs[j].Index]; ok {
+				mergeVFAdminInfo(&devices[i].VFs[j], admin)
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// mergeVFAdminInfo copies administrative state onto a VF discovered from sysfs
+func mergeVFAdminInfo(vf *VirtualFunction, admin VirtualFunction) {
+	vf.MAC = admin.MAC
+	vf.Vlan = admin.Vlan
+	vf.Qos = admin.Qos
+	vf.Trust = admin.Trust
+	vf.SpoofChk = admin.SpoofChk
+	vf.LinkState = admin.LinkState
+	vf.MinTxRate = admin.MinTxRate
+	vf.MaxTxRate = admin.MaxTxRate
+	vf.QueryRSS = admin.QueryRSS
+}
+
+// vfLinkSetter sets IFLA_VF_* attributes of a VF through its PF netdev
+type vfLinkSetter interface {
+	SetMAC(pfName string, vf int, mac net.HardwareAddr) error
+	SetVlanQos(pfName string, vf, vlan, qos int) error
+	SetSpoofChk(pfName string, vf int, on bool) error
+	SetTrust(pfName string, vf int, on bool) error
+	SetLinkState(pfName string, vf int, state uint32) error
+	SetRate(pfName string, vf int, minRate, maxRate uint32) error
+	SetQueryRSS(pfName string, vf int, on bool) error
+}
+
+// vfLinks is defined as a variable so it can be overridden in tests
+var vfLinks vfLinkSetter = netlinkVFLinks{}
+
+// netlinkVFLinks sets VF attributes with RTM_SETLINK requests
+type netlinkVFLinks struct{}
+
+func (netlinkVFLinks) SetMAC(pfName string, vf int, mac net.HardwareAddr) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfHardwareAddr(link, vf, mac)
+	})
+}
+
+func (netlinkVFLinks) SetVlanQos(pfName string, vf, vlan, qos int) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfVlanQos(link, vf, vlan, qos)
+	})
+}
+
+func (netlinkVFLinks) SetSpoofChk(pfName string, vf int, on bool) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfSpoofchk(link, vf, on)
+	})
+}
+
+func (netlinkVFLinks) SetTrust(pfName string, vf int, on bool) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfTrust(link, vf, on)
+	})
+}
+
+func (netlinkVFLinks) SetLinkState(pfName string, vf int, state uint32) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfState(link, vf, state)
+	})
+}
+
+func (netlinkVFLinks) SetRate(pfName string, vf int, minRate, maxRate uint32) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		return netlink.LinkSetVfRate(link, vf, int(minRate), int(maxRate))
+	})
+}
+
+// SetQueryRSS sends IFLA_VF_RSS_QUERY_EN, which the netlink library has no
+// setter for
+func (netlinkVFLinks) SetQueryRSS(pfName string, vf int, on bool) error {
+	return withPFLink(pfName, func(link netlink.Link) error {
+		req := nl.NewNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		msg.Index = int32(link.Attrs().Index)
+		req.AddData(msg)
+
+		var setting uint32
+		if on {
+			setting = 1
+		}
+		data := nl.NewRtAttr(unix.IFLA_VFINFO_LIST, nil)
+		info := data.AddRtAttr(nl.IFLA_VF_INFO, nil)
+		query := nl.VfRssQueryEn{Vf: uint32(vf), Setting: setting}
+		info.AddRtAttr(nl.IFLA_VF_RSS_QUERY_EN, query.Serialize())
+		req.AddData(data)
+
+		_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+		return err
+	})
+}
+
+// withPFLink looks up a PF netdev and runs fn on it
+func withPFLink(pfName string, fn func(link netlink.Link) error) error {
+	link, err := netlink.LinkByName(pfName)
+	if err != nil {
+		return fmt.Errorf("failed to get link %s: %v", pfName, err)
+	}
+	return fn(link)
+}
+
+// FindPF returns the PF device matching a PCI address or interface name
+func FindPF(devices []Device, pf string) (*Device, error) {
+	for i := range devices {
+		if devices[i].PCIAddress == pf || devices[i].Name == pf {
+			if !devices[i].SRIOVCapable {
+				return nil, fmt.Errorf("device %s is not SR-IOV capable", pf)
+			}
+			return &devices[i], nil
+		}
+	}
+	return nil, fmt.Errorf("physical function %s not found", pf)
+}
@@ -0,0 +1,279 @@
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+)
+
+// VFSettings are administrative settings of a VF, applied through its PF
+// netdev. Unset fields leave the VF as it is.
+type VFSettings struct {
+	// MAC is the VF's MAC address. In a policy's vf_template it is the base
+	// address, and VF n gets the base plus n.
+	MAC       string  `json:"mac,omitempty"`
+	Vlan      *int    `json:"vlan,omitempty"`
+	Qos       *int    `json:"qos,omitempty"`
+	SpoofChk  *bool   `json:"spoof_check,omitempty"`
+	Trust     *bool   `json:"trust,omitempty"`
+	LinkState string  `json:"link_state,omitempty"`
+	MinTxRate *uint32 `json:"min_tx_rate,omitempty"`
+	MaxTxRate *uint32 `json:"max_tx_rate,omitempty"`
+	QueryRSS  *bool   `json:"query_rss,omitempty"`
+}
+
+// VFOverride replaces template settings for the VF with index VF
+type VFOverride struct {
+	VF int `json:"vf"`
+	VFSettings
+}
+
+// Validate checks the settings for values the kernel would reject
+func (s *VFSettings) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.MAC != "" {
+		mac, err := net.ParseMAC(s.MAC)
+		if err != nil || len(mac) != 6 {
+			return fmt.Errorf("invalid mac %q", s.MAC)
+		}
+		if mac[0]&1 != 0 {
+			return fmt.Errorf("mac %s is not a unicast address", s.MAC)
+		}
+	}
+	if s.Vlan != nil && (*s.Vlan < 0 || *s.Vlan > 4095) {
+		return fmt.Errorf("vlan must be between 0 and 4095, got %d", *s.Vlan)
+	}
+	if s.Qos != nil && (*s.Qos < 0 || *s.Qos > 7) {
+		return fmt.Errorf("qos must be between 0 and 7, got %d", *s.Qos)
+	}
+	if _, ok := vfLinkStates[s.LinkState]; s.LinkState != "" && !ok {
+		return fmt.Errorf("invalid link_state %q, expected auto, enable or disable", s.LinkState)
+	}
+	if s.MinTxRate != nil && s.MaxTxRate != nil && *s.MaxTxRate != 0 && *s.MinTxRate > *s.MaxTxRate {
+		return fmt.Errorf("min_tx_rate %d exceeds max_tx_rate %d", *s.MinTxRate, *s.MaxTxRate)
+	}
+	return nil
+}
+
+// merge returns the settings with every field set in override replacing its own
+func (s VFSettings) merge(override VFSettings) VFSettings {
+	if override.MAC != "" {
+		s.MAC = override.MAC
+	}
+	if override.Vlan != nil {
+		s.Vlan = override.Vlan
+	}
+	if override.Qos != nil {
+		s.Qos = override.Qos
+	}
+	if override.SpoofChk != nil {
+		s.SpoofChk = override.SpoofChk
+	}
+	if override.Trust != nil {
+		s.Trust = override.Trust
+	}
+	if override.LinkState != "" {
+		s.LinkState = override.LinkState
+	}
+	if override.MinTxRate != nil {
+		s.MinTxRate = override.MinTxRate
+	}
+	if override.MaxTxRate != nil {
+		s.MaxTxRate = override.MaxTxRate
+	}
+	if override.QueryRSS != nil {
+		s.QueryRSS = override.QueryRSS
+	}
+	return s
+}
+
+// HasVFSettings reports whether the policy sets any per-VF administrative state
+func (p *DevicePolicy) HasVFSettings() bool {
+	return p.VFTemplate != nil || len(p.VFOverrides) > 0
+}
+
+// VFSettingsFor returns the settings of VF index: the template, with its MAC
+// offset by the index, merged with the VF's override
+func (p *DevicePolicy) VFSettingsFor(index int) (VFSettings, error) {
+	var settings VFSettings
+	if p.VFTemplate != nil {
+		settings = *p.VFTemplate
+		if settings.MAC != "" {
+			mac, err := offsetMAC(settings.MAC, index)
+			if err != nil {
+				return settings, err
+			}
+			settings.MAC = mac
+		}
+	}
+	for _, override := range p.VFOverrides {
+		if override.VF == index {
+			settings = settings.merge(override.VFSettings)
+		}
+	}
+	return settings, nil
+}
+
+// offsetMAC adds offset to a MAC address treated as a 48-bit number
+func offsetMAC(base string, offset int) (string, error) {
+	mac, err := net.ParseMAC(base)
+	if err != nil || len(mac) != 6 {
+		return "", fmt.Errorf("invalid mac %q", base)
+	}
+	var value uint64
+	for _, b := range mac {
+		value = value<<8 | uint64(b)
+	}
+	value += uint64(offset)
+	if value >= 1<<48 {
+		return "", fmt.Errorf("mac %s plus %d overflows", base, offset)
+	}
+	for i := 5; i >= 0; i-- {
+		mac[i] = byte(value)
+		value >>= 8
+	}
+	return mac.String(), nil
+}
+
+// vfLinkStates maps link_state names to IFLA_VF_LINK_STATE values
+var vfLinkStates = map[string]uint32{
+	"auto":    netlink.VF_LINK_STATE_AUTO,
+	"enable":  netlink.VF_LINK_STATE_ENABLE,
+	"disable": netlink.VF_LINK_STATE_DISABLE,
+}
+
+// applyVFSettings brings the first numVFs VFs of a PF to the policy's
+// template and overrides. Only attributes that differ from the current state
+// are changed; a description of each change is returned.
+func (m *SRIOVManager) applyVFSettings(device Device, policy *DevicePolicy, numVFs int) ([]string, error) {
+	if !policy.HasVFSettings() || numVFs == 0 {
+		return nil, nil
+	}
+	if device.Name == "" {
+		return nil, fmt.Errorf("PF %s has no netdev to configure VFs through", device.PCIAddress)
+	}
+
+	current, err := getVFAdminInfo(device.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VF state of %s: %v", device.Name, err)
+	}
+
+	var actions []string
+	for index := 0; index < numVFs; index++ {
+		settings, err := policy.VFSettingsFor(index)
+		if err != nil {
+			return actions, fmt.Errorf("vf%d: %v", index, err)
+		}
+		vf, ok := current[index]
+		if !ok && !m.Config().DryRun {
+			return actions, fmt.Errorf("vf%d of %s not found", index, device.Name)
+		}
+		changed, err := m.applyVFSettingsTo(device.Name, index, vf, settings)
+		actions = append(actions, changed...)
+		if err != nil {
+			return actions, err
+		}
+	}
+	return actions, nil
+}
+
+// applyVFSettingsTo changes the attributes of one VF that differ from settings
+func (m *SRIOVManager) applyVFSettingsTo(pfName string, index int, vf VirtualFunction, settings VFSettings) ([]string, error) {
+	var actions []string
+	set := func(attr, current, desired string, apply func() error) error {
+		target := fmt.Sprintf("%s vf %d %s", pfName, index, attr)
+		if err := m.applyNetlink(fmt.Sprintf("set %s of vf%d on %s", attr, index, pfName), target, current, desired, apply); err != nil {
+			return fmt.Errorf("failed to set %s of vf%d on %s: %v", attr, index, pfName, err)
+		}
+		actions = append(actions, fmt.Sprintf("set vf%d %s to %s", index, attr, desired))
+		return nil
+	}
+
+	if settings.MAC != "" && !strings.EqualFold(settings.MAC, vf.MAC) {
+		mac, _ := net.ParseMAC(settings.MAC)
+		if err := set("mac", vf.MAC, settings.MAC, func() error {
+			return vfLinks.SetMAC(pfName, index, mac)
+		}); err != nil {
+			return actions, err
+		}
+	}
+
+	if settings.Vlan != nil || settings.Qos != nil {
+		vlan, qos := intOr(settings.Vlan, vf.Vlan), intOr(settings.Qos, vf.Qos)
+		if vlan != vf.Vlan || qos != vf.Qos {
+			if err := set("vlan", fmt.Sprintf("%d qos %d", vf.Vlan, vf.Qos), fmt.Sprintf("%d qos %d", vlan, qos), func() error {
+				return vfLinks.SetVlanQos(pfName, index, vlan, qos)
+			}); err != nil {
+				return actions, err
+			}
+		}
+	}
+
+	if settings.SpoofChk != nil && *settings.SpoofChk != vf.SpoofChk {
+		if err := set("spoofchk", onOff(vf.SpoofChk), onOff(*settings.SpoofChk), func() error {
+			return vfLinks.SetSpoofChk(pfName, index, *settings.SpoofChk)
+		}); err != nil {
+			return actions, err
+		}
+	}
+
+	if settings.Trust != nil && *settings.Trust != vf.Trust {
+		if err := set("trust", onOff(vf.Trust), onOff(*settings.Trust), func() error {
+			return vfLinks.SetTrust(pfName, index, *settings.Trust)
+		}); err != nil {
+			return actions, err
+		}
+	}
+
+	if settings.LinkState != "" && settings.LinkState != vf.LinkState {
+		if err := set("state", vf.LinkState, settings.LinkState, func() error {
+			return vfLinks.SetLinkState(pfName, index, vfLinkStates[settings.LinkState])
+		}); err != nil {
+			return actions, err
+		}
+	}
+
+	if settings.MinTxRate != nil || settings.MaxTxRate != nil {
+		minRate, maxRate := uint32Or(settings.MinTxRate, vf.MinTxRate), uint32Or(settings.MaxTxRate, vf.MaxTxRate)
+		if minRate != vf.MinTxRate || maxRate != vf.MaxTxRate {
+			if err := set("rate", fmt.Sprintf("min %d max %d", vf.MinTxRate, vf.MaxTxRate), fmt.Sprintf("min %d max %d", minRate, maxRate), func() error {
+				return vfLinks.SetRate(pfName, index, minRate, maxRate)
+			}); err != nil {
+				return actions, err
+			}
+		}
+	}
+
+	if settings.QueryRSS != nil && *settings.QueryRSS != vf.QueryRSS {
+		if err := set("query_rss", onOff(vf.QueryRSS), onOff(*settings.QueryRSS), func() error {
+			return vfLinks.SetQueryRSS(pfName, index, *settings.QueryRSS)
+		}); err != nil {
+			return actions, err
+		}
+	}
+
+	return actions, nil
+}
+
+func intOr(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func uint32Or(value *uint32, fallback uint32) uint32 {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+// onOff formats a flag the way ip(8) does
+func onOff(value bool) string {
+	return map[bool]string{true: "on", false: "off"}[value]
+}
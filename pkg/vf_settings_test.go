@@ -0,0 +1,205 @@
+package pkg
+
+import (
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeVFLinks records VF attribute changes and keeps the resulting state
+type fakeVFLinks struct {
+	vfs   map[int]VirtualFunction
+	calls []string
+}
+
+func (f *fakeVFLinks) update(vf int, call string, change func(*VirtualFunction)) error {
+	state := f.vfs[vf]
+	change(&state)
+	f.vfs[vf] = state
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *fakeVFLinks) SetMAC(pfName string, vf int, mac net.HardwareAddr) error {
+	return f.update(vf, "mac", func(v *VirtualFunction) { v.MAC = mac.String() })
+}
+
+func (f *fakeVFLinks) SetVlanQos(pfName string, vf, vlan, qos int) error {
+	return f.update(vf, "vlan", func(v *VirtualFunction) { v.Vlan, v.Qos = vlan, qos })
+}
+
+func (f *fakeVFLinks) SetSpoofChk(pfName string, vf int, on bool) error {
+	return f.update(vf, "spoofchk", func(v *VirtualFunction) { v.SpoofChk = on })
+}
+
+func (f *fakeVFLinks) SetTrust(pfName string, vf int, on bool) error {
+	return f.update(vf, "trust", func(v *VirtualFunction) { v.Trust = on })
+}
+
+func (f *fakeVFLinks) SetLinkState(pfName string, vf int, state uint32) error {
+	return f.update(vf, "state", func(v *VirtualFunction) { v.LinkState = vfLinkStateString(state) })
+}
+
+func (f *fakeVFLinks) SetRate(pfName string, vf int, minRate, maxRate uint32) error {
+	return f.update(vf, "rate", func(v *VirtualFunction) { v.MinTxRate, v.MaxTxRate = minRate, maxRate })
+}
+
+func (f *fakeVFLinks) SetQueryRSS(pfName string, vf int, on bool) error {
+	return f.update(vf, "query_rss", func(v *VirtualFunction) { v.QueryRSS = on })
+}
+
+// useFakeVFLinks routes VF reads and writes to a fake holding freshly created VFs
+func useFakeVFLinks(t *testing.T, numVFs int) *fakeVFLinks {
+	links := &fakeVFLinks{vfs: make(map[int]VirtualFunction)}
+	for i := 0; i < numVFs; i++ {
+		links.vfs[i] = VirtualFunction{Index: i, MAC: "00:00:00:00:00:00", SpoofChk: true, LinkState: "auto"}
+	}
+
+	originalLinks, originalInfo := vfLinks, getVFAdminInfo
+	vfLinks = links
+	SetGetVFAdminInfo(func(pfName string) (map[int]VirtualFunction, error) {
+		vfs := make(map[int]VirtualFunction)
+		for index, vf := range links.vfs {
+			vfs[index] = vf
+		}
+		return vfs, nil
+	})
+	t.Cleanup(func() {
+		vfLinks = originalLinks
+		SetGetVFAdminInfo(originalInfo)
+	})
+	return links
+}
+
+func intPtr(v int) *int          { return &v }
+func boolPtr(v bool) *bool       { return &v }
+func uint32Ptr(v uint32) *uint32 { return &v }
+
+// TestVFSettingsFor tests template MAC offsets and override merging
+func TestVFSettingsFor(t *testing.T) {
+	policy := DevicePolicy{
+		NumVFs: 4,
+		VFTemplate: &VFSettings{
+			MAC:   "02:00:00:00:00:fe",
+			Vlan:  intPtr(100),
+			Trust: boolPtr(true),
+		},
+		VFOverrides: []VFOverride{
+			{VF: 2, VFSettings: VFSettings{MAC: "02:aa:00:00:00:01", Trust: boolPtr(false), MaxTxRate: uint32Ptr(1000)}},
+		},
+	}
+
+	vf1, err := policy.VFSettingsFor(1)
+	if err != nil {
+		t.Fatalf("VFSettingsFor(1) failed: %v", err)
+	}
+	expected := VFSettings{MAC: "02:00:00:00:00:ff", Vlan: intPtr(100), Trust: boolPtr(true)}
+	if !reflect.DeepEqual(vf1, expected) {
+		t.Errorf("vf1: expected %+v, got %+v", expected, vf1)
+	}
+
+	vf3, _ := policy.VFSettingsFor(3)
+	if vf3.MAC != "02:00:00:00:01:01" {
+		t.Errorf("vf3: expected MAC to carry into the next octet, got %s", vf3.MAC)
+	}
+
+	vf2, _ := policy.VFSettingsFor(2)
+	expected = VFSettings{MAC: "02:aa:00:00:00:01", Vlan: intPtr(100), Trust: boolPtr(false), MaxTxRate: uint32Ptr(1000)}
+	if !reflect.DeepEqual(vf2, expected) {
+		t.Errorf("vf2: expected %+v, got %+v", expected, vf2)
+	}
+}
+
+// TestReconcileVFSettings tests that reconcile applies only differing VF
+// attributes and plans them in dry-run mode
+func TestReconcileVFSettings(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+	writeSysfsFiles(t, filepath.Join(root, "bus", "pci", "devices", "0000:31:00.0"), map[string]string{"sriov_numvfs": "2\n"})
+
+	links := useFakeVFLinks(t, 2)
+	policies := []DevicePolicy{{
+		VendorID: "15b3", DeviceID: "101e", NumVFs: 2,
+		VFTemplate: &VFSettings{
+			MAC:       "02:00:00:00:01:00",
+			SpoofChk:  boolPtr(true),
+			Trust:     boolPtr(true),
+			LinkState: "enable",
+			QueryRSS:  boolPtr(true),
+		},
+		VFOverrides: []VFOverride{
+			{VF: 1, VFSettings: VFSettings{Vlan: intPtr(200), Qos: intPtr(3), MinTxRate: uint32Ptr(100), MaxTxRate: uint32Ptr(5000)}},
+		},
+	}}
+	device := Device{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", VendorID: "15b3", DeviceID: "101e"}
+
+	dryRun := NewSRIOVManager(&SRIOVConfig{DryRun: true, DevicePolicies: policies})
+	if status := dryRun.reconcileDevice(device); status.State != ReconcilePlanned {
+		t.Errorf("expected planned, got %s (%s)", status.State, status.Message)
+	}
+	plan := dryRun.Plan()
+	if len(plan.Actions) != 10 || len(links.calls) != 0 {
+		t.Fatalf("expected 10 planned actions and no changes, got %d actions and %v", len(plan.Actions), links.calls)
+	}
+	first := plan.Actions[0]
+	if first.Type != ActionNetlink || first.Target != "ens60f0np0 vf 0 mac" || first.Current != "00:00:00:00:00:00" || first.Desired != "02:00:00:00:01:00" {
+		t.Errorf("unexpected first action %+v", first)
+	}
+
+	manager := NewSRIOVManager(&SRIOVConfig{DevicePolicies: policies})
+	status := manager.reconcileDevice(device)
+	if status.State != ReconcileApplied {
+		t.Fatalf("expected applied, got %s (%s)", status.State, status.Message)
+	}
+	// Spoof check is already on, so it is not touched
+	expectedCalls := []string{"mac", "trust", "state", "query_rss", "mac", "vlan", "trust", "state", "rate", "query_rss"}
+	if !reflect.DeepEqual(links.calls, expectedCalls) {
+		t.Errorf("expected calls %v, got %v", expectedCalls, links.calls)
+	}
+	expectedVF1 := VirtualFunction{
+		Index: 1, MAC: "02:00:00:00:01:01", Vlan: 200, Qos: 3, SpoofChk: true, Trust: true,
+		LinkState: "enable", MinTxRate: 100, MaxTxRate: 5000, QueryRSS: true,
+	}
+	if !reflect.DeepEqual(links.vfs[1], expectedVF1) {
+		t.Errorf("expected vf1 %+v, got %+v", expectedVF1, links.vfs[1])
+	}
+
+	links.calls = nil
+	if status := manager.reconcileDevice(device); status.State != ReconcileInSync || len(links.calls) != 0 {
+		t.Errorf("expected in-sync without changes, got %s %v", status.State, links.calls)
+	}
+}
+
+// TestValidateVFSettings tests validation of VF templates and overrides
+func TestValidateVFSettings(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy DevicePolicy
+		valid  bool
+	}{
+		{"valid", DevicePolicy{VFTemplate: &VFSettings{MAC: "02:00:00:00:00:00", Vlan: intPtr(10), LinkState: "auto"}}, true},
+		{"multicast mac", DevicePolicy{VFTemplate: &VFSettings{MAC: "01:00:5e:00:00:01"}}, false},
+		{"mac overflow", DevicePolicy{VFTemplate: &VFSettings{MAC: "ff:ff:ff:ff:ff:fe"}}, false},
+		{"vlan out of range", DevicePolicy{VFTemplate: &VFSettings{Vlan: intPtr(4096)}}, false},
+		{"qos out of range", DevicePolicy{VFOverrides: []VFOverride{{VF: 0, VFSettings: VFSettings{Qos: intPtr(8)}}}}, false},
+		{"invalid link state", DevicePolicy{VFTemplate: &VFSettings{LinkState: "up"}}, false},
+		{"min rate above max", DevicePolicy{VFTemplate: &VFSettings{MinTxRate: uint32Ptr(500), MaxTxRate: uint32Ptr(100)}}, false},
+		{"override out of range", DevicePolicy{VFOverrides: []VFOverride{{VF: 4}}}, false},
+		{"duplicate override", DevicePolicy{VFOverrides: []VFOverride{{VF: 1}, {VF: 1}}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.policy.VendorID, tc.policy.DeviceID, tc.policy.NumVFs = "15b3", "101e", 4
+			err := (&SRIOVConfig{DevicePolicies: []DevicePolicy{tc.policy}}).ValidateConfig()
+			if tc.valid && err != nil {
+				t.Errorf("expected valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VirtualFunction holds information about a single SR-IOV virtual function
+type VirtualFunction struct {
+	Index      int    // VF index on the parent PF (virtfnN)
+	PCIAddress string // PCI address of the VF
+	Driver     string // Kernel driver bound to the VF, empty if unbound
+	NetDev     string // Network interface name, empty if none
+	IOMMUGroup string // IOMMU group number, empty if IOMMU is disabled
+	PFAddress  string // PCI address of the parent physical function
+	// Administrative state as configured on the PF netdev
+	MAC       string
+	Vlan      int
+	Qos       int
+	Trust     bool
+	SpoofChk  bool
+	LinkState string // "auto", "enable" or "disable"
+	MinTxRate uint32 // Mbps, 0 means unlimited
+	MaxTxRate uint32 // Mbps, 0 means unlimited
+	QueryRSS  bool   // VF may query the PF's RSS redirection table and key
+	// Switchdev representor netdev and the function state behind it
+	Representor  string
+	PortFunction *PortFunction
+}
+
+// parseVirtualFunctions walks the virtfn* symlinks of a PF and returns its VFs
+// ordered by VF index
+func parseVirtualFunctions(devicePath string) ([]VirtualFunction, error) {
+	links, err := filepath.Glob(filepath.Join(devicePath, "virtfn*"))
+	if err != nil {
+		return nil, err
+	}
+
+	pfAddress := filepath.Base(devicePath)
+	var vfs []VirtualFunction
+	for _, link := range links {
+		index, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(link), "virtfn"))
+		if err != nil {
+			continue
+		}
+
+		target, err := os.Readlink(link)
+		if err != nil {
+			Debug("Failed to read VF link %s: %v", link, err)
+			continue
+		}
+
+		vfAddress := filepath.Base(target)
+		vfPath := filepath.Join(filepath.Dir(devicePath), vfAddress)
+		vfs = append(vfs, parseVirtualFunction(vfPath, vfAddress, pfAddress, index))
+	}
+
+	sort.Slice(vfs, func(i, j int) bool {
+		return vfs[i].Index < vfs[j].Index
+	})
+
+	return vfs, nil
+}
+
+// parseVirtualFunction reads driver, netdev and IOMMU group for a single VF
+func parseVirtualFunction(vfPath, vfAddress, pfAddress string, index int) VirtualFunction {
+	vf := VirtualFunction{
+		Index:      index,
+		PCIAddress: vfAddress,
+		PFAddress:  pfAddress,
+	}
+
+	// Bound driver
+	if driverLink, err := os.Readlink(filepath.Join(vfPath, "driver")); err == nil {
+		vf.Driver = filepath.Base(driverLink)
+	}
+
+	// Network interface, only present while bound to a netdev driver
+	if entries, err := os.ReadDir(filepath.Join(vfPath, "net")); err == nil && len(entries) > 0 {
+		vf.NetDev = entries[0].Name()
+	}
+
+	// IOMMU group
+	if groupLink, err := os.Readlink(filepath.Join(vfPath, "iommu_group")); err == nil {
+		vf.IOMMUGroup = filepath.Base(groupLink)
+	}
+
+	return vf
+}
+
+// parsePhysFn returns the PF address of a VF from its physfn symlink,
+// or an empty string if the device is not a VF
+func parsePhysFn(devicePath string) string {
+	target, err := os.Readlink(filepath.Join(devicePath, "physfn"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// inUseVFs returns a description of each VF of a PF that is bound to vfio-pci
+// or has a netdev that is administratively up
+func inUseVFs(pfAddress string) ([]string, error) {
+	vfs, err := parseVirtualFunctions(PciDevicePath(pfAddress))
+	if err != nil {
+		return nil, err
+	}
+
+	var inUse []string
+	for _, vf := range vfs {
+		if vf.Driver == "vfio-pci" {
+			inUse = append(inUse, fmt.Sprintf("vf%d (%s) bound to vfio-pci", vf.Index, vf.PCIAddress))
+			continue
+		}
+		if vf.NetDev == "" {
+			continue
+		}
+		// IFF_UP rather than operstate: a VF brought up by a workload is in
+		// use even while it has no carrier
+		flags, err := strconv.ParseUint(readSysfsAttr(filepath.Join(NetClassPath(), vf.NetDev), "flags"), 0, 32)
+		if err == nil && flags&iffUp != 0 {
+			inUse = append(inUse, fmt.Sprintf("vf%d (%s) netdev %s is up", vf.Index, vf.PCIAddress, vf.NetDev))
+		}
+	}
+	return inUse, nil
+}
@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// symlink creates a symlink and fails the test on error
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", filepath.Dir(link), err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatalf("failed to link %s -> %s: %v", link, target, err)
+	}
+}
+
+// TestParseVirtualFunctions tests VF enumeration through virtfn and physfn links
+func TestParseVirtualFunctions(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	pfDir := filepath.Join(devicesDir, "0000:31:00.0")
+	writeSysfsFiles(t, pfDir, map[string]string{
+		"vendor":         "0x15b3",
+		"device":         "0x101e",
+		"class":          "0x020000",
+		"sriov_totalvfs": "16",
+		"sriov_numvfs":   "2",
+	})
+
+	// VF 0 is a netdev on mlx5_core, VF 1 is bound to vfio-pci
+	vfs := []struct {
+		addr   string
+		driver string
+		netdev string
+		group  string
+	}{
+		{"0000:31:00.2", "mlx5_core", "ens60f0npf0vf0", "42"},
+		{"0000:31:00.3", "vfio-pci", "", "43"},
+	}
+	for i, vf := range vfs {
+		vfDir := filepath.Join(devicesDir, vf.addr)
+		writeSysfsFiles(t, vfDir, map[string]string{
+			"vendor": "0x15b3",
+			"device": "0x101e",
+			"class":  "0x020000",
+		})
+		symlink(t, "../../../bus/pci/drivers/"+vf.driver, filepath.Join(vfDir, "driver"))
+		symlink(t, "../../../kernel/iommu_groups/"+vf.group, filepath.Join(vfDir, "iommu_group"))
+		symlink(t, "../0000:31:00.0", filepath.Join(vfDir, "physfn"))
+		if vf.netdev != "" {
+			writeSysfsFiles(t, filepath.Join(vfDir, "net", vf.netdev), nil)
+		}
+		symlink(t, "../"+vf.addr, filepath.Join(pfDir, "virtfn"+string(rune('0'+i))))
+	}
+
+	devices, err := ParseSysfsPciDevices()
+	if err != nil {
+		t.Fatalf("ParseSysfsPciDevices returned error: %v", err)
+	}
+
+	byBus := make(map[string]SysfsPciDevice)
+	for _, d := range devices {
+		byBus[d.Bus] = d
+	}
+
+	pf, ok := byBus["0000:31:00.0"]
+	if !ok {
+		t.Fatal("PF not discovered")
+	}
+	if len(pf.VFs) != len(vfs) {
+		t.Fatalf("expected %d VFs, got %d", len(vfs), len(pf.VFs))
+	}
+	for i, expected := range vfs {
+		vf := pf.VFs[i]
+		if vf.Index != i {
+			t.Errorf("VF %d: expected index %d, got %d", i, i, vf.Index)
+		}
+		if vf.PCIAddress != expected.addr {
+			t.Errorf("VF %d: expected address %s, got %s", i, expected.addr, vf.PCIAddress)
+		}
+		if vf.Driver != expected.driver {
+			t.Errorf("VF %d: expected driver %s, got %s", i, expected.driver, vf.Driver)
+		}
+		if vf.NetDev != expected.netdev {
+			t.Errorf("VF %d: expected netdev %q, got %q", i, expected.netdev, vf.NetDev)
+		}
+		if vf.IOMMUGroup != expected.group {
+			t.Errorf("VF %d: expected IOMMU group %s, got %s", i, expected.group, vf.IOMMUGroup)
+		}
+		if vf.PFAddress != "0000:31:00.0" {
+			t.Errorf("VF %d: expected PF 0000:31:00.0, got %s", i, vf.PFAddress)
+		}
+	}
+	if pf.PhysFn != "" {
+		t.Errorf("PF should not have a physfn link, got %s", pf.PhysFn)
+	}
+
+	vf, ok := byBus["0000:31:00.3"]
+	if !ok {
+		t.Fatal("VF not discovered")
+	}
+	if vf.PhysFn != "0000:31:00.0" {
+		t.Errorf("expected VF physfn 0000:31:00.0, got %s", vf.PhysFn)
+	}
+}
+
+// TestDeviceGetVF tests VF lookup by index on a Device
+func TestDeviceGetVF(t *testing.T) {
+	device := Device{
+		PCIAddress: "0000:31:00.0",
+		VFs: []VirtualFunction{
+			{Index: 0, PCIAddress: "0000:31:00.2"},
+			{Index: 1, PCIAddress: "0000:31:00.3"},
+		},
+	}
+
+	if vf := device.GetVF(1); vf == nil || vf.PCIAddress != "0000:31:00.3" {
+		t.Errorf("expected VF 1 at 0000:31:00.3, got %v", vf)
+	}
+	if vf := device.GetVF(5); vf != nil {
+		t.Errorf("expected nil for missing VF, got %v", vf)
+	}
+	if device.IsVF() {
+		t.Error("PF should not report as VF")
+	}
+	if !(&Device{PhysFn: "0000:31:00.0"}).IsVF() {
+		t.Error("device with physfn should report as VF")
+	}
+}
+
+// TestAttachVFInfo tests merging netlink VF administrative state onto sysfs VFs
+func TestAttachVFInfo(t *testing.T) {
+	defer SetGetVFAdminInfo(netlinkVFAdminInfo)
+	SetGetVFAdminInfo(func(pfName string) (map[int]VirtualFunction, error) {
+		if pfName != "ens60f0np0" {
+			t.Errorf("unexpected PF lookup %s", pfName)
+		}
+		return map[int]VirtualFunction{
+			1: {Index: 1, MAC: "02:00:00:00:00:01", Vlan: 100, Trust: true, SpoofChk: true, LinkState: "enable", MaxTxRate: 1000},
+		}, nil
+	})
+
+	devices := []Device{
+		{
+			Name:         "ens60f0np0",
+			PCIAddress:   "0000:31:00.0",
+			SRIOVCapable: true,
+			VFs: []VirtualFunction{
+				{Index: 0, PCIAddress: "0000:31:00.2", Driver: "mlx5_core"},
+				{Index: 1, PCIAddress: "0000:31:00.3", Driver: "vfio-pci"},
+			},
+		},
+		{Name: "ens61f0", PCIAddress: "0000:32:00.0"},
+	}
+
+	devices, err := AttachVFInfo(devices)
+	if err != nil {
+		t.Fatalf("AttachVFInfo failed: %v", err)
+	}
+
+	vf := devices[0].GetVF(1)
+	if vf.MAC != "02:00:00:00:00:01" || vf.Vlan != 100 || !vf.Trust || !vf.SpoofChk || vf.LinkState != "enable" || vf.MaxTxRate != 1000 {
+		t.Errorf("VF admin state not merged: %+v", *vf)
+	}
+	if vf.Driver != "vfio-pci" || vf.PCIAddress != "0000:31:00.3" {
+		t.Errorf("sysfs VF fields overwritten: %+v", *vf)
+	}
+	if devices[0].GetVF(0).MAC != "" {
+		t.Errorf("VF 0 should have no admin state, got %+v", *devices[0].GetVF(0))
+	}
+}
+
+// TestFindPF tests PF lookup by PCI address and interface name
+func TestFindPF(t *testing.T) {
+	devices := []Device{
+		{Name: "ens60f0np0", PCIAddress: "0000:31:00.0", SRIOVCapable: true},
+		{Name: "eno1", PCIAddress: "0000:05:00.0"},
+	}
+
+	for _, key := range []string{"ens60f0np0", "0000:31:00.0"} {
+		pf, err := FindPF(devices, key)
+		if err != nil {
+			t.Errorf("FindPF(%s) failed: %v", key, err)
+		} else if pf.PCIAddress != "0000:31:00.0" {
+			t.Errorf("FindPF(%s) returned %s", key, pf.PCIAddress)
+		}
+	}
+
+	if _, err := FindPF(devices, "eno1"); err == nil {
+		t.Error("expected error for non SR-IOV device")
+	}
+	if _, err := FindPF(devices, "missing0"); err == nil {
+		t.Error("expected error for missing device")
+	}
+}
+
+// TestInUseVFs tests that a VF netdev counts as in use when it is
+// administratively up, whatever its operstate
+func TestInUseVFs(t *testing.T) {
+	root := t.TempDir()
+	SetSysfsRoot(root)
+	defer SetSysfsRoot("")
+
+	devicesDir := filepath.Join(root, "bus", "pci", "devices")
+	pfDir := filepath.Join(devicesDir, "0000:31:00.0")
+	vfs := []struct{ addr, netdev, flags, operstate string }{
+		{"0000:31:00.2", "ens60f0v0", "0x1003", "down"},
+		{"0000:31:00.3", "ens60f0v1", "0x1002", "down"},
+	}
+	for i, vf := range vfs {
+		vfDir := filepath.Join(devicesDir, vf.addr)
+		writeSysfsFiles(t, filepath.Join(vfDir, "net", vf.netdev), nil)
+		symlink(t, "../../../bus/pci/drivers/mlx5_core", filepath.Join(vfDir, "driver"))
+		symlink(t, "../"+vf.addr, filepath.Join(pfDir, "virtfn"+string(rune('0'+i))))
+		writeSysfsFiles(t, filepath.Join(root, "class", "net", vf.netdev), map[string]string{
+			"flags":     vf.flags + "\n",
+			"operstate": vf.operstate + "\n",
+		})
+	}
+
+	inUse, err := inUseVFs("0000:31:00.0")
+	if err != nil {
+		t.Fatalf("inUseVFs failed: %v", err)
+	}
+	if len(inUse) != 1 || inUse[0] != "vf0 (0000:31:00.2) netdev ens60f0v0 is up" {
+		t.Errorf("expected only vf0 in use, got %v", inUse)
+	}
+}
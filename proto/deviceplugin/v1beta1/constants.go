@@ -0,0 +1,15 @@
+package v1beta1
+
+const (
+	// Healthy means the device is usable
+	Healthy = "Healthy"
+	// Unhealthy means the device must not be allocated
+	Unhealthy = "Unhealthy"
+
+	// Version is the API version sent in RegisterRequest
+	Version = "v1beta1"
+	// DevicePluginPath is the directory holding the kubelet and plugin sockets
+	DevicePluginPath = "/var/lib/kubelet/device-plugins/"
+	// KubeletSocket is the name of the kubelet registration socket
+	KubeletSocket = "kubelet.sock"
+)
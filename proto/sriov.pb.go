@@ -21,6 +21,59 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// DeviceEventType is the kind of change reported by WatchDevices
+type DeviceEventType int32
+
+const (
+	DeviceEventType_DEVICE_EVENT_UNSPECIFIED DeviceEventType = 0
+	DeviceEventType_DEVICE_ADDED             DeviceEventType = 1
+	DeviceEventType_DEVICE_REMOVED           DeviceEventType = 2
+	DeviceEventType_DEVICE_MODIFIED          DeviceEventType = 3
+)
+
+// Enum value maps for DeviceEventType.
+var (
+	DeviceEventType_name = map[int32]string{
+		0: "DEVICE_EVENT_UNSPECIFIED",
+		1: "DEVICE_ADDED",
+		2: "DEVICE_REMOVED",
+		3: "DEVICE_MODIFIED",
+	}
+	DeviceEventType_value = map[string]int32{
+		"DEVICE_EVENT_UNSPECIFIED": 0,
+		"DEVICE_ADDED":             1,
+		"DEVICE_REMOVED":           2,
+		"DEVICE_MODIFIED":          3,
+	}
+)
+
+func (x DeviceEventType) Enum() *DeviceEventType {
+	p := new(DeviceEventType)
+	*p = x
+	return p
+}
+
+func (x DeviceEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeviceEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_sriov_proto_enumTypes[0].Descriptor()
+}
+
+func (DeviceEventType) Type() protoreflect.EnumType {
+	return &file_sriov_proto_enumTypes[0]
+}
+
+func (x DeviceEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeviceEventType.Descriptor instead.
+func (DeviceEventType) EnumDescriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{0}
+}
+
 type Empty struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -461,6 +514,182 @@ func (x *EthtoolInfo) GetChannels() *EthtoolChannelInfo {
 	return nil
 }
 
+// VirtualFunction describes a single SR-IOV VF and its administrative state
+// as configured on the parent PF
+type VirtualFunction struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Index      int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	PciAddress string                 `protobuf:"bytes,2,opt,name=pci_address,json=pciAddress,proto3" json:"pci_address,omitempty"`
+	Driver     string                 `protobuf:"bytes,3,opt,name=driver,proto3" json:"driver,omitempty"`
+	Netdev     string                 `protobuf:"bytes,4,opt,name=netdev,proto3" json:"netdev,omitempty"`
+	Mac        string                 `protobuf:"bytes,5,opt,name=mac,proto3" json:"mac,omitempty"`
+	Vlan       int32                  `protobuf:"varint,6,opt,name=vlan,proto3" json:"vlan,omitempty"`
+	Qos        int32                  `protobuf:"varint,7,opt,name=qos,proto3" json:"qos,omitempty"`
+	Trust      bool                   `protobuf:"varint,8,opt,name=trust,proto3" json:"trust,omitempty"`
+	Spoofchk   bool                   `protobuf:"varint,9,opt,name=spoofchk,proto3" json:"spoofchk,omitempty"`
+	LinkState  string                 `protobuf:"bytes,10,opt,name=link_state,json=linkState,proto3" json:"link_state,omitempty"`
+	MinTxRate  uint32                 `protobuf:"varint,11,opt,name=min_tx_rate,json=minTxRate,proto3" json:"min_tx_rate,omitempty"`
+	MaxTxRate  uint32                 `protobuf:"varint,12,opt,name=max_tx_rate,json=maxTxRate,proto3" json:"max_tx_rate,omitempty"`
+	IommuGroup string                 `protobuf:"bytes,13,opt,name=iommu_group,json=iommuGroup,proto3" json:"iommu_group,omitempty"`
+	PfAddress  string                 `protobuf:"bytes,14,opt,name=pf_address,json=pfAddress,proto3" json:"pf_address,omitempty"`
+	// Switchdev representor netdev of the VF and the function behind it
+	Representor  string        `protobuf:"bytes,15,opt,name=representor,proto3" json:"representor,omitempty"`
+	PortFunction *PortFunction `protobuf:"bytes,16,opt,name=port_function,json=portFunction,proto3" json:"port_function,omitempty"`
+	// Whether the VF may query the PF's RSS redirection table and key
+	QueryRss      bool `protobuf:"varint,17,opt,name=query_rss,json=queryRss,proto3" json:"query_rss,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VirtualFunction) Reset() {
+	*x = VirtualFunction{}
+	mi := &file_sriov_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VirtualFunction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VirtualFunction) ProtoMessage() {}
+
+func (x *VirtualFunction) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VirtualFunction.ProtoReflect.Descriptor instead.
+func (*VirtualFunction) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VirtualFunction) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *VirtualFunction) GetPciAddress() string {
+	if x != nil {
+		return x.PciAddress
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetNetdev() string {
+	if x != nil {
+		return x.Netdev
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetMac() string {
+	if x != nil {
+		return x.Mac
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetVlan() int32 {
+	if x != nil {
+		return x.Vlan
+	}
+	return 0
+}
+
+func (x *VirtualFunction) GetQos() int32 {
+	if x != nil {
+		return x.Qos
+	}
+	return 0
+}
+
+func (x *VirtualFunction) GetTrust() bool {
+	if x != nil {
+		return x.Trust
+	}
+	return false
+}
+
+func (x *VirtualFunction) GetSpoofchk() bool {
+	if x != nil {
+		return x.Spoofchk
+	}
+	return false
+}
+
+func (x *VirtualFunction) GetLinkState() string {
+	if x != nil {
+		return x.LinkState
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetMinTxRate() uint32 {
+	if x != nil {
+		return x.MinTxRate
+	}
+	return 0
+}
+
+func (x *VirtualFunction) GetMaxTxRate() uint32 {
+	if x != nil {
+		return x.MaxTxRate
+	}
+	return 0
+}
+
+func (x *VirtualFunction) GetIommuGroup() string {
+	if x != nil {
+		return x.IommuGroup
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetPfAddress() string {
+	if x != nil {
+		return x.PfAddress
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetRepresentor() string {
+	if x != nil {
+		return x.Representor
+	}
+	return ""
+}
+
+func (x *VirtualFunction) GetPortFunction() *PortFunction {
+	if x != nil {
+		return x.PortFunction
+	}
+	return nil
+}
+
+func (x *VirtualFunction) GetQueryRss() bool {
+	if x != nil {
+		return x.QueryRss
+	}
+	return false
+}
+
 type Device struct {
 	state                protoimpl.MessageState         `protogen:"open.v1"`
 	PciAddress           string                         `protobuf:"bytes,1,opt,name=pci_address,json=pciAddress,proto3" json:"pci_address,omitempty"`
@@ -472,15 +701,28 @@ type Device struct {
 	DetailedCapabilities map[string]*DetailedCapability `protobuf:"bytes,7,rep,name=detailed_capabilities,json=detailedCapabilities,proto3" json:"detailed_capabilities,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	EthtoolInfo          *EthtoolInfo                   `protobuf:"bytes,8,opt,name=ethtool_info,json=ethtoolInfo,proto3" json:"ethtool_info,omitempty"`
 	// NUMA topology information
-	NumaNode      int32           `protobuf:"varint,9,opt,name=numa_node,json=numaNode,proto3" json:"numa_node,omitempty"`
-	NumaDistance  map[int32]int32 `protobuf:"bytes,10,rep,name=numa_distance,json=numaDistance,proto3" json:"numa_distance,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	NumaNode     int32           `protobuf:"varint,9,opt,name=numa_node,json=numaNode,proto3" json:"numa_node,omitempty"`
+	NumaDistance map[int32]int32 `protobuf:"bytes,10,rep,name=numa_distance,json=numaDistance,proto3" json:"numa_distance,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Virtual functions of a PF, ordered by VF index
+	Vfs []*VirtualFunction `protobuf:"bytes,11,rep,name=vfs,proto3" json:"vfs,omitempty"`
+	// Parent PF address when this device is itself a VF
+	Physfn string `protobuf:"bytes,12,opt,name=physfn,proto3" json:"physfn,omitempty"`
+	// SR-IOV VF counts from sriov_totalvfs and sriov_numvfs
+	TotalVfs int32 `protobuf:"varint,13,opt,name=total_vfs,json=totalVfs,proto3" json:"total_vfs,omitempty"`
+	NumVfs   int32 `protobuf:"varint,14,opt,name=num_vfs,json=numVfs,proto3" json:"num_vfs,omitempty"`
+	// Devlink eswitch state of a PF, unset when the driver has no eswitch
+	Eswitch *Eswitch `protobuf:"bytes,15,opt,name=eswitch,proto3" json:"eswitch,omitempty"`
+	// Ports of the PF's devlink instance, ordered by port index
+	DevlinkPorts []*DevlinkPort `protobuf:"bytes,16,rep,name=devlink_ports,json=devlinkPorts,proto3" json:"devlink_ports,omitempty"`
+	// Scalable functions of a PF, ordered by sfnum
+	Sfs           []*SubFunction `protobuf:"bytes,17,rep,name=sfs,proto3" json:"sfs,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Device) Reset() {
 	*x = Device{}
-	mi := &file_sriov_proto_msgTypes[6]
+	mi := &file_sriov_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -492,7 +734,7 @@ func (x *Device) String() string {
 func (*Device) ProtoMessage() {}
 
 func (x *Device) ProtoReflect() protoreflect.Message {
-	mi := &file_sriov_proto_msgTypes[6]
+	mi := &file_sriov_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -505,7 +747,7 @@ func (x *Device) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Device.ProtoReflect.Descriptor instead.
 func (*Device) Descriptor() ([]byte, []int) {
-	return file_sriov_proto_rawDescGZIP(), []int{6}
+	return file_sriov_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *Device) GetPciAddress() string {
@@ -578,63 +820,89 @@ func (x *Device) GetNumaDistance() map[int32]int32 {
 	return nil
 }
 
-type ListDevicesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *Device) GetVfs() []*VirtualFunction {
+	if x != nil {
+		return x.Vfs
+	}
+	return nil
 }
 
-func (x *ListDevicesRequest) Reset() {
-	*x = ListDevicesRequest{}
-	mi := &file_sriov_proto_msgTypes[7]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *Device) GetPhysfn() string {
+	if x != nil {
+		return x.Physfn
+	}
+	return ""
 }
 
-func (x *ListDevicesRequest) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *Device) GetTotalVfs() int32 {
+	if x != nil {
+		return x.TotalVfs
+	}
+	return 0
 }
 
-func (*ListDevicesRequest) ProtoMessage() {}
+func (x *Device) GetNumVfs() int32 {
+	if x != nil {
+		return x.NumVfs
+	}
+	return 0
+}
 
-func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_sriov_proto_msgTypes[7]
+func (x *Device) GetEswitch() *Eswitch {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.Eswitch
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
-func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
-	return file_sriov_proto_rawDescGZIP(), []int{7}
+func (x *Device) GetDevlinkPorts() []*DevlinkPort {
+	if x != nil {
+		return x.DevlinkPorts
+	}
+	return nil
 }
 
-type ListDevicesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Devices       []*Device              `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+func (x *Device) GetSfs() []*SubFunction {
+	if x != nil {
+		return x.Sfs
+	}
+	return nil
+}
+
+// SubFunction is a scalable function of a PF
+type SubFunction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Sfnum uint32                 `protobuf:"varint,1,opt,name=sfnum,proto3" json:"sfnum,omitempty"`
+	Pfnum uint32                 `protobuf:"varint,2,opt,name=pfnum,proto3" json:"pfnum,omitempty"`
+	// Devlink port index and netdev of the SF's eswitch representor
+	PortIndex   uint32 `protobuf:"varint,3,opt,name=port_index,json=portIndex,proto3" json:"port_index,omitempty"`
+	Representor string `protobuf:"bytes,4,opt,name=representor,proto3" json:"representor,omitempty"`
+	HwAddr      string `protobuf:"bytes,5,opt,name=hw_addr,json=hwAddr,proto3" json:"hw_addr,omitempty"`
+	// active or inactive
+	State string `protobuf:"bytes,6,opt,name=state,proto3" json:"state,omitempty"`
+	// attached or detached
+	Opstate string `protobuf:"bytes,7,opt,name=opstate,proto3" json:"opstate,omitempty"`
+	// Auxiliary bus device and netdev of an active SF
+	AuxDevice     string `protobuf:"bytes,8,opt,name=aux_device,json=auxDevice,proto3" json:"aux_device,omitempty"`
+	Netdev        string `protobuf:"bytes,9,opt,name=netdev,proto3" json:"netdev,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListDevicesResponse) Reset() {
-	*x = ListDevicesResponse{}
+func (x *SubFunction) Reset() {
+	*x = SubFunction{}
 	mi := &file_sriov_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListDevicesResponse) String() string {
+func (x *SubFunction) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDevicesResponse) ProtoMessage() {}
+func (*SubFunction) ProtoMessage() {}
 
-func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+func (x *SubFunction) ProtoReflect() protoreflect.Message {
 	mi := &file_sriov_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -646,38 +914,113 @@ func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
-func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SubFunction.ProtoReflect.Descriptor instead.
+func (*SubFunction) Descriptor() ([]byte, []int) {
 	return file_sriov_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ListDevicesResponse) GetDevices() []*Device {
+func (x *SubFunction) GetSfnum() uint32 {
 	if x != nil {
-		return x.Devices
+		return x.Sfnum
 	}
-	return nil
+	return 0
 }
 
-type RefreshDevicesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+func (x *SubFunction) GetPfnum() uint32 {
+	if x != nil {
+		return x.Pfnum
+	}
+	return 0
+}
+
+func (x *SubFunction) GetPortIndex() uint32 {
+	if x != nil {
+		return x.PortIndex
+	}
+	return 0
+}
+
+func (x *SubFunction) GetRepresentor() string {
+	if x != nil {
+		return x.Representor
+	}
+	return ""
+}
+
+func (x *SubFunction) GetHwAddr() string {
+	if x != nil {
+		return x.HwAddr
+	}
+	return ""
+}
+
+func (x *SubFunction) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *SubFunction) GetOpstate() string {
+	if x != nil {
+		return x.Opstate
+	}
+	return ""
+}
+
+func (x *SubFunction) GetAuxDevice() string {
+	if x != nil {
+		return x.AuxDevice
+	}
+	return ""
+}
+
+func (x *SubFunction) GetNetdev() string {
+	if x != nil {
+		return x.Netdev
+	}
+	return ""
+}
+
+// DevlinkPort is a devlink port; pcipf, pcivf and pcisf ports are eswitch
+// representors
+type DevlinkPort struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// e.g. pci/0000:31:00.0/1
+	Handle     string `protobuf:"bytes,1,opt,name=handle,proto3" json:"handle,omitempty"`
+	PciAddress string `protobuf:"bytes,2,opt,name=pci_address,json=pciAddress,proto3" json:"pci_address,omitempty"`
+	Index      uint32 `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	Type       string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Netdev     string `protobuf:"bytes,5,opt,name=netdev,proto3" json:"netdev,omitempty"`
+	// physical, pcipf, pcivf, pcisf or virtual
+	Flavour string `protobuf:"bytes,6,opt,name=flavour,proto3" json:"flavour,omitempty"`
+	// Physical port number of a physical port
+	Number        uint32        `protobuf:"varint,7,opt,name=number,proto3" json:"number,omitempty"`
+	Controller    uint32        `protobuf:"varint,8,opt,name=controller,proto3" json:"controller,omitempty"`
+	Pfnum         uint32        `protobuf:"varint,9,opt,name=pfnum,proto3" json:"pfnum,omitempty"`
+	Vfnum         uint32        `protobuf:"varint,10,opt,name=vfnum,proto3" json:"vfnum,omitempty"`
+	Sfnum         uint32        `protobuf:"varint,11,opt,name=sfnum,proto3" json:"sfnum,omitempty"`
+	External      bool          `protobuf:"varint,12,opt,name=external,proto3" json:"external,omitempty"`
+	Splittable    bool          `protobuf:"varint,13,opt,name=splittable,proto3" json:"splittable,omitempty"`
+	Function      *PortFunction `protobuf:"bytes,14,opt,name=function,proto3" json:"function,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RefreshDevicesRequest) Reset() {
-	*x = RefreshDevicesRequest{}
+func (x *DevlinkPort) Reset() {
+	*x = DevlinkPort{}
 	mi := &file_sriov_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RefreshDevicesRequest) String() string {
+func (x *DevlinkPort) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RefreshDevicesRequest) ProtoMessage() {}
+func (*DevlinkPort) ProtoMessage() {}
 
-func (x *RefreshDevicesRequest) ProtoReflect() protoreflect.Message {
+func (x *DevlinkPort) ProtoReflect() protoreflect.Message {
 	mi := &file_sriov_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -689,35 +1032,1102 @@ func (x *RefreshDevicesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RefreshDevicesRequest.ProtoReflect.Descriptor instead.
-func (*RefreshDevicesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use DevlinkPort.ProtoReflect.Descriptor instead.
+func (*DevlinkPort) Descriptor() ([]byte, []int) {
 	return file_sriov_proto_rawDescGZIP(), []int{9}
 }
 
-type RefreshDevicesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	DeviceCount   int32                  `protobuf:"varint,3,opt,name=device_count,json=deviceCount,proto3" json:"device_count,omitempty"`
-	unknownFields protoimpl.UnknownFields
+func (x *DevlinkPort) GetHandle() string {
+	if x != nil {
+		return x.Handle
+	}
+	return ""
+}
+
+func (x *DevlinkPort) GetPciAddress() string {
+	if x != nil {
+		return x.PciAddress
+	}
+	return ""
+}
+
+func (x *DevlinkPort) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DevlinkPort) GetNetdev() string {
+	if x != nil {
+		return x.Netdev
+	}
+	return ""
+}
+
+func (x *DevlinkPort) GetFlavour() string {
+	if x != nil {
+		return x.Flavour
+	}
+	return ""
+}
+
+func (x *DevlinkPort) GetNumber() uint32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetController() uint32 {
+	if x != nil {
+		return x.Controller
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetPfnum() uint32 {
+	if x != nil {
+		return x.Pfnum
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetVfnum() uint32 {
+	if x != nil {
+		return x.Vfnum
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetSfnum() uint32 {
+	if x != nil {
+		return x.Sfnum
+	}
+	return 0
+}
+
+func (x *DevlinkPort) GetExternal() bool {
+	if x != nil {
+		return x.External
+	}
+	return false
+}
+
+func (x *DevlinkPort) GetSplittable() bool {
+	if x != nil {
+		return x.Splittable
+	}
+	return false
+}
+
+func (x *DevlinkPort) GetFunction() *PortFunction {
+	if x != nil {
+		return x.Function
+	}
+	return nil
+}
+
+// PortFunction is the function behind a representor port
+type PortFunction struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	HwAddr string                 `protobuf:"bytes,1,opt,name=hw_addr,json=hwAddr,proto3" json:"hw_addr,omitempty"`
+	// active or inactive
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// attached or detached
+	Opstate string `protobuf:"bytes,3,opt,name=opstate,proto3" json:"opstate,omitempty"`
+	// enable or disable, empty when not reported
+	Roce          string `protobuf:"bytes,4,opt,name=roce,proto3" json:"roce,omitempty"`
+	Migratable    string `protobuf:"bytes,5,opt,name=migratable,proto3" json:"migratable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PortFunction) Reset() {
+	*x = PortFunction{}
+	mi := &file_sriov_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PortFunction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PortFunction) ProtoMessage() {}
+
+func (x *PortFunction) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PortFunction.ProtoReflect.Descriptor instead.
+func (*PortFunction) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PortFunction) GetHwAddr() string {
+	if x != nil {
+		return x.HwAddr
+	}
+	return ""
+}
+
+func (x *PortFunction) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *PortFunction) GetOpstate() string {
+	if x != nil {
+		return x.Opstate
+	}
+	return ""
+}
+
+func (x *PortFunction) GetRoce() string {
+	if x != nil {
+		return x.Roce
+	}
+	return ""
+}
+
+func (x *PortFunction) GetMigratable() string {
+	if x != nil {
+		return x.Migratable
+	}
+	return ""
+}
+
+// Eswitch is the devlink eswitch configuration of a PF
+type Eswitch struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// legacy or switchdev
+	Mode string `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	// none, link, network or transport
+	InlineMode string `protobuf:"bytes,2,opt,name=inline_mode,json=inlineMode,proto3" json:"inline_mode,omitempty"`
+	// none or basic
+	EncapMode     string `protobuf:"bytes,3,opt,name=encap_mode,json=encapMode,proto3" json:"encap_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Eswitch) Reset() {
+	*x = Eswitch{}
+	mi := &file_sriov_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Eswitch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Eswitch) ProtoMessage() {}
+
+func (x *Eswitch) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Eswitch.ProtoReflect.Descriptor instead.
+func (*Eswitch) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Eswitch) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Eswitch) GetInlineMode() string {
+	if x != nil {
+		return x.InlineMode
+	}
+	return ""
+}
+
+func (x *Eswitch) GetEncapMode() string {
+	if x != nil {
+		return x.EncapMode
+	}
+	return ""
+}
+
+type ListDevicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesRequest) Reset() {
+	*x = ListDevicesRequest{}
+	mi := &file_sriov_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRequest) ProtoMessage() {}
+
+func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
+func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{12}
+}
+
+type ListDevicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Devices       []*Device              `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDevicesResponse) Reset() {
+	*x = ListDevicesResponse{}
+	mi := &file_sriov_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesResponse) ProtoMessage() {}
+
+func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
+func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListDevicesResponse) GetDevices() []*Device {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type RefreshDevicesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshDevicesRequest) Reset() {
+	*x = RefreshDevicesRequest{}
+	mi := &file_sriov_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshDevicesRequest) ProtoMessage() {}
+
+func (x *RefreshDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshDevicesRequest.ProtoReflect.Descriptor instead.
+func (*RefreshDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{14}
+}
+
+type RefreshDevicesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	DeviceCount   int32                  `protobuf:"varint,3,opt,name=device_count,json=deviceCount,proto3" json:"device_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshDevicesResponse) Reset() {
+	*x = RefreshDevicesResponse{}
+	mi := &file_sriov_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshDevicesResponse) ProtoMessage() {}
+
+func (x *RefreshDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshDevicesResponse.ProtoReflect.Descriptor instead.
+func (*RefreshDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *RefreshDevicesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RefreshDevicesResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RefreshDevicesResponse) GetDeviceCount() int32 {
+	if x != nil {
+		return x.DeviceCount
+	}
+	return 0
+}
+
+type ListVFsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// PF to list, by PCI address or interface name
+	Pf            string `protobuf:"bytes,1,opt,name=pf,proto3" json:"pf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVFsRequest) Reset() {
+	*x = ListVFsRequest{}
+	mi := &file_sriov_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVFsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVFsRequest) ProtoMessage() {}
+
+func (x *ListVFsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVFsRequest.ProtoReflect.Descriptor instead.
+func (*ListVFsRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListVFsRequest) GetPf() string {
+	if x != nil {
+		return x.Pf
+	}
+	return ""
+}
+
+type ListVFsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PfAddress     string                 `protobuf:"bytes,1,opt,name=pf_address,json=pfAddress,proto3" json:"pf_address,omitempty"`
+	PfName        string                 `protobuf:"bytes,2,opt,name=pf_name,json=pfName,proto3" json:"pf_name,omitempty"`
+	Vfs           []*VirtualFunction     `protobuf:"bytes,3,rep,name=vfs,proto3" json:"vfs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListVFsResponse) Reset() {
+	*x = ListVFsResponse{}
+	mi := &file_sriov_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListVFsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVFsResponse) ProtoMessage() {}
+
+func (x *ListVFsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVFsResponse.ProtoReflect.Descriptor instead.
+func (*ListVFsResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListVFsResponse) GetPfAddress() string {
+	if x != nil {
+		return x.PfAddress
+	}
+	return ""
+}
+
+func (x *ListVFsResponse) GetPfName() string {
+	if x != nil {
+		return x.PfName
+	}
+	return ""
+}
+
+func (x *ListVFsResponse) GetVfs() []*VirtualFunction {
+	if x != nil {
+		return x.Vfs
+	}
+	return nil
+}
+
+type WatchDevicesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Send an ADDED event for every known device before streaming changes
+	IncludeExisting bool `protobuf:"varint,1,opt,name=include_existing,json=includeExisting,proto3" json:"include_existing,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *WatchDevicesRequest) Reset() {
+	*x = WatchDevicesRequest{}
+	mi := &file_sriov_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDevicesRequest) ProtoMessage() {}
+
+func (x *WatchDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDevicesRequest.ProtoReflect.Descriptor instead.
+func (*WatchDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *WatchDevicesRequest) GetIncludeExisting() bool {
+	if x != nil {
+		return x.IncludeExisting
+	}
+	return false
+}
+
+type DeviceEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  DeviceEventType        `protobuf:"varint,1,opt,name=type,proto3,enum=sriov.DeviceEventType" json:"type,omitempty"`
+	// Device state after the change, or the last known state for removals
+	Device *Device `protobuf:"bytes,2,opt,name=device,proto3" json:"device,omitempty"`
+	// Names of the device fields that changed, set for MODIFIED events
+	ChangedFields []string `protobuf:"bytes,3,rep,name=changed_fields,json=changedFields,proto3" json:"changed_fields,omitempty"`
+	// Unix time of the change in nanoseconds
+	Timestamp     int64 `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceEvent) Reset() {
+	*x = DeviceEvent{}
+	mi := &file_sriov_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceEvent) ProtoMessage() {}
+
+func (x *DeviceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceEvent.ProtoReflect.Descriptor instead.
+func (*DeviceEvent) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeviceEvent) GetType() DeviceEventType {
+	if x != nil {
+		return x.Type
+	}
+	return DeviceEventType_DEVICE_EVENT_UNSPECIFIED
+}
+
+func (x *DeviceEvent) GetDevice() *Device {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+func (x *DeviceEvent) GetChangedFields() []string {
+	if x != nil {
+		return x.ChangedFields
+	}
+	return nil
+}
+
+func (x *DeviceEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+// VFLease records VFs handed out from a resource pool to one owner
+type VFLease struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Pool     string                 `protobuf:"bytes,2,opt,name=pool,proto3" json:"pool,omitempty"`
+	Owner    string                 `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Metadata map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// PCI addresses of the leased VFs
+	PciAddresses []string `protobuf:"bytes,5,rep,name=pci_addresses,json=pciAddresses,proto3" json:"pci_addresses,omitempty"`
+	// Unix times in nanoseconds; expires_at is 0 for leases without a TTL
+	CreatedAt     int64 `protobuf:"varint,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt     int64 `protobuf:"varint,7,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VFLease) Reset() {
+	*x = VFLease{}
+	mi := &file_sriov_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VFLease) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VFLease) ProtoMessage() {}
+
+func (x *VFLease) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VFLease.ProtoReflect.Descriptor instead.
+func (*VFLease) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *VFLease) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *VFLease) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *VFLease) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *VFLease) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *VFLease) GetPciAddresses() []string {
+	if x != nil {
+		return x.PciAddresses
+	}
+	return nil
+}
+
+func (x *VFLease) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *VFLease) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+type AllocateVFRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resource pool name from the server configuration
+	Pool  string `protobuf:"bytes,1,opt,name=pool,proto3" json:"pool,omitempty"`
+	Count int32  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// Preferred NUMA node; unset lets the server pick the fullest node
+	NumaNode *int32 `protobuf:"varint,3,opt,name=numa_node,json=numaNode,proto3,oneof" json:"numa_node,omitempty"`
+	// Fail rather than use VFs outside numa_node
+	StrictNuma bool              `protobuf:"varint,4,opt,name=strict_numa,json=strictNuma,proto3" json:"strict_numa,omitempty"`
+	Owner      string            `protobuf:"bytes,5,opt,name=owner,proto3" json:"owner,omitempty"`
+	Metadata   map[string]string `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Lease lifetime; 0 keeps the lease until it is released
+	TtlSeconds    int64 `protobuf:"varint,7,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AllocateVFRequest) Reset() {
+	*x = AllocateVFRequest{}
+	mi := &file_sriov_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AllocateVFRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AllocateVFRequest) ProtoMessage() {}
+
+func (x *AllocateVFRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AllocateVFRequest.ProtoReflect.Descriptor instead.
+func (*AllocateVFRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AllocateVFRequest) GetPool() string {
+	if x != nil {
+		return x.Pool
+	}
+	return ""
+}
+
+func (x *AllocateVFRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *AllocateVFRequest) GetNumaNode() int32 {
+	if x != nil && x.NumaNode != nil {
+		return *x.NumaNode
+	}
+	return 0
+}
+
+func (x *AllocateVFRequest) GetStrictNuma() bool {
+	if x != nil {
+		return x.StrictNuma
+	}
+	return false
+}
+
+func (x *AllocateVFRequest) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *AllocateVFRequest) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *AllocateVFRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type AllocateVFResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lease         *VFLease               `protobuf:"bytes,1,opt,name=lease,proto3" json:"lease,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AllocateVFResponse) Reset() {
+	*x = AllocateVFResponse{}
+	mi := &file_sriov_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AllocateVFResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AllocateVFResponse) ProtoMessage() {}
+
+func (x *AllocateVFResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AllocateVFResponse.ProtoReflect.Descriptor instead.
+func (*AllocateVFResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *AllocateVFResponse) GetLease() *VFLease {
+	if x != nil {
+		return x.Lease
+	}
+	return nil
+}
+
+type ReleaseVFRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeaseId       string                 `protobuf:"bytes,1,opt,name=lease_id,json=leaseId,proto3" json:"lease_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseVFRequest) Reset() {
+	*x = ReleaseVFRequest{}
+	mi := &file_sriov_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseVFRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseVFRequest) ProtoMessage() {}
+
+func (x *ReleaseVFRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseVFRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseVFRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ReleaseVFRequest) GetLeaseId() string {
+	if x != nil {
+		return x.LeaseId
+	}
+	return ""
+}
+
+type ReleaseVFResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseVFResponse) Reset() {
+	*x = ReleaseVFResponse{}
+	mi := &file_sriov_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseVFResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseVFResponse) ProtoMessage() {}
+
+func (x *ReleaseVFResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseVFResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseVFResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{24}
+}
+
+type BindDriverRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// PCI address of the VF to rebind
+	PciAddress string `protobuf:"bytes,1,opt,name=pci_address,json=pciAddress,proto3" json:"pci_address,omitempty"`
+	// Kernel driver to bind to, e.g. vfio-pci or mlx5_core
+	Driver        string `protobuf:"bytes,2,opt,name=driver,proto3" json:"driver,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BindDriverRequest) Reset() {
+	*x = BindDriverRequest{}
+	mi := &file_sriov_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindDriverRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindDriverRequest) ProtoMessage() {}
+
+func (x *BindDriverRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindDriverRequest.ProtoReflect.Descriptor instead.
+func (*BindDriverRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *BindDriverRequest) GetPciAddress() string {
+	if x != nil {
+		return x.PciAddress
+	}
+	return ""
+}
+
+func (x *BindDriverRequest) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+type BindDriverResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PciAddress     string                 `protobuf:"bytes,1,opt,name=pci_address,json=pciAddress,proto3" json:"pci_address,omitempty"`
+	PreviousDriver string                 `protobuf:"bytes,2,opt,name=previous_driver,json=previousDriver,proto3" json:"previous_driver,omitempty"`
+	Driver         string                 `protobuf:"bytes,3,opt,name=driver,proto3" json:"driver,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BindDriverResponse) Reset() {
+	*x = BindDriverResponse{}
+	mi := &file_sriov_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BindDriverResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BindDriverResponse) ProtoMessage() {}
+
+func (x *BindDriverResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BindDriverResponse.ProtoReflect.Descriptor instead.
+func (*BindDriverResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *BindDriverResponse) GetPciAddress() string {
+	if x != nil {
+		return x.PciAddress
+	}
+	return ""
+}
+
+func (x *BindDriverResponse) GetPreviousDriver() string {
+	if x != nil {
+		return x.PreviousDriver
+	}
+	return ""
+}
+
+func (x *BindDriverResponse) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
+type ListSFsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// PF to list, by PCI address or interface name
+	Pf            string `protobuf:"bytes,1,opt,name=pf,proto3" json:"pf,omitempty"`
+	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *RefreshDevicesResponse) Reset() {
-	*x = RefreshDevicesResponse{}
-	mi := &file_sriov_proto_msgTypes[10]
+func (x *ListSFsRequest) Reset() {
+	*x = ListSFsRequest{}
+	mi := &file_sriov_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *RefreshDevicesResponse) String() string {
+func (x *ListSFsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RefreshDevicesResponse) ProtoMessage() {}
+func (*ListSFsRequest) ProtoMessage() {}
 
-func (x *RefreshDevicesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_sriov_proto_msgTypes[10]
+func (x *ListSFsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -728,32 +2138,281 @@ func (x *RefreshDevicesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RefreshDevicesResponse.ProtoReflect.Descriptor instead.
-func (*RefreshDevicesResponse) Descriptor() ([]byte, []int) {
-	return file_sriov_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use ListSFsRequest.ProtoReflect.Descriptor instead.
+func (*ListSFsRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{27}
 }
 
-func (x *RefreshDevicesResponse) GetSuccess() bool {
+func (x *ListSFsRequest) GetPf() string {
 	if x != nil {
-		return x.Success
+		return x.Pf
 	}
-	return false
+	return ""
 }
 
-func (x *RefreshDevicesResponse) GetMessage() string {
+type ListSFsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PfAddress     string                 `protobuf:"bytes,1,opt,name=pf_address,json=pfAddress,proto3" json:"pf_address,omitempty"`
+	PfName        string                 `protobuf:"bytes,2,opt,name=pf_name,json=pfName,proto3" json:"pf_name,omitempty"`
+	Sfs           []*SubFunction         `protobuf:"bytes,3,rep,name=sfs,proto3" json:"sfs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSFsResponse) Reset() {
+	*x = ListSFsResponse{}
+	mi := &file_sriov_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSFsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSFsResponse) ProtoMessage() {}
+
+func (x *ListSFsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[28]
 	if x != nil {
-		return x.Message
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSFsResponse.ProtoReflect.Descriptor instead.
+func (*ListSFsResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListSFsResponse) GetPfAddress() string {
+	if x != nil {
+		return x.PfAddress
 	}
 	return ""
 }
 
-func (x *RefreshDevicesResponse) GetDeviceCount() int32 {
+func (x *ListSFsResponse) GetPfName() string {
 	if x != nil {
-		return x.DeviceCount
+		return x.PfName
+	}
+	return ""
+}
+
+func (x *ListSFsResponse) GetSfs() []*SubFunction {
+	if x != nil {
+		return x.Sfs
+	}
+	return nil
+}
+
+type CreateSFRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// PF to create the SF on, by PCI address or interface name
+	Pf    string `protobuf:"bytes,1,opt,name=pf,proto3" json:"pf,omitempty"`
+	Sfnum uint32 `protobuf:"varint,2,opt,name=sfnum,proto3" json:"sfnum,omitempty"`
+	// MAC address of the SF; empty leaves it to the driver
+	HwAddr string `protobuf:"bytes,3,opt,name=hw_addr,json=hwAddr,proto3" json:"hw_addr,omitempty"`
+	// active or inactive; empty activates the SF
+	State         string `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSFRequest) Reset() {
+	*x = CreateSFRequest{}
+	mi := &file_sriov_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSFRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSFRequest) ProtoMessage() {}
+
+func (x *CreateSFRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSFRequest.ProtoReflect.Descriptor instead.
+func (*CreateSFRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *CreateSFRequest) GetPf() string {
+	if x != nil {
+		return x.Pf
+	}
+	return ""
+}
+
+func (x *CreateSFRequest) GetSfnum() uint32 {
+	if x != nil {
+		return x.Sfnum
+	}
+	return 0
+}
+
+func (x *CreateSFRequest) GetHwAddr() string {
+	if x != nil {
+		return x.HwAddr
+	}
+	return ""
+}
+
+func (x *CreateSFRequest) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+type CreateSFResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sf            *SubFunction           `protobuf:"bytes,1,opt,name=sf,proto3" json:"sf,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSFResponse) Reset() {
+	*x = CreateSFResponse{}
+	mi := &file_sriov_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSFResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSFResponse) ProtoMessage() {}
+
+func (x *CreateSFResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSFResponse.ProtoReflect.Descriptor instead.
+func (*CreateSFResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *CreateSFResponse) GetSf() *SubFunction {
+	if x != nil {
+		return x.Sf
+	}
+	return nil
+}
+
+type DeleteSFRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pf            string                 `protobuf:"bytes,1,opt,name=pf,proto3" json:"pf,omitempty"`
+	Sfnum         uint32                 `protobuf:"varint,2,opt,name=sfnum,proto3" json:"sfnum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSFRequest) Reset() {
+	*x = DeleteSFRequest{}
+	mi := &file_sriov_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSFRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSFRequest) ProtoMessage() {}
+
+func (x *DeleteSFRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSFRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSFRequest) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DeleteSFRequest) GetPf() string {
+	if x != nil {
+		return x.Pf
+	}
+	return ""
+}
+
+func (x *DeleteSFRequest) GetSfnum() uint32 {
+	if x != nil {
+		return x.Sfnum
 	}
 	return 0
 }
 
+type DeleteSFResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSFResponse) Reset() {
+	*x = DeleteSFResponse{}
+	mi := &file_sriov_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSFResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSFResponse) ProtoMessage() {}
+
+func (x *DeleteSFResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_sriov_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSFResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSFResponse) Descriptor() ([]byte, []int) {
+	return file_sriov_proto_rawDescGZIP(), []int{32}
+}
+
 var File_sriov_proto protoreflect.FileDescriptor
 
 const file_sriov_proto_rawDesc = "" +
@@ -800,7 +2459,30 @@ const file_sriov_proto_rawDesc = "" +
 	"\vEthtoolInfo\x121\n" +
 	"\bfeatures\x18\x01 \x03(\v2\x15.sriov.EthtoolFeatureR\bfeatures\x12*\n" +
 	"\x04ring\x18\x02 \x01(\v2\x16.sriov.EthtoolRingInfoR\x04ring\x125\n" +
-	"\bchannels\x18\x03 \x01(\v2\x19.sriov.EthtoolChannelInfoR\bchannels\"\xc9\x04\n" +
+	"\bchannels\x18\x03 \x01(\v2\x19.sriov.EthtoolChannelInfoR\bchannels\"\xfa\x03\n" +
+	"\x0fVirtualFunction\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x1f\n" +
+	"\vpci_address\x18\x02 \x01(\tR\n" +
+	"pciAddress\x12\x16\n" +
+	"\x06driver\x18\x03 \x01(\tR\x06driver\x12\x16\n" +
+	"\x06netdev\x18\x04 \x01(\tR\x06netdev\x12\x10\n" +
+	"\x03mac\x18\x05 \x01(\tR\x03mac\x12\x12\n" +
+	"\x04vlan\x18\x06 \x01(\x05R\x04vlan\x12\x10\n" +
+	"\x03qos\x18\a \x01(\x05R\x03qos\x12\x14\n" +
+	"\x05trust\x18\b \x01(\bR\x05trust\x12\x1a\n" +
+	"\bspoofchk\x18\t \x01(\bR\bspoofchk\x12\x1d\n" +
+	"\n" +
+	"link_state\x18\n" +
+	" \x01(\tR\tlinkState\x12\x1e\n" +
+	"\vmin_tx_rate\x18\v \x01(\rR\tminTxRate\x12\x1e\n" +
+	"\vmax_tx_rate\x18\f \x01(\rR\tmaxTxRate\x12\x1f\n" +
+	"\viommu_group\x18\r \x01(\tR\n" +
+	"iommuGroup\x12\x1d\n" +
+	"\n" +
+	"pf_address\x18\x0e \x01(\tR\tpfAddress\x12 \n" +
+	"\vrepresentor\x18\x0f \x01(\tR\vrepresentor\x128\n" +
+	"\rport_function\x18\x10 \x01(\v2\x13.sriov.PortFunctionR\fportFunction\x12\x1b\n" +
+	"\tquery_rss\x18\x11 \x01(\bR\bqueryRss\"\xca\x06\n" +
 	"\x06Device\x12\x1f\n" +
 	"\vpci_address\x18\x01 \x01(\tR\n" +
 	"pciAddress\x12\x12\n" +
@@ -813,13 +2495,67 @@ const file_sriov_proto_rawDesc = "" +
 	"\fethtool_info\x18\b \x01(\v2\x12.sriov.EthtoolInfoR\vethtoolInfo\x12\x1b\n" +
 	"\tnuma_node\x18\t \x01(\x05R\bnumaNode\x12D\n" +
 	"\rnuma_distance\x18\n" +
-	" \x03(\v2\x1f.sriov.Device.NumaDistanceEntryR\fnumaDistance\x1ab\n" +
+	" \x03(\v2\x1f.sriov.Device.NumaDistanceEntryR\fnumaDistance\x12(\n" +
+	"\x03vfs\x18\v \x03(\v2\x16.sriov.VirtualFunctionR\x03vfs\x12\x16\n" +
+	"\x06physfn\x18\f \x01(\tR\x06physfn\x12\x1b\n" +
+	"\ttotal_vfs\x18\r \x01(\x05R\btotalVfs\x12\x17\n" +
+	"\anum_vfs\x18\x0e \x01(\x05R\x06numVfs\x12(\n" +
+	"\aeswitch\x18\x0f \x01(\v2\x0e.sriov.EswitchR\aeswitch\x127\n" +
+	"\rdevlink_ports\x18\x10 \x03(\v2\x12.sriov.DevlinkPortR\fdevlinkPorts\x12$\n" +
+	"\x03sfs\x18\x11 \x03(\v2\x12.sriov.SubFunctionR\x03sfs\x1ab\n" +
 	"\x19DetailedCapabilitiesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12/\n" +
 	"\x05value\x18\x02 \x01(\v2\x19.sriov.DetailedCapabilityR\x05value:\x028\x01\x1a?\n" +
 	"\x11NumaDistanceEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\x05R\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xfa\x01\n" +
+	"\vSubFunction\x12\x14\n" +
+	"\x05sfnum\x18\x01 \x01(\rR\x05sfnum\x12\x14\n" +
+	"\x05pfnum\x18\x02 \x01(\rR\x05pfnum\x12\x1d\n" +
+	"\n" +
+	"port_index\x18\x03 \x01(\rR\tportIndex\x12 \n" +
+	"\vrepresentor\x18\x04 \x01(\tR\vrepresentor\x12\x17\n" +
+	"\ahw_addr\x18\x05 \x01(\tR\x06hwAddr\x12\x14\n" +
+	"\x05state\x18\x06 \x01(\tR\x05state\x12\x18\n" +
+	"\aopstate\x18\a \x01(\tR\aopstate\x12\x1d\n" +
+	"\n" +
+	"aux_device\x18\b \x01(\tR\tauxDevice\x12\x16\n" +
+	"\x06netdev\x18\t \x01(\tR\x06netdev\"\x89\x03\n" +
+	"\vDevlinkPort\x12\x16\n" +
+	"\x06handle\x18\x01 \x01(\tR\x06handle\x12\x1f\n" +
+	"\vpci_address\x18\x02 \x01(\tR\n" +
+	"pciAddress\x12\x14\n" +
+	"\x05index\x18\x03 \x01(\rR\x05index\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x16\n" +
+	"\x06netdev\x18\x05 \x01(\tR\x06netdev\x12\x18\n" +
+	"\aflavour\x18\x06 \x01(\tR\aflavour\x12\x16\n" +
+	"\x06number\x18\a \x01(\rR\x06number\x12\x1e\n" +
+	"\n" +
+	"controller\x18\b \x01(\rR\n" +
+	"controller\x12\x14\n" +
+	"\x05pfnum\x18\t \x01(\rR\x05pfnum\x12\x14\n" +
+	"\x05vfnum\x18\n" +
+	" \x01(\rR\x05vfnum\x12\x14\n" +
+	"\x05sfnum\x18\v \x01(\rR\x05sfnum\x12\x1a\n" +
+	"\bexternal\x18\f \x01(\bR\bexternal\x12\x1e\n" +
+	"\n" +
+	"splittable\x18\r \x01(\bR\n" +
+	"splittable\x12/\n" +
+	"\bfunction\x18\x0e \x01(\v2\x13.sriov.PortFunctionR\bfunction\"\x8b\x01\n" +
+	"\fPortFunction\x12\x17\n" +
+	"\ahw_addr\x18\x01 \x01(\tR\x06hwAddr\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x18\n" +
+	"\aopstate\x18\x03 \x01(\tR\aopstate\x12\x12\n" +
+	"\x04roce\x18\x04 \x01(\tR\x04roce\x12\x1e\n" +
+	"\n" +
+	"migratable\x18\x05 \x01(\tR\n" +
+	"migratable\"]\n" +
+	"\aEswitch\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x1f\n" +
+	"\vinline_mode\x18\x02 \x01(\tR\n" +
+	"inlineMode\x12\x1d\n" +
+	"\n" +
+	"encap_mode\x18\x03 \x01(\tR\tencapMode\"\x14\n" +
 	"\x12ListDevicesRequest\">\n" +
 	"\x13ListDevicesResponse\x12'\n" +
 	"\adevices\x18\x01 \x03(\v2\r.sriov.DeviceR\adevices\"\x17\n" +
@@ -827,10 +2563,99 @@ const file_sriov_proto_rawDesc = "" +
 	"\x16RefreshDevicesResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12!\n" +
-	"\fdevice_count\x18\x03 \x01(\x05R\vdeviceCount2\xa3\x01\n" +
+	"\fdevice_count\x18\x03 \x01(\x05R\vdeviceCount\" \n" +
+	"\x0eListVFsRequest\x12\x0e\n" +
+	"\x02pf\x18\x01 \x01(\tR\x02pf\"s\n" +
+	"\x0fListVFsResponse\x12\x1d\n" +
+	"\n" +
+	"pf_address\x18\x01 \x01(\tR\tpfAddress\x12\x17\n" +
+	"\apf_name\x18\x02 \x01(\tR\x06pfName\x12(\n" +
+	"\x03vfs\x18\x03 \x03(\v2\x16.sriov.VirtualFunctionR\x03vfs\"@\n" +
+	"\x13WatchDevicesRequest\x12)\n" +
+	"\x10include_existing\x18\x01 \x01(\bR\x0fincludeExisting\"\xa5\x01\n" +
+	"\vDeviceEvent\x12*\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x16.sriov.DeviceEventTypeR\x04type\x12%\n" +
+	"\x06device\x18\x02 \x01(\v2\r.sriov.DeviceR\x06device\x12%\n" +
+	"\x0echanged_fields\x18\x03 \x03(\tR\rchangedFields\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\"\x9d\x02\n" +
+	"\aVFLease\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04pool\x18\x02 \x01(\tR\x04pool\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x128\n" +
+	"\bmetadata\x18\x04 \x03(\v2\x1c.sriov.VFLease.MetadataEntryR\bmetadata\x12#\n" +
+	"\rpci_addresses\x18\x05 \x03(\tR\fpciAddresses\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\a \x01(\x03R\texpiresAt\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc6\x02\n" +
+	"\x11AllocateVFRequest\x12\x12\n" +
+	"\x04pool\x18\x01 \x01(\tR\x04pool\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12 \n" +
+	"\tnuma_node\x18\x03 \x01(\x05H\x00R\bnumaNode\x88\x01\x01\x12\x1f\n" +
+	"\vstrict_numa\x18\x04 \x01(\bR\n" +
+	"strictNuma\x12\x14\n" +
+	"\x05owner\x18\x05 \x01(\tR\x05owner\x12B\n" +
+	"\bmetadata\x18\x06 \x03(\v2&.sriov.AllocateVFRequest.MetadataEntryR\bmetadata\x12\x1f\n" +
+	"\vttl_seconds\x18\a \x01(\x03R\n" +
+	"ttlSeconds\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\f\n" +
+	"\n" +
+	"_numa_node\":\n" +
+	"\x12AllocateVFResponse\x12$\n" +
+	"\x05lease\x18\x01 \x01(\v2\x0e.sriov.VFLeaseR\x05lease\"-\n" +
+	"\x10ReleaseVFRequest\x12\x19\n" +
+	"\blease_id\x18\x01 \x01(\tR\aleaseId\"\x13\n" +
+	"\x11ReleaseVFResponse\"L\n" +
+	"\x11BindDriverRequest\x12\x1f\n" +
+	"\vpci_address\x18\x01 \x01(\tR\n" +
+	"pciAddress\x12\x16\n" +
+	"\x06driver\x18\x02 \x01(\tR\x06driver\"v\n" +
+	"\x12BindDriverResponse\x12\x1f\n" +
+	"\vpci_address\x18\x01 \x01(\tR\n" +
+	"pciAddress\x12'\n" +
+	"\x0fprevious_driver\x18\x02 \x01(\tR\x0epreviousDriver\x12\x16\n" +
+	"\x06driver\x18\x03 \x01(\tR\x06driver\" \n" +
+	"\x0eListSFsRequest\x12\x0e\n" +
+	"\x02pf\x18\x01 \x01(\tR\x02pf\"o\n" +
+	"\x0fListSFsResponse\x12\x1d\n" +
+	"\n" +
+	"pf_address\x18\x01 \x01(\tR\tpfAddress\x12\x17\n" +
+	"\apf_name\x18\x02 \x01(\tR\x06pfName\x12$\n" +
+	"\x03sfs\x18\x03 \x03(\v2\x12.sriov.SubFunctionR\x03sfs\"f\n" +
+	"\x0fCreateSFRequest\x12\x0e\n" +
+	"\x02pf\x18\x01 \x01(\tR\x02pf\x12\x14\n" +
+	"\x05sfnum\x18\x02 \x01(\rR\x05sfnum\x12\x17\n" +
+	"\ahw_addr\x18\x03 \x01(\tR\x06hwAddr\x12\x14\n" +
+	"\x05state\x18\x04 \x01(\tR\x05state\"6\n" +
+	"\x10CreateSFResponse\x12\"\n" +
+	"\x02sf\x18\x01 \x01(\v2\x12.sriov.SubFunctionR\x02sf\"7\n" +
+	"\x0fDeleteSFRequest\x12\x0e\n" +
+	"\x02pf\x18\x01 \x01(\tR\x02pf\x12\x14\n" +
+	"\x05sfnum\x18\x02 \x01(\rR\x05sfnum\"\x12\n" +
+	"\x10DeleteSFResponse*j\n" +
+	"\x0fDeviceEventType\x12\x1c\n" +
+	"\x18DEVICE_EVENT_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fDEVICE_ADDED\x10\x01\x12\x12\n" +
+	"\x0eDEVICE_REMOVED\x10\x02\x12\x13\n" +
+	"\x0fDEVICE_MODIFIED\x10\x032\x99\x05\n" +
 	"\fSRIOVManager\x12D\n" +
 	"\vListDevices\x12\x19.sriov.ListDevicesRequest\x1a\x1a.sriov.ListDevicesResponse\x12M\n" +
-	"\x0eRefreshDevices\x12\x1c.sriov.RefreshDevicesRequest\x1a\x1d.sriov.RefreshDevicesResponseB&Z$example.com/sriov-plugin/proto;protob\x06proto3"
+	"\x0eRefreshDevices\x12\x1c.sriov.RefreshDevicesRequest\x1a\x1d.sriov.RefreshDevicesResponse\x128\n" +
+	"\aListVFs\x12\x15.sriov.ListVFsRequest\x1a\x16.sriov.ListVFsResponse\x12@\n" +
+	"\fWatchDevices\x12\x1a.sriov.WatchDevicesRequest\x1a\x12.sriov.DeviceEvent0\x01\x12A\n" +
+	"\n" +
+	"AllocateVF\x12\x18.sriov.AllocateVFRequest\x1a\x19.sriov.AllocateVFResponse\x12>\n" +
+	"\tReleaseVF\x12\x17.sriov.ReleaseVFRequest\x1a\x18.sriov.ReleaseVFResponse\x12A\n" +
+	"\n" +
+	"BindDriver\x12\x18.sriov.BindDriverRequest\x1a\x19.sriov.BindDriverResponse\x128\n" +
+	"\aListSFs\x12\x15.sriov.ListSFsRequest\x1a\x16.sriov.ListSFsResponse\x12;\n" +
+	"\bCreateSF\x12\x16.sriov.CreateSFRequest\x1a\x17.sriov.CreateSFResponse\x12;\n" +
+	"\bDeleteSF\x12\x16.sriov.DeleteSFRequest\x1a\x17.sriov.DeleteSFResponseB&Z$example.com/sriov-plugin/proto;protob\x06proto3"
 
 var (
 	file_sriov_proto_rawDescOnce sync.Once
@@ -844,42 +2669,98 @@ func file_sriov_proto_rawDescGZIP() []byte {
 	return file_sriov_proto_rawDescData
 }
 
-var file_sriov_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_sriov_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_sriov_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_sriov_proto_goTypes = []any{
-	(*Empty)(nil),                  // 0: sriov.Empty
-	(*DetailedCapability)(nil),     // 1: sriov.DetailedCapability
-	(*EthtoolFeature)(nil),         // 2: sriov.EthtoolFeature
-	(*EthtoolRingInfo)(nil),        // 3: sriov.EthtoolRingInfo
-	(*EthtoolChannelInfo)(nil),     // 4: sriov.EthtoolChannelInfo
-	(*EthtoolInfo)(nil),            // 5: sriov.EthtoolInfo
-	(*Device)(nil),                 // 6: sriov.Device
-	(*ListDevicesRequest)(nil),     // 7: sriov.ListDevicesRequest
-	(*ListDevicesResponse)(nil),    // 8: sriov.ListDevicesResponse
-	(*RefreshDevicesRequest)(nil),  // 9: sriov.RefreshDevicesRequest
-	(*RefreshDevicesResponse)(nil), // 10: sriov.RefreshDevicesResponse
-	nil,                            // 11: sriov.DetailedCapability.ParametersEntry
-	nil,                            // 12: sriov.Device.DetailedCapabilitiesEntry
-	nil,                            // 13: sriov.Device.NumaDistanceEntry
+	(DeviceEventType)(0),           // 0: sriov.DeviceEventType
+	(*Empty)(nil),                  // 1: sriov.Empty
+	(*DetailedCapability)(nil),     // 2: sriov.DetailedCapability
+	(*EthtoolFeature)(nil),         // 3: sriov.EthtoolFeature
+	(*EthtoolRingInfo)(nil),        // 4: sriov.EthtoolRingInfo
+	(*EthtoolChannelInfo)(nil),     // 5: sriov.EthtoolChannelInfo
+	(*EthtoolInfo)(nil),            // 6: sriov.EthtoolInfo
+	(*VirtualFunction)(nil),        // 7: sriov.VirtualFunction
+	(*Device)(nil),                 // 8: sriov.Device
+	(*SubFunction)(nil),            // 9: sriov.SubFunction
+	(*DevlinkPort)(nil),            // 10: sriov.DevlinkPort
+	(*PortFunction)(nil),           // 11: sriov.PortFunction
+	(*Eswitch)(nil),                // 12: sriov.Eswitch
+	(*ListDevicesRequest)(nil),     // 13: sriov.ListDevicesRequest
+	(*ListDevicesResponse)(nil),    // 14: sriov.ListDevicesResponse
+	(*RefreshDevicesRequest)(nil),  // 15: sriov.RefreshDevicesRequest
+	(*RefreshDevicesResponse)(nil), // 16: sriov.RefreshDevicesResponse
+	(*ListVFsRequest)(nil),         // 17: sriov.ListVFsRequest
+	(*ListVFsResponse)(nil),        // 18: sriov.ListVFsResponse
+	(*WatchDevicesRequest)(nil),    // 19: sriov.WatchDevicesRequest
+	(*DeviceEvent)(nil),            // 20: sriov.DeviceEvent
+	(*VFLease)(nil),                // 21: sriov.VFLease
+	(*AllocateVFRequest)(nil),      // 22: sriov.AllocateVFRequest
+	(*AllocateVFResponse)(nil),     // 23: sriov.AllocateVFResponse
+	(*ReleaseVFRequest)(nil),       // 24: sriov.ReleaseVFRequest
+	(*ReleaseVFResponse)(nil),      // 25: sriov.ReleaseVFResponse
+	(*BindDriverRequest)(nil),      // 26: sriov.BindDriverRequest
+	(*BindDriverResponse)(nil),     // 27: sriov.BindDriverResponse
+	(*ListSFsRequest)(nil),         // 28: sriov.ListSFsRequest
+	(*ListSFsResponse)(nil),        // 29: sriov.ListSFsResponse
+	(*CreateSFRequest)(nil),        // 30: sriov.CreateSFRequest
+	(*CreateSFResponse)(nil),       // 31: sriov.CreateSFResponse
+	(*DeleteSFRequest)(nil),        // 32: sriov.DeleteSFRequest
+	(*DeleteSFResponse)(nil),       // 33: sriov.DeleteSFResponse
+	nil,                            // 34: sriov.DetailedCapability.ParametersEntry
+	nil,                            // 35: sriov.Device.DetailedCapabilitiesEntry
+	nil,                            // 36: sriov.Device.NumaDistanceEntry
+	nil,                            // 37: sriov.VFLease.MetadataEntry
+	nil,                            // 38: sriov.AllocateVFRequest.MetadataEntry
 }
 var file_sriov_proto_depIdxs = []int32{
-	11, // 0: sriov.DetailedCapability.parameters:type_name -> sriov.DetailedCapability.ParametersEntry
-	2,  // 1: sriov.EthtoolInfo.features:type_name -> sriov.EthtoolFeature
-	3,  // 2: sriov.EthtoolInfo.ring:type_name -> sriov.EthtoolRingInfo
-	4,  // 3: sriov.EthtoolInfo.channels:type_name -> sriov.EthtoolChannelInfo
-	12, // 4: sriov.Device.detailed_capabilities:type_name -> sriov.Device.DetailedCapabilitiesEntry
-	5,  // 5: sriov.Device.ethtool_info:type_name -> sriov.EthtoolInfo
-	13, // 6: sriov.Device.numa_distance:type_name -> sriov.Device.NumaDistanceEntry
-	6,  // 7: sriov.ListDevicesResponse.devices:type_name -> sriov.Device
-	1,  // 8: sriov.Device.DetailedCapabilitiesEntry.value:type_name -> sriov.DetailedCapability
-	7,  // 9: sriov.SRIOVManager.ListDevices:input_type -> sriov.ListDevicesRequest
-	9,  // 10: sriov.SRIOVManager.RefreshDevices:input_type -> sriov.RefreshDevicesRequest
-	8,  // 11: sriov.SRIOVManager.ListDevices:output_type -> sriov.ListDevicesResponse
-	10, // 12: sriov.SRIOVManager.RefreshDevices:output_type -> sriov.RefreshDevicesResponse
-	11, // [11:13] is the sub-list for method output_type
-	9,  // [9:11] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	34, // 0: sriov.DetailedCapability.parameters:type_name -> sriov.DetailedCapability.ParametersEntry
+	3,  // 1: sriov.EthtoolInfo.features:type_name -> sriov.EthtoolFeature
+	4,  // 2: sriov.EthtoolInfo.ring:type_name -> sriov.EthtoolRingInfo
+	5,  // 3: sriov.EthtoolInfo.channels:type_name -> sriov.EthtoolChannelInfo
+	11, // 4: sriov.VirtualFunction.port_function:type_name -> sriov.PortFunction
+	35, // 5: sriov.Device.detailed_capabilities:type_name -> sriov.Device.DetailedCapabilitiesEntry
+	6,  // 6: sriov.Device.ethtool_info:type_name -> sriov.EthtoolInfo
+	36, // 7: sriov.Device.numa_distance:type_name -> sriov.Device.NumaDistanceEntry
+	7,  // 8: sriov.Device.vfs:type_name -> sriov.VirtualFunction
+	12, // 9: sriov.Device.eswitch:type_name -> sriov.Eswitch
+	10, // 10: sriov.Device.devlink_ports:type_name -> sriov.DevlinkPort
+	9,  // 11: sriov.Device.sfs:type_name -> sriov.SubFunction
+	11, // 12: sriov.DevlinkPort.function:type_name -> sriov.PortFunction
+	8,  // 13: sriov.ListDevicesResponse.devices:type_name -> sriov.Device
+	7,  // 14: sriov.ListVFsResponse.vfs:type_name -> sriov.VirtualFunction
+	0,  // 15: sriov.DeviceEvent.type:type_name -> sriov.DeviceEventType
+	8,  // 16: sriov.DeviceEvent.device:type_name -> sriov.Device
+	37, // 17: sriov.VFLease.metadata:type_name -> sriov.VFLease.MetadataEntry
+	38, // 18: sriov.AllocateVFRequest.metadata:type_name -> sriov.AllocateVFRequest.MetadataEntry
+	21, // 19: sriov.AllocateVFResponse.lease:type_name -> sriov.VFLease
+	9,  // 20: sriov.ListSFsResponse.sfs:type_name -> sriov.SubFunction
+	9,  // 21: sriov.CreateSFResponse.sf:type_name -> sriov.SubFunction
+	2,  // 22: sriov.Device.DetailedCapabilitiesEntry.value:type_name -> sriov.DetailedCapability
+	13, // 23: sriov.SRIOVManager.ListDevices:input_type -> sriov.ListDevicesRequest
+	15, // 24: sriov.SRIOVManager.RefreshDevices:input_type -> sriov.RefreshDevicesRequest
+	17, // 25: sriov.SRIOVManager.ListVFs:input_type -> sriov.ListVFsRequest
+	19, // 26: sriov.SRIOVManager.WatchDevices:input_type -> sriov.WatchDevicesRequest
+	22, // 27: sriov.SRIOVManager.AllocateVF:input_type -> sriov.AllocateVFRequest
+	24, // 28: sriov.SRIOVManager.ReleaseVF:input_type -> sriov.ReleaseVFRequest
+	26, // 29: sriov.SRIOVManager.BindDriver:input_type -> sriov.BindDriverRequest
+	28, // 30: sriov.SRIOVManager.ListSFs:input_type -> sriov.ListSFsRequest
+	30, // 31: sriov.SRIOVManager.CreateSF:input_type -> sriov.CreateSFRequest
+	32, // 32: sriov.SRIOVManager.DeleteSF:input_type -> sriov.DeleteSFRequest
+	14, // 33: sriov.SRIOVManager.ListDevices:output_type -> sriov.ListDevicesResponse
+	16, // 34: sriov.SRIOVManager.RefreshDevices:output_type -> sriov.RefreshDevicesResponse
+	18, // 35: sriov.SRIOVManager.ListVFs:output_type -> sriov.ListVFsResponse
+	20, // 36: sriov.SRIOVManager.WatchDevices:output_type -> sriov.DeviceEvent
+	23, // 37: sriov.SRIOVManager.AllocateVF:output_type -> sriov.AllocateVFResponse
+	25, // 38: sriov.SRIOVManager.ReleaseVF:output_type -> sriov.ReleaseVFResponse
+	27, // 39: sriov.SRIOVManager.BindDriver:output_type -> sriov.BindDriverResponse
+	29, // 40: sriov.SRIOVManager.ListSFs:output_type -> sriov.ListSFsResponse
+	31, // 41: sriov.SRIOVManager.CreateSF:output_type -> sriov.CreateSFResponse
+	33, // 42: sriov.SRIOVManager.DeleteSF:output_type -> sriov.DeleteSFResponse
+	33, // [33:43] is the sub-list for method output_type
+	23, // [23:33] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_sriov_proto_init() }
@@ -887,18 +2768,20 @@ func file_sriov_proto_init() {
 	if File_sriov_proto != nil {
 		return
 	}
+	file_sriov_proto_msgTypes[21].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_sriov_proto_rawDesc), len(file_sriov_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   14,
+			NumEnums:      1,
+			NumMessages:   38,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_sriov_proto_goTypes,
 		DependencyIndexes: file_sriov_proto_depIdxs,
+		EnumInfos:         file_sriov_proto_enumTypes,
 		MessageInfos:      file_sriov_proto_msgTypes,
 	}.Build()
 	File_sriov_proto = out.File
@@ -21,6 +21,14 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	SRIOVManager_ListDevices_FullMethodName    = "/sriov.SRIOVManager/ListDevices"
 	SRIOVManager_RefreshDevices_FullMethodName = "/sriov.SRIOVManager/RefreshDevices"
+	SRIOVManager_ListVFs_FullMethodName        = "/sriov.SRIOVManager/ListVFs"
+	SRIOVManager_WatchDevices_FullMethodName   = "/sriov.SRIOVManager/WatchDevices"
+	SRIOVManager_AllocateVF_FullMethodName     = "/sriov.SRIOVManager/AllocateVF"
+	SRIOVManager_ReleaseVF_FullMethodName      = "/sriov.SRIOVManager/ReleaseVF"
+	SRIOVManager_BindDriver_FullMethodName     = "/sriov.SRIOVManager/BindDriver"
+	SRIOVManager_ListSFs_FullMethodName        = "/sriov.SRIOVManager/ListSFs"
+	SRIOVManager_CreateSF_FullMethodName       = "/sriov.SRIOVManager/CreateSF"
+	SRIOVManager_DeleteSF_FullMethodName       = "/sriov.SRIOVManager/DeleteSF"
 )
 
 // SRIOVManagerClient is the client API for SRIOVManager service.
@@ -29,6 +37,14 @@ const (
 type SRIOVManagerClient interface {
 	ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error)
 	RefreshDevices(ctx context.Context, in *RefreshDevicesRequest, opts ...grpc.CallOption) (*RefreshDevicesResponse, error)
+	ListVFs(ctx context.Context, in *ListVFsRequest, opts ...grpc.CallOption) (*ListVFsResponse, error)
+	WatchDevices(ctx context.Context, in *WatchDevicesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceEvent], error)
+	AllocateVF(ctx context.Context, in *AllocateVFRequest, opts ...grpc.CallOption) (*AllocateVFResponse, error)
+	ReleaseVF(ctx context.Context, in *ReleaseVFRequest, opts ...grpc.CallOption) (*ReleaseVFResponse, error)
+	BindDriver(ctx context.Context, in *BindDriverRequest, opts ...grpc.CallOption) (*BindDriverResponse, error)
+	ListSFs(ctx context.Context, in *ListSFsRequest, opts ...grpc.CallOption) (*ListSFsResponse, error)
+	CreateSF(ctx context.Context, in *CreateSFRequest, opts ...grpc.CallOption) (*CreateSFResponse, error)
+	DeleteSF(ctx context.Context, in *DeleteSFRequest, opts ...grpc.CallOption) (*DeleteSFResponse, error)
 }
 
 type sRIOVManagerClient struct {
@@ -59,12 +75,109 @@ func (c *sRIOVManagerClient) RefreshDevices(ctx context.Context, in *RefreshDevi
 	return out, nil
 }
 
+func (c *sRIOVManagerClient) ListVFs(ctx context.Context, in *ListVFsRequest, opts ...grpc.CallOption) (*ListVFsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListVFsResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_ListVFs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) WatchDevices(ctx context.Context, in *WatchDevicesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SRIOVManager_ServiceDesc.Streams[0], SRIOVManager_WatchDevices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDevicesRequest, DeviceEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SRIOVManager_WatchDevicesClient = grpc.ServerStreamingClient[DeviceEvent]
+
+func (c *sRIOVManagerClient) AllocateVF(ctx context.Context, in *AllocateVFRequest, opts ...grpc.CallOption) (*AllocateVFResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AllocateVFResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_AllocateVF_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) ReleaseVF(ctx context.Context, in *ReleaseVFRequest, opts ...grpc.CallOption) (*ReleaseVFResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseVFResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_ReleaseVF_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) BindDriver(ctx context.Context, in *BindDriverRequest, opts ...grpc.CallOption) (*BindDriverResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BindDriverResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_BindDriver_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) ListSFs(ctx context.Context, in *ListSFsRequest, opts ...grpc.CallOption) (*ListSFsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSFsResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_ListSFs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) CreateSF(ctx context.Context, in *CreateSFRequest, opts ...grpc.CallOption) (*CreateSFResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateSFResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_CreateSF_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *sRIOVManagerClient) DeleteSF(ctx context.Context, in *DeleteSFRequest, opts ...grpc.CallOption) (*DeleteSFResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSFResponse)
+	err := c.cc.Invoke(ctx, SRIOVManager_DeleteSF_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SRIOVManagerServer is the server API for SRIOVManager service.
 // All implementations must embed UnimplementedSRIOVManagerServer
 // for forward compatibility.
 type SRIOVManagerServer interface {
 	ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error)
 	RefreshDevices(context.Context, *RefreshDevicesRequest) (*RefreshDevicesResponse, error)
+	ListVFs(context.Context, *ListVFsRequest) (*ListVFsResponse, error)
+	WatchDevices(*WatchDevicesRequest, grpc.ServerStreamingServer[DeviceEvent]) error
+	AllocateVF(context.Context, *AllocateVFRequest) (*AllocateVFResponse, error)
+	ReleaseVF(context.Context, *ReleaseVFRequest) (*ReleaseVFResponse, error)
+	BindDriver(context.Context, *BindDriverRequest) (*BindDriverResponse, error)
+	ListSFs(context.Context, *ListSFsRequest) (*ListSFsResponse, error)
+	CreateSF(context.Context, *CreateSFRequest) (*CreateSFResponse, error)
+	DeleteSF(context.Context, *DeleteSFRequest) (*DeleteSFResponse, error)
 	mustEmbedUnimplementedSRIOVManagerServer()
 }
 
@@ -81,6 +194,30 @@ func (UnimplementedSRIOVManagerServer) ListDevices(context.Context, *ListDevices
 func (UnimplementedSRIOVManagerServer) RefreshDevices(context.Context, *RefreshDevicesRequest) (*RefreshDevicesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RefreshDevices not implemented")
 }
+func (UnimplementedSRIOVManagerServer) ListVFs(context.Context, *ListVFsRequest) (*ListVFsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVFs not implemented")
+}
+func (UnimplementedSRIOVManagerServer) WatchDevices(*WatchDevicesRequest, grpc.ServerStreamingServer[DeviceEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDevices not implemented")
+}
+func (UnimplementedSRIOVManagerServer) AllocateVF(context.Context, *AllocateVFRequest) (*AllocateVFResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllocateVF not implemented")
+}
+func (UnimplementedSRIOVManagerServer) ReleaseVF(context.Context, *ReleaseVFRequest) (*ReleaseVFResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseVF not implemented")
+}
+func (UnimplementedSRIOVManagerServer) BindDriver(context.Context, *BindDriverRequest) (*BindDriverResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BindDriver not implemented")
+}
+func (UnimplementedSRIOVManagerServer) ListSFs(context.Context, *ListSFsRequest) (*ListSFsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSFs not implemented")
+}
+func (UnimplementedSRIOVManagerServer) CreateSF(context.Context, *CreateSFRequest) (*CreateSFResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSF not implemented")
+}
+func (UnimplementedSRIOVManagerServer) DeleteSF(context.Context, *DeleteSFRequest) (*DeleteSFResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSF not implemented")
+}
 func (UnimplementedSRIOVManagerServer) mustEmbedUnimplementedSRIOVManagerServer() {}
 func (UnimplementedSRIOVManagerServer) testEmbeddedByValue()                      {}
 
@@ -138,6 +275,143 @@ func _SRIOVManager_RefreshDevices_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SRIOVManager_ListVFs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVFsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).ListVFs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_ListVFs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).ListVFs(ctx, req.(*ListVFsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_WatchDevices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDevicesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SRIOVManagerServer).WatchDevices(m, &grpc.GenericServerStream[WatchDevicesRequest, DeviceEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SRIOVManager_WatchDevicesServer = grpc.ServerStreamingServer[DeviceEvent]
+
+func _SRIOVManager_AllocateVF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateVFRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).AllocateVF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_AllocateVF_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).AllocateVF(ctx, req.(*AllocateVFRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_ReleaseVF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseVFRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).ReleaseVF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_ReleaseVF_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).ReleaseVF(ctx, req.(*ReleaseVFRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_BindDriver_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BindDriverRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).BindDriver(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_BindDriver_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).BindDriver(ctx, req.(*BindDriverRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_ListSFs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSFsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).ListSFs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_ListSFs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).ListSFs(ctx, req.(*ListSFsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_CreateSF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSFRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).CreateSF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_CreateSF_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).CreateSF(ctx, req.(*CreateSFRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SRIOVManager_DeleteSF_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSFRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SRIOVManagerServer).DeleteSF(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SRIOVManager_DeleteSF_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SRIOVManagerServer).DeleteSF(ctx, req.(*DeleteSFRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // SRIOVManager_ServiceDesc is the grpc.ServiceDesc for SRIOVManager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,7 +427,41 @@ var SRIOVManager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RefreshDevices",
 			Handler:    _SRIOVManager_RefreshDevices_Handler,
 		},
+		{
+			MethodName: "ListVFs",
+			Handler:    _SRIOVManager_ListVFs_Handler,
+		},
+		{
+			MethodName: "AllocateVF",
+			Handler:    _SRIOVManager_AllocateVF_Handler,
+		},
+		{
+			MethodName: "ReleaseVF",
+			Handler:    _SRIOVManager_ReleaseVF_Handler,
+		},
+		{
+			MethodName: "BindDriver",
+			Handler:    _SRIOVManager_BindDriver_Handler,
+		},
+		{
+			MethodName: "ListSFs",
+			Handler:    _SRIOVManager_ListSFs_Handler,
+		},
+		{
+			MethodName: "CreateSF",
+			Handler:    _SRIOVManager_CreateSF_Handler,
+		},
+		{
+			MethodName: "DeleteSF",
+			Handler:    _SRIOVManager_DeleteSF_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDevices",
+			Handler:       _SRIOVManager_WatchDevices_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "sriov.proto",
 }